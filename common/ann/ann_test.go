@@ -16,12 +16,15 @@ package ann
 
 import (
 	"bufio"
+	"bytes"
+	"github.com/chewxy/math32"
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	"github.com/zhenghaoz/gorse/base/floats"
 	"github.com/zhenghaoz/gorse/common/datautil"
 	"github.com/zhenghaoz/gorse/common/util"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -122,21 +125,23 @@ func TestMNIST(t *testing.T) {
 	// Create brute-force index
 	bf := NewBruteforce(floats.Euclidean)
 	for _, image := range dat.TrainImages[:trainSize] {
-		_ = bf.Add(image)
+		_, _ = bf.Add(image)
 	}
 
 	// Create HNSW index
 	hnsw := NewHNSW(floats.Euclidean)
 	for _, image := range dat.TrainImages[:trainSize] {
-		_ = hnsw.Add(image)
+		_, _ = hnsw.Add(image)
 	}
 
 	// Test search
 	r := 0.0
 	for _, image := range dat.TestImages[:testSize] {
-		gt := bf.SearchVector(image, 100, false)
+		gt, err := bf.SearchVector(image, 100, false)
+		assert.NoError(t, err)
 		assert.Len(t, gt, 100)
-		scores := hnsw.SearchVector(image, 100, false)
+		scores, err := hnsw.SearchVector(image, 100, false)
+		assert.NoError(t, err)
 		assert.Len(t, scores, 100)
 		r += recall(gt, scores)
 	}
@@ -204,13 +209,13 @@ func TestMovieLens(t *testing.T) {
 	// Create brute-force index
 	bf := NewBruteforce(jaccard)
 	for _, movie := range movies {
-		_ = bf.Add(movie)
+		_, _ = bf.Add(movie)
 	}
 
 	// Create HNSW index
 	hnsw := NewHNSW(jaccard)
 	for _, movie := range movies {
-		_ = hnsw.Add(movie)
+		_, _ = hnsw.Add(movie)
 	}
 
 	// Test search
@@ -225,3 +230,117 @@ func TestMovieLens(t *testing.T) {
 	r /= float64(testSize)
 	assert.Greater(t, r, 0.98)
 }
+
+func randomVectors(n, dim int) [][]float32 {
+	rng := rand.New(rand.NewSource(0))
+	vectors := make([][]float32, n)
+	for i := range vectors {
+		vectors[i] = make([]float32, dim)
+		for j := range vectors[i] {
+			vectors[i][j] = rng.Float32()
+		}
+	}
+	return vectors
+}
+
+func TestHNSWTuneEf(t *testing.T) {
+	vectors := randomVectors(2000, 16)
+	hnsw := NewHNSW(floats.Euclidean)
+	hnsw.SetParams(8, 20)
+	for _, v := range vectors {
+		_, _ = hnsw.Add(v)
+	}
+	ef, r := hnsw.TuneEf(10, 0.95, 100)
+	assert.GreaterOrEqual(t, ef, 10)
+	assert.GreaterOrEqual(t, r, float32(0.95))
+
+	// Verify recall on other queries
+	bf := NewBruteforce(floats.Euclidean)
+	for _, v := range vectors {
+		_, _ = bf.Add(v)
+	}
+	total := 0.0
+	for i := 1000; i < 1100; i++ {
+		gt, err := bf.SearchIndex(i, 10, false)
+		assert.NoError(t, err)
+		scores, err := hnsw.SearchIndex(i, 11, false)
+		assert.NoError(t, err)
+		total += recall(gt, scores)
+	}
+	assert.Greater(t, total/100, 0.9)
+}
+
+func TestHNSWMarshal(t *testing.T) {
+	vectors := randomVectors(500, 16)
+	hnsw := NewHNSW(floats.Euclidean)
+	for _, v := range vectors {
+		_, err := hnsw.Add(v)
+		assert.NoError(t, err)
+	}
+	buf := bytes.NewBuffer(nil)
+	err := hnsw.Marshal(buf)
+	assert.NoError(t, err)
+
+	copied := NewHNSW(floats.Euclidean)
+	err = copied.Unmarshal(buf)
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		expected, err := hnsw.SearchIndex(i, 10, false)
+		assert.NoError(t, err)
+		actual, err := copied.SearchIndex(i, 10, false)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	}
+
+	// Insert after loading
+	_, err = copied.Add(vectors[0])
+	assert.NoError(t, err)
+	scores, err := copied.SearchIndex(500, 2, false)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int{0, 500}, lo.Map(scores, func(v lo.Tuple2[int, float32], _ int) int { return v.A }))
+}
+
+func TestHNSWNaN(t *testing.T) {
+	hnsw := NewHNSW(func(a, b []float32) float32 { return math32.NaN() })
+	index, err := hnsw.Add([]float32{0})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, index)
+	// the vector is kept although it can't be linked
+	index, err = hnsw.Add([]float32{1})
+	assert.Error(t, err)
+	assert.Equal(t, 1, index)
+	_, err = hnsw.SearchIndex(1, 1, false)
+	assert.Error(t, err)
+	_, err = hnsw.SearchVector([]float32{2}, 1, false)
+	assert.Error(t, err)
+}
+
+func benchmarkIndex(b *testing.B, newIndex func() Index) {
+	vectors := randomVectors(10000, 32)
+	b.Run("Build", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			index := newIndex()
+			for _, v := range vectors {
+				_, _ = index.Add(v)
+			}
+		}
+	})
+	b.Run("Query", func(b *testing.B) {
+		index := newIndex()
+		for _, v := range vectors {
+			_, _ = index.Add(v)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, _ = index.SearchIndex(i%len(vectors), 10, true)
+		}
+	})
+}
+
+func BenchmarkBruteforce(b *testing.B) {
+	benchmarkIndex(b, func() Index { return NewBruteforce(floats.Euclidean) })
+}
+
+func BenchmarkHNSW(b *testing.B) {
+	benchmarkIndex(b, func() Index { return NewHNSW(floats.Euclidean) })
+}
@@ -15,6 +15,7 @@
 package ann
 
 import (
+	"github.com/chewxy/math32"
 	"github.com/juju/errors"
 	"github.com/samber/lo"
 	"github.com/zhenghaoz/gorse/base/heap"
@@ -30,10 +31,10 @@ func NewBruteforce[T any](distanceFunc func(a, b T) float32) *Bruteforce[T] {
 	return &Bruteforce[T]{distanceFunc: distanceFunc}
 }
 
-func (b *Bruteforce[T]) Add(v T) int {
+func (b *Bruteforce[T]) Add(v T) (int, error) {
 	// Add vector
 	b.vectors = append(b.vectors, v)
-	return len(b.vectors)
+	return len(b.vectors), nil
 }
 
 func (b *Bruteforce[T]) SearchIndex(q, k int, prune0 bool) ([]lo.Tuple2[int, float32], error) {
@@ -45,7 +46,11 @@ func (b *Bruteforce[T]) SearchIndex(q, k int, prune0 bool) ([]lo.Tuple2[int, flo
 	pq := heap.NewPriorityQueue(true)
 	for i, vec := range b.vectors {
 		if i != q {
-			pq.Push(int32(i), b.distanceFunc(b.vectors[q], vec))
+			d := b.distanceFunc(b.vectors[q], vec)
+			if math32.IsNaN(d) {
+				return nil, errors.Errorf("NaN distance to vector %v", i)
+			}
+			pq.Push(int32(i), d)
 			if pq.Len() > k {
 				pq.Pop()
 			}
@@ -62,11 +67,15 @@ func (b *Bruteforce[T]) SearchIndex(q, k int, prune0 bool) ([]lo.Tuple2[int, flo
 	return scores, nil
 }
 
-func (b *Bruteforce[T]) SearchVector(q T, k int, prune0 bool) []lo.Tuple2[int, float32] {
+func (b *Bruteforce[T]) SearchVector(q T, k int, prune0 bool) ([]lo.Tuple2[int, float32], error) {
 	// Search
 	pq := heap.NewPriorityQueue(true)
 	for i, vec := range b.vectors {
-		pq.Push(int32(i), b.distanceFunc(q, vec))
+		d := b.distanceFunc(q, vec)
+		if math32.IsNaN(d) {
+			return nil, errors.Errorf("NaN distance to vector %v", i)
+		}
+		pq.Push(int32(i), d)
 		if pq.Len() > k {
 			pq.Pop()
 		}
@@ -79,5 +88,5 @@ func (b *Bruteforce[T]) SearchVector(q T, k int, prune0 bool) []lo.Tuple2[int, f
 			scores = append(scores, lo.Tuple2[int, float32]{A: int(value), B: score})
 		}
 	}
-	return scores
+	return scores, nil
 }
@@ -15,11 +15,13 @@
 package ann
 
 import (
+	"encoding/gob"
 	"github.com/chewxy/math32"
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/pkg/errors"
 	"github.com/samber/lo"
 	"github.com/zhenghaoz/gorse/base/heap"
+	"io"
 	"math/rand"
 	"modernc.org/mathutil"
 	"sync"
@@ -51,12 +53,25 @@ func NewHNSW[T any](distanceFunc func(a, b T) float32) *HNSW[T] {
 	}
 }
 
-func (h *HNSW[T]) Add(v T) int {
+// SetParams sets the maximum number of connections per layer and the size of the dynamic candidate list
+// used during construction. It must be called before any vector is added.
+func (h *HNSW[T]) SetParams(maxConnection, efConstruction int) {
+	h.levelFactor = 1.0 / math32.Log(float32(maxConnection))
+	h.maxConnection = maxConnection
+	h.maxConnection0 = 2 * maxConnection
+	h.efConstruction = efConstruction
+}
+
+// Add inserts a vector and returns its index. The vector is kept but not linked if distances to it are invalid, so
+// indices of later vectors stay aligned.
+func (h *HNSW[T]) Add(v T) (int, error) {
 	// Add vector
 	h.vectors = append(h.vectors, v)
 	h.bottomNeighbors = append(h.bottomNeighbors, heap.NewPriorityQueue(false))
-	h.insert(int32(len(h.vectors) - 1))
-	return len(h.vectors) - 1
+	if err := h.insert(int32(len(h.vectors) - 1)); err != nil {
+		return len(h.vectors) - 1, err
+	}
+	return len(h.vectors) - 1, nil
 }
 
 func (h *HNSW[T]) SearchIndex(q, k int, prune0 bool) ([]lo.Tuple2[int, float32], error) {
@@ -64,7 +79,10 @@ func (h *HNSW[T]) SearchIndex(q, k int, prune0 bool) ([]lo.Tuple2[int, float32],
 	if q < 0 || q >= len(h.vectors) {
 		return nil, errors.Errorf("index out of range: %v", q)
 	}
-	w := h.knnSearch(h.vectors[q], k, h.efSearchValue(k))
+	w, err := h.knnSearch(h.vectors[q], k, h.efSearchValue(k))
+	if err != nil {
+		return nil, err
+	}
 	scores := make([]lo.Tuple2[int, float32], 0)
 	for w.Len() > 0 {
 		value, score := w.Pop()
@@ -75,8 +93,11 @@ func (h *HNSW[T]) SearchIndex(q, k int, prune0 bool) ([]lo.Tuple2[int, float32],
 	return scores, nil
 }
 
-func (h *HNSW[T]) SearchVector(q T, k int, prune0 bool) []lo.Tuple2[int, float32] {
-	w := h.knnSearch(q, k, h.efSearchValue(k))
+func (h *HNSW[T]) SearchVector(q T, k int, prune0 bool) ([]lo.Tuple2[int, float32], error) {
+	w, err := h.knnSearch(q, k, h.efSearchValue(k))
+	if err != nil {
+		return nil, err
+	}
 	scores := make([]lo.Tuple2[int, float32], 0)
 	for w.Len() > 0 {
 		value, score := w.Pop()
@@ -84,26 +105,33 @@ func (h *HNSW[T]) SearchVector(q T, k int, prune0 bool) []lo.Tuple2[int, float32
 			scores = append(scores, lo.Tuple2[int, float32]{A: int(value), B: score})
 		}
 	}
-	return scores
+	return scores, nil
 }
 
-func (h *HNSW[T]) knnSearch(q T, k, ef int) *heap.PriorityQueue {
+func (h *HNSW[T]) knnSearch(q T, k, ef int) (*heap.PriorityQueue, error) {
 	var (
-		w           *heap.PriorityQueue                    // set for the current the nearest element
-		enterPoints = h.distance(q, []int32{h.enterPoint}) // get enter point for hnsw
-		topLayer    = len(h.upperNeighbors)                // top layer for hnsw
+		w        *heap.PriorityQueue     // set for the current the nearest element
+		topLayer = len(h.upperNeighbors) // top layer for hnsw
 	)
+	enterPoints, err := h.distance(q, []int32{h.enterPoint}) // get enter point for hnsw
+	if err != nil {
+		return nil, err
+	}
 	for currentLayer := topLayer; currentLayer > 0; currentLayer-- {
-		w = h.searchLayer(q, enterPoints, 1, currentLayer)
+		if w, err = h.searchLayer(q, enterPoints, 1, currentLayer); err != nil {
+			return nil, err
+		}
 		enterPoints = heap.NewPriorityQueue(false)
 		enterPoints.Push(w.Peek())
 	}
-	w = h.searchLayer(q, enterPoints, ef, 0)
-	return h.selectNeighbors(q, w, k)
+	if w, err = h.searchLayer(q, enterPoints, ef, 0); err != nil {
+		return nil, err
+	}
+	return h.selectNeighbors(q, w, k), nil
 }
 
 // insert i-th vector into the vector index.
-func (h *HNSW[T]) insert(q int32) {
+func (h *HNSW[T]) insert(q int32) error {
 	// insert first point
 	var isFirstPoint bool
 	h.initOnce.Do(func() {
@@ -116,23 +144,30 @@ func (h *HNSW[T]) insert(q int32) {
 		}
 	})
 	if isFirstPoint {
-		return
+		return nil
 	}
 
 	var (
-		w           *heap.PriorityQueue                               // list for the currently found nearest elements
-		enterPoints = h.distance(h.vectors[q], []int32{h.enterPoint}) // get enter point for hnsw
-		l           = int(math32.Floor(-math32.Log(rand.Float32()) * h.levelFactor))
-		topLayer    = len(h.upperNeighbors)
+		w        *heap.PriorityQueue // list for the currently found nearest elements
+		l        = int(math32.Floor(-math32.Log(rand.Float32()) * h.levelFactor))
+		topLayer = len(h.upperNeighbors)
 	)
+	enterPoints, err := h.distance(h.vectors[q], []int32{h.enterPoint}) // get enter point for hnsw
+	if err != nil {
+		return err
+	}
 
 	for currentLayer := topLayer; currentLayer >= l+1; currentLayer-- {
-		w = h.searchLayer(h.vectors[q], enterPoints, 1, currentLayer)
+		if w, err = h.searchLayer(h.vectors[q], enterPoints, 1, currentLayer); err != nil {
+			return err
+		}
 		enterPoints = h.selectNeighbors(h.vectors[q], w, 1)
 	}
 
 	for currentLayer := mathutil.Min(topLayer, l); currentLayer >= 0; currentLayer-- {
-		w = h.searchLayer(h.vectors[q], enterPoints, h.efConstruction, currentLayer)
+		if w, err = h.searchLayer(h.vectors[q], enterPoints, h.efConstruction, currentLayer); err != nil {
+			return err
+		}
 		neighbors := h.selectNeighbors(h.vectors[q], w, h.maxConnection)
 		// add bidirectional connections from upperNeighbors to q at layer l_c
 		h.setNeighbourhood(q, currentLayer, neighbors)
@@ -160,9 +195,10 @@ func (h *HNSW[T]) insert(q int32) {
 		h.upperNeighbors = append(h.upperNeighbors, make(map[int32]*heap.PriorityQueue))
 		h.setNeighbourhood(q, topLayer+1, heap.NewPriorityQueue(false))
 	}
+	return nil
 }
 
-func (h *HNSW[T]) searchLayer(q T, enterPoints *heap.PriorityQueue, ef, currentLayer int) *heap.PriorityQueue {
+func (h *HNSW[T]) searchLayer(q T, enterPoints *heap.PriorityQueue, ef, currentLayer int) (*heap.PriorityQueue, error) {
 	var (
 		v          = mapset.NewSet(enterPoints.Values()...) // set of visited elements
 		candidates = enterPoints.Clone()                    // set of candidates
@@ -185,7 +221,11 @@ func (h *HNSW[T]) searchLayer(q T, enterPoints *heap.PriorityQueue, ef, currentL
 				v.Add(e)
 				// get the furthest element from w to q
 				_, fq = w.Peek()
-				if eq := h.distanceFunc(h.vectors[e], q); eq < fq || w.Len() < ef {
+				eq := h.distanceFunc(h.vectors[e], q)
+				if math32.IsNaN(eq) {
+					return nil, errors.Errorf("NaN distance to vector %v", e)
+				}
+				if eq < fq || w.Len() < ef {
 					candidates.Push(e, eq)
 					w.Push(e, eq)
 					if w.Len() > ef {
@@ -196,7 +236,7 @@ func (h *HNSW[T]) searchLayer(q T, enterPoints *heap.PriorityQueue, ef, currentL
 			}
 		}
 	}
-	return w.Reverse()
+	return w.Reverse(), nil
 }
 
 func (h *HNSW[T]) setNeighbourhood(e int32, currentLayer int, connections *heap.PriorityQueue) {
@@ -223,12 +263,17 @@ func (h *HNSW[T]) selectNeighbors(_ T, candidates *heap.PriorityQueue, m int) *h
 	return pq.Reverse()
 }
 
-func (h *HNSW[T]) distance(q T, points []int32) *heap.PriorityQueue {
+// distance returns points ordered by distances to q. NaN distances are rejected since they can't be ordered.
+func (h *HNSW[T]) distance(q T, points []int32) (*heap.PriorityQueue, error) {
 	pq := heap.NewPriorityQueue(false)
 	for _, point := range points {
-		pq.Push(point, h.distanceFunc(h.vectors[point], q))
+		d := h.distanceFunc(h.vectors[point], q)
+		if math32.IsNaN(d) {
+			return nil, errors.Errorf("NaN distance to vector %v", point)
+		}
+		pq.Push(point, d)
 	}
-	return pq
+	return pq, nil
 }
 
 // efSearchValue returns the efSearch value to use, given the current number of elements desired.
@@ -238,3 +283,129 @@ func (h *HNSW[T]) efSearchValue(n int) int {
 	}
 	return mathutil.Max(h.efConstruction, n)
 }
+
+// SetEf sets the size of the dynamic candidate list used during search. A larger ef results in higher recall.
+func (h *HNSW[T]) SetEf(ef int) {
+	h.ef = ef
+}
+
+// Recall estimates the recall of searching k nearest neighbors of given queries against brute-force search.
+func (h *HNSW[T]) Recall(queries []int, k int) float32 {
+	bf := &Bruteforce[T]{distanceFunc: h.distanceFunc, vectors: h.vectors}
+	var hit, total int
+	for _, q := range queries {
+		gt, err := bf.SearchIndex(q, k, false)
+		if err != nil {
+			continue
+		}
+		pred, err := h.SearchIndex(q, k+1, false)
+		if err != nil {
+			continue
+		}
+		s := mapset.NewSet[int]()
+		for _, pair := range gt {
+			s.Add(pair.A)
+		}
+		for _, pair := range pred {
+			if pair.A != q && s.Contains(pair.A) {
+				hit++
+			}
+		}
+		total += len(gt)
+	}
+	if total == 0 {
+		return 1
+	}
+	return float32(hit) / float32(total)
+}
+
+// TuneEf doubles ef until the recall of searching k nearest neighbors, estimated on sampled queries, reaches the
+// target. It returns the chosen ef and the estimated recall.
+func (h *HNSW[T]) TuneEf(k int, target float32, numSamples int) (int, float32) {
+	queries := rand.Perm(len(h.vectors))[:mathutil.Min(numSamples, len(h.vectors))]
+	ef := mathutil.Max(k, 1)
+	for {
+		h.ef = ef
+		recall := h.Recall(queries, k)
+		if recall >= target || ef >= len(h.vectors) {
+			return ef, recall
+		}
+		ef *= 2
+	}
+}
+
+type hnswSnapshot[T any] struct {
+	Vectors         []T
+	BottomNeighbors [][]heap.Elem[int32, float32]
+	UpperNeighbors  []map[int32][]heap.Elem[int32, float32]
+	EnterPoint      int32
+	LevelFactor     float32
+	MaxConnection   int
+	MaxConnection0  int
+	Ef              int
+	EfConstruction  int
+}
+
+// Marshal writes vectors, graph and parameters of the index.
+func (h *HNSW[T]) Marshal(w io.Writer) error {
+	snapshot := hnswSnapshot[T]{
+		Vectors:         h.vectors,
+		BottomNeighbors: make([][]heap.Elem[int32, float32], len(h.bottomNeighbors)),
+		UpperNeighbors:  make([]map[int32][]heap.Elem[int32, float32], len(h.upperNeighbors)),
+		EnterPoint:      h.enterPoint,
+		LevelFactor:     h.levelFactor,
+		MaxConnection:   h.maxConnection,
+		MaxConnection0:  h.maxConnection0,
+		Ef:              h.ef,
+		EfConstruction:  h.efConstruction,
+	}
+	for i, neighbors := range h.bottomNeighbors {
+		snapshot.BottomNeighbors[i] = neighbors.Elems()
+	}
+	for i, layer := range h.upperNeighbors {
+		snapshot.UpperNeighbors[i] = make(map[int32][]heap.Elem[int32, float32], len(layer))
+		for e, neighbors := range layer {
+			snapshot.UpperNeighbors[i][e] = neighbors.Elems()
+		}
+	}
+	return errors.WithStack(gob.NewEncoder(w).Encode(snapshot))
+}
+
+// Unmarshal reads vectors, graph and parameters of the index. The distance function is kept.
+func (h *HNSW[T]) Unmarshal(r io.Reader) error {
+	var snapshot hnswSnapshot[T]
+	if err := gob.NewDecoder(r).Decode(&snapshot); err != nil {
+		return errors.WithStack(err)
+	}
+	h.vectors = snapshot.Vectors
+	h.bottomNeighbors = make([]*heap.PriorityQueue, len(snapshot.BottomNeighbors))
+	for i, elems := range snapshot.BottomNeighbors {
+		h.bottomNeighbors[i] = newPriorityQueue(elems)
+	}
+	h.upperNeighbors = make([]map[int32]*heap.PriorityQueue, len(snapshot.UpperNeighbors))
+	for i, layer := range snapshot.UpperNeighbors {
+		h.upperNeighbors[i] = make(map[int32]*heap.PriorityQueue, len(layer))
+		for e, elems := range layer {
+			h.upperNeighbors[i][e] = newPriorityQueue(elems)
+		}
+	}
+	h.enterPoint = snapshot.EnterPoint
+	h.levelFactor = snapshot.LevelFactor
+	h.maxConnection = snapshot.MaxConnection
+	h.maxConnection0 = snapshot.MaxConnection0
+	h.ef = snapshot.Ef
+	h.efConstruction = snapshot.EfConstruction
+	if len(h.vectors) > 0 {
+		// The first point has been inserted.
+		h.initOnce.Do(func() {})
+	}
+	return nil
+}
+
+func newPriorityQueue(elems []heap.Elem[int32, float32]) *heap.PriorityQueue {
+	pq := heap.NewPriorityQueue(false)
+	for _, elem := range elems {
+		pq.Push(elem.Value, elem.Weight)
+	}
+	return pq
+}
@@ -25,7 +25,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/expr-lang/expr"
 	"github.com/expr-lang/expr/parser"
+	"github.com/expr-lang/expr/vm"
 	"github.com/go-playground/locales/en"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
@@ -35,6 +37,7 @@ import (
 	"github.com/spf13/viper"
 	"github.com/zhenghaoz/gorse/base/log"
 	"github.com/zhenghaoz/gorse/storage"
+	"github.com/zhenghaoz/gorse/storage/data"
 	"go.opentelemetry.io/otel/exporters/jaeger"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
@@ -66,12 +69,13 @@ type Config struct {
 
 // DatabaseConfig is the configuration for the database.
 type DatabaseConfig struct {
-	DataStore        string      `mapstructure:"data_store" validate:"required,data_store"`   // database for data store
-	CacheStore       string      `mapstructure:"cache_store" validate:"required,cache_store"` // database for cache store
-	TablePrefix      string      `mapstructure:"table_prefix"`
-	DataTablePrefix  string      `mapstructure:"data_table_prefix"`
-	CacheTablePrefix string      `mapstructure:"cache_table_prefix"`
-	MySQL            MySQLConfig `mapstructure:"mysql"`
+	DataStore           string        `mapstructure:"data_store" validate:"required,data_store"`   // database for data store
+	CacheStore          string        `mapstructure:"cache_store" validate:"required,cache_store"` // database for cache store
+	TablePrefix         string        `mapstructure:"table_prefix"`
+	DataTablePrefix     string        `mapstructure:"data_table_prefix"`
+	CacheTablePrefix    string        `mapstructure:"cache_table_prefix"`
+	FeedbackDedupWindow time.Duration `mapstructure:"feedback_dedup_window" validate:"gte=0"` // ignore identical feedback within the window
+	MySQL               MySQLConfig   `mapstructure:"mysql"`
 }
 
 type MySQLConfig struct {
@@ -80,56 +84,98 @@ type MySQLConfig struct {
 
 // MasterConfig is the configuration for the master.
 type MasterConfig struct {
-	Port              int           `mapstructure:"port" validate:"gte=0"`        // master port
-	Host              string        `mapstructure:"host"`                         // master host
-	SSLMode           bool          `mapstructure:"ssl_mode"`                     // enable SSL mode
-	SSLCA             string        `mapstructure:"ssl_ca"`                       // SSL CA file
-	SSLCert           string        `mapstructure:"ssl_cert"`                     // SSL certificate file
-	SSLKey            string        `mapstructure:"ssl_key"`                      // SSL key file
-	HttpPort          int           `mapstructure:"http_port" validate:"gte=0"`   // HTTP port
-	HttpHost          string        `mapstructure:"http_host"`                    // HTTP host
-	HttpCorsDomains   []string      `mapstructure:"http_cors_domains"`            // add allowed cors domains
-	HttpCorsMethods   []string      `mapstructure:"http_cors_methods"`            // add allowed cors methods
-	NumJobs           int           `mapstructure:"n_jobs" validate:"gt=0"`       // number of working jobs
-	MetaTimeout       time.Duration `mapstructure:"meta_timeout" validate:"gt=0"` // cluster meta timeout (second)
-	DashboardUserName string        `mapstructure:"dashboard_user_name"`          // dashboard user name
-	DashboardPassword string        `mapstructure:"dashboard_password"`           // dashboard password
-	DashboardRedacted bool          `mapstructure:"dashboard_redacted"`
-	AdminAPIKey       string        `mapstructure:"admin_api_key"`
+	Port                int                   `mapstructure:"port" validate:"gte=0"`        // master port
+	Host                string                `mapstructure:"host"`                         // master host
+	SSLMode             bool                  `mapstructure:"ssl_mode"`                     // enable SSL mode
+	SSLCA               string                `mapstructure:"ssl_ca"`                       // SSL CA file
+	SSLCert             string                `mapstructure:"ssl_cert"`                     // SSL certificate file
+	SSLKey              string                `mapstructure:"ssl_key"`                      // SSL key file
+	HttpPort            int                   `mapstructure:"http_port" validate:"gte=0"`   // HTTP port
+	HttpHost            string                `mapstructure:"http_host"`                    // HTTP host
+	HttpCorsDomains     []string              `mapstructure:"http_cors_domains"`            // add allowed cors domains
+	HttpCorsMethods     []string              `mapstructure:"http_cors_methods"`            // add allowed cors methods
+	NumJobs             int                   `mapstructure:"n_jobs" validate:"gt=0"`       // number of working jobs
+	MetaTimeout         time.Duration         `mapstructure:"meta_timeout" validate:"gt=0"` // cluster meta timeout (second)
+	AutoPruneDeadNodes  bool                  `mapstructure:"auto_prune_dead_nodes"`        // remove nodes without heartbeat in twice of meta timeout
+	DashboardUserName   string                `mapstructure:"dashboard_user_name"`          // dashboard user name
+	DashboardPassword   string                `mapstructure:"dashboard_password"`           // dashboard password
+	DashboardRedacted   bool                  `mapstructure:"dashboard_redacted"`
+	AdminAPIKey         string                `mapstructure:"admin_api_key"`
+	RestoreVersionCheck bool                  `mapstructure:"restore_version_check"`                                       // reject restoring dumps from incompatible versions
+	Webhooks            []WebhookConfig       `mapstructure:"webhooks" validate:"dive"`                                    // webhooks notified on system events
+	DashboardUsers      []DashboardUserConfig `mapstructure:"dashboard_users" validate:"dive"`                             // additional dashboard accounts
+	WarmUpCache         bool                  `mapstructure:"warm_up_cache"`                                               // populate non-personalized recommendations on startup
+	WarmUpTimeout       time.Duration         `mapstructure:"warm_up_timeout" validate:"gt=0"`                             // time limit of warming up the cache
+	CookieSecure        bool                  `mapstructure:"cookie_secure"`                                               // send the session cookie over HTTPS only
+	CookieSameSite      string                `mapstructure:"cookie_same_site" validate:"omitempty,oneof=lax strict none"` // SameSite mode of the session cookie
+	CookieDomain        string                `mapstructure:"cookie_domain"`                                               // domain of the session cookie
+	CookieMaxAge        time.Duration         `mapstructure:"cookie_max_age" validate:"gte=0"`                             // lifetime of the session cookie
+	PopulationThreshold int                   `mapstructure:"population_threshold" validate:"gte=0"`                       // minimal change of users or items shown in population history
+	MaxUploadSize       int64                 `mapstructure:"max_upload_size" validate:"gte=0"`                            // maximal size of uploaded files of imports in bytes
+	DebugMode           bool                  `mapstructure:"debug_mode"`                                                  // enable debug endpoints of the dashboard
+	StatsRefresh        time.Duration         `mapstructure:"stats_refresh" validate:"gt=0"`                               // interval of refreshing dashboard stats
+}
+
+// DashboardUserConfig is an additional account of the dashboard. Users of the read_only role can view the dashboard but
+// can't modify data or configurations.
+type DashboardUserConfig struct {
+	Name     string `mapstructure:"name" validate:"required"`
+	Password string `mapstructure:"password" validate:"required"`
+	Role     string `mapstructure:"role" validate:"oneof=admin read_only"`
+}
+
+// WebhookConfig is the configuration of a webhook. All events are subscribed if Events is empty.
+type WebhookConfig struct {
+	URL    string   `mapstructure:"url" validate:"required,url"`
+	Events []string `mapstructure:"events" validate:"dive,oneof=model.retrained cluster.node_joined cluster.node_left data.purged item.inserted"`
+	Secret string   `mapstructure:"secret"`
 }
 
 // ServerConfig is the configuration for the server.
 type ServerConfig struct {
-	APIKey         string        `mapstructure:"api_key"`                      // default number of returned items
-	DefaultN       int           `mapstructure:"default_n" validate:"gt=0"`    // secret key for RESTful APIs (SSL required)
-	ClockError     time.Duration `mapstructure:"clock_error" validate:"gte=0"` // clock error in the cluster in seconds
-	AutoInsertUser bool          `mapstructure:"auto_insert_user"`             // insert new users while inserting feedback
-	AutoInsertItem bool          `mapstructure:"auto_insert_item"`             // insert new items while inserting feedback
-	CacheExpire    time.Duration `mapstructure:"cache_expire" validate:"gt=0"` // server-side cache expire time
+	APIKey               string        `mapstructure:"api_key"`                            // default number of returned items
+	DefaultN             int           `mapstructure:"default_n" validate:"gt=0"`          // secret key for RESTful APIs (SSL required)
+	ClockError           time.Duration `mapstructure:"clock_error" validate:"gte=0"`       // clock error in the cluster in seconds
+	AutoInsertUser       bool          `mapstructure:"auto_insert_user"`                   // insert new users while inserting feedback
+	AutoInsertItem       bool          `mapstructure:"auto_insert_item"`                   // insert new items while inserting feedback
+	CacheExpire          time.Duration `mapstructure:"cache_expire" validate:"gt=0"`       // server-side cache expire time
+	IdempotencyTTL       time.Duration `mapstructure:"idempotency_ttl" validate:"gte=0"`   // expire time of idempotency keys
+	StrictFeedbackType   bool          `mapstructure:"strict_feedback_type"`               // reject feedback of unknown types
+	RejectDuplicateUsers bool          `mapstructure:"reject_duplicate_users"`             // reject batches of users with duplicate ids
+	RequestTimeout       time.Duration `mapstructure:"request_timeout" validate:"gte=0"`   // timeout of requests to other routes
+	RecommendTimeout     time.Duration `mapstructure:"recommend_timeout" validate:"gte=0"` // timeout of recommendation requests
+	SearchTimeout        time.Duration `mapstructure:"search_timeout" validate:"gte=0"`    // timeout of search requests
+	DashboardTimeout     time.Duration `mapstructure:"dashboard_timeout" validate:"gte=0"` // timeout of dashboard requests
+	ImportTimeout        time.Duration `mapstructure:"import_timeout" validate:"gte=0"`    // timeout of import requests
 }
 
 // RecommendConfig is the configuration of recommendation setup.
 type RecommendConfig struct {
-	CacheSize       int                     `mapstructure:"cache_size" validate:"gt=0"`
-	CacheExpire     time.Duration           `mapstructure:"cache_expire" validate:"gt=0"`
-	ActiveUserTTL   int                     `mapstructure:"active_user_ttl" validate:"gte=0"`
-	DataSource      DataSourceConfig        `mapstructure:"data_source"`
-	NonPersonalized []NonPersonalizedConfig `mapstructure:"non-personalized" validate:"dive"`
-	Popular         PopularConfig           `mapstructure:"popular"`
-	ItemToItem      []ItemToItemConfig      `mapstructure:"item-to-item" validate:"dive"`
-	UserNeighbors   NeighborsConfig         `mapstructure:"user_neighbors"`
-	ItemNeighbors   NeighborsConfig         `mapstructure:"item_neighbors"`
-	Collaborative   CollaborativeConfig     `mapstructure:"collaborative"`
-	Replacement     ReplacementConfig       `mapstructure:"replacement"`
-	Offline         OfflineConfig           `mapstructure:"offline"`
-	Online          OnlineConfig            `mapstructure:"online"`
+	CacheSize           int                     `mapstructure:"cache_size" validate:"gt=0"`
+	CacheExpire         time.Duration           `mapstructure:"cache_expire" validate:"gt=0"`
+	ActiveUserTTL       int                     `mapstructure:"active_user_ttl" validate:"gte=0"`
+	CustomScorers       []string                `mapstructure:"custom_scorers" validate:"dive,required"`
+	CustomScorerTimeout time.Duration           `mapstructure:"custom_scorer_timeout" validate:"gt=0"`
+	DataSource          DataSourceConfig        `mapstructure:"data_source"`
+	NonPersonalized     []NonPersonalizedConfig `mapstructure:"non-personalized" validate:"dive"`
+	Popular             PopularConfig           `mapstructure:"popular"`
+	ItemToItem          []ItemToItemConfig      `mapstructure:"item-to-item" validate:"dive"`
+	UserNeighbors       NeighborsConfig         `mapstructure:"user_neighbors"`
+	ItemNeighbors       ItemNeighborsConfig     `mapstructure:"item_neighbors"`
+	Collaborative       CollaborativeConfig     `mapstructure:"collaborative"`
+	Replacement         ReplacementConfig       `mapstructure:"replacement"`
+	Offline             OfflineConfig           `mapstructure:"offline"`
+	Online              OnlineConfig            `mapstructure:"online"`
 }
 
 type DataSourceConfig struct {
 	PositiveFeedbackTypes []string `mapstructure:"positive_feedback_types"`                // positive feedback type
 	ReadFeedbackTypes     []string `mapstructure:"read_feedback_types"`                    // feedback type for read event
+	ExcludedFeedbackTypes []string `mapstructure:"excluded_feedback_types"`                // feedback types excluded from training
 	PositiveFeedbackTTL   uint     `mapstructure:"positive_feedback_ttl" validate:"gte=0"` // time-to-live of positive feedbacks
 	ItemTTL               uint     `mapstructure:"item_ttl" validate:"gte=0"`              // item-to-live of items
+	MaxLabelsPerUser      int      `mapstructure:"max_labels_per_user" validate:"gte=0"`   // maximum number of labels per user
+	TruncateLabels        bool     `mapstructure:"truncate_labels"`                        // truncate exceeded labels instead of rejecting users
 }
 
 type NonPersonalizedConfig struct {
@@ -144,12 +190,30 @@ type PopularConfig struct {
 
 type NeighborsConfig struct {
 	NeighborType string `mapstructure:"neighbor_type" validate:"oneof=auto similar related ''"`
+	NumNeighbors int    `mapstructure:"num_neighbors" validate:"gte=0"`
+}
+
+// GetNumNeighbors returns the number of neighbors to store. The cache size is used if not set.
+func (config *NeighborsConfig) GetNumNeighbors(cacheSize int) int {
+	if config.NumNeighbors > 0 {
+		return config.NumNeighbors
+	}
+	return cacheSize
+}
+
+type ItemNeighborsConfig struct {
+	NeighborsConfig `mapstructure:",squash"`
+	EmbeddingLabel  string  `mapstructure:"embedding_label"`
+	MaxConnection   int     `mapstructure:"max_connection" validate:"gt=0"`
+	EfConstruction  int     `mapstructure:"ef_construction" validate:"gt=0"`
+	RecallTarget    float32 `mapstructure:"recall_target" validate:"gte=0,lte=1"`
 }
 
 type ItemToItemConfig struct {
-	Name   string `mapstructure:"name" json:"name"`
-	Type   string `mapstructure:"type" json:"type" validate:"oneof=embedding tags users"`
-	Column string `mapstructure:"column" json:"column" validate:"item_expr"`
+	Name      string  `mapstructure:"name" json:"name"`
+	Type      string  `mapstructure:"type" json:"type" validate:"oneof=embedding tags users"`
+	Column    string  `mapstructure:"column" json:"column" validate:"item_expr"`
+	Shrinkage float32 `mapstructure:"shrinkage" json:"shrinkage" validate:"gte=0"`
 }
 
 func (config *ItemToItemConfig) Hash() string {
@@ -157,6 +221,10 @@ func (config *ItemToItemConfig) Hash() string {
 	hash.Write([]byte(config.Name))
 	hash.Write([]byte(config.Type))
 	hash.Write([]byte(config.Column))
+	// digests of recommenders without shrinkage are kept unchanged
+	if config.Shrinkage != 0 {
+		_, _ = fmt.Fprintf(hash, "shrinkage=%g", config.Shrinkage)
+	}
 	return string(hash.Sum(nil))
 }
 
@@ -166,6 +234,9 @@ type CollaborativeConfig struct {
 	ModelSearchEpoch      int           `mapstructure:"model_search_epoch" validate:"gt=0"`
 	ModelSearchTrials     int           `mapstructure:"model_search_trials" validate:"gt=0"`
 	EnableModelSizeSearch bool          `mapstructure:"enable_model_size_search"`
+	NegativeSampler       string        `mapstructure:"negative_sampler" validate:"oneof=uniform popularity hard ''"`
+	SplitStrategy         string        `mapstructure:"split_strategy" validate:"oneof=leave_one_out random temporal ''"`
+	SplitRatio            float32       `mapstructure:"split_ratio" validate:"gt=0,lt=1"`
 }
 
 type ReplacementConfig struct {
@@ -188,8 +259,34 @@ type OfflineConfig struct {
 }
 
 type OnlineConfig struct {
-	FallbackRecommend            []string `mapstructure:"fallback_recommend"`
-	NumFeedbackFallbackItemBased int      `mapstructure:"num_feedback_fallback_item_based" validate:"gt=0"`
+	Mode                         string             `mapstructure:"mode" validate:"oneof=fallback blend"`
+	BlendWeights                 map[string]float64 `mapstructure:"blend_weights" validate:"dive,keys,oneof=offline collaborative latest popular,endkeys,gte=0"`
+	Normalization                string             `mapstructure:"normalization" validate:"oneof=min_max z_score"`
+	FallbackRecommend            []string           `mapstructure:"fallback_recommend"`
+	NumFeedbackFallbackItemBased int                `mapstructure:"num_feedback_fallback_item_based" validate:"gt=0"`
+	MaxRecommendSize             int                `mapstructure:"max_recommend_size" validate:"gte=0"`
+	WriteBackRecommend           bool               `mapstructure:"write_back_recommend"`
+	WriteBackRecommendTTL        time.Duration      `mapstructure:"write_back_recommend_ttl" validate:"gt=0"`
+	ScoreExpr                    string             `mapstructure:"score_expr" validate:"omitempty,score_expr"`
+}
+
+// CompileScoreExpr compiles a score expression of the original score, the item and the age of the item. The
+// expression must return a number.
+func CompileScoreExpr(expression string) (*vm.Program, error) {
+	program, err := expr.Compile(expression, expr.Env(map[string]any{
+		"score": float64(0),
+		"item":  data.Item{},
+		"age":   time.Duration(0),
+	}))
+	if err != nil {
+		return nil, err
+	}
+	switch program.Node().Type().Kind() {
+	case reflect.Float64, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Interface:
+	default:
+		return nil, errors.New("score expression must return float64")
+	}
+	return program, nil
 }
 
 type TracingConfig struct {
@@ -221,39 +318,60 @@ func GetDefaultConfig() *Config {
 			},
 		},
 		Master: MasterConfig{
-			Port:            8086,
-			Host:            "0.0.0.0",
-			HttpPort:        8088,
-			HttpHost:        "0.0.0.0",
-			HttpCorsDomains: []string{".*"},
-			HttpCorsMethods: []string{"GET", "POST", "PUT", "DELETE", "PATCH"},
-			NumJobs:         1,
-			MetaTimeout:     10 * time.Second,
+			Port:               8086,
+			Host:               "0.0.0.0",
+			HttpPort:           8088,
+			HttpHost:           "0.0.0.0",
+			HttpCorsDomains:    []string{".*"},
+			HttpCorsMethods:    []string{"GET", "POST", "PUT", "DELETE", "PATCH"},
+			NumJobs:            1,
+			MetaTimeout:        10 * time.Second,
+			AutoPruneDeadNodes: true,
+			WarmUpTimeout:      5 * time.Minute,
+			CookieSecure:       true,
+			CookieSameSite:     "lax",
+			MaxUploadSize:      1 << 30,
+			StatsRefresh:       10 * time.Second,
 		},
 		Server: ServerConfig{
-			DefaultN:       10,
-			ClockError:     5 * time.Second,
-			AutoInsertUser: true,
-			AutoInsertItem: true,
-			CacheExpire:    10 * time.Second,
+			DefaultN:         10,
+			ClockError:       5 * time.Second,
+			AutoInsertUser:   true,
+			AutoInsertItem:   true,
+			CacheExpire:      10 * time.Second,
+			IdempotencyTTL:   24 * time.Hour,
+			RequestTimeout:   time.Minute,
+			RecommendTimeout: 30 * time.Second,
+			SearchTimeout:    30 * time.Second,
+			DashboardTimeout: 5 * time.Minute,
+			ImportTimeout:    time.Hour,
 		},
 		Recommend: RecommendConfig{
-			CacheSize:   100,
-			CacheExpire: 72 * time.Hour,
+			CacheSize:           100,
+			CacheExpire:         72 * time.Hour,
+			CustomScorerTimeout: time.Second,
 			Popular: PopularConfig{
 				PopularWindow: 180 * 24 * time.Hour,
 			},
 			UserNeighbors: NeighborsConfig{
 				NeighborType: "auto",
 			},
-			ItemNeighbors: NeighborsConfig{
-				NeighborType: "auto",
+			ItemNeighbors: ItemNeighborsConfig{
+				NeighborsConfig: NeighborsConfig{
+					NeighborType: "auto",
+				},
+				MaxConnection:  48,
+				EfConstruction: 100,
+				RecallTarget:   0.9,
 			},
 			Collaborative: CollaborativeConfig{
 				ModelFitPeriod:    60 * time.Minute,
 				ModelSearchPeriod: 180 * time.Minute,
 				ModelSearchEpoch:  100,
 				ModelSearchTrials: 10,
+				NegativeSampler:   "uniform",
+				SplitStrategy:     "leave_one_out",
+				SplitRatio:        0.2,
 			},
 			Replacement: ReplacementConfig{
 				EnableReplacement:        false,
@@ -271,8 +389,11 @@ func GetDefaultConfig() *Config {
 				EnableClickThroughPrediction: false,
 			},
 			Online: OnlineConfig{
+				Mode:                         "fallback",
+				Normalization:                "min_max",
 				FallbackRecommend:            []string{"latest"},
 				NumFeedbackFallbackItemBased: 10,
+				WriteBackRecommendTTL:        24 * time.Hour,
 			},
 		},
 		Tracing: TracingConfig{
@@ -308,6 +429,9 @@ func (config *Config) ItemNeighborDigest() string {
 	} else {
 		hash.Write([]byte("-"))
 	}
+	if config.Recommend.ItemNeighbors.EmbeddingLabel != "" {
+		hash.Write([]byte(fmt.Sprintf("-%s", config.Recommend.ItemNeighbors.EmbeddingLabel)))
+	}
 	return string(hash.Sum(nil))
 }
 
@@ -484,6 +608,16 @@ func setDefault() {
 	viper.SetDefault("master.http_cors_methods", defaultConfig.Master.HttpCorsMethods)
 	viper.SetDefault("master.n_jobs", defaultConfig.Master.NumJobs)
 	viper.SetDefault("master.meta_timeout", defaultConfig.Master.MetaTimeout)
+	viper.SetDefault("master.auto_prune_dead_nodes", defaultConfig.Master.AutoPruneDeadNodes)
+	viper.SetDefault("master.restore_version_check", defaultConfig.Master.RestoreVersionCheck)
+	viper.SetDefault("master.warm_up_cache", defaultConfig.Master.WarmUpCache)
+	viper.SetDefault("master.warm_up_timeout", defaultConfig.Master.WarmUpTimeout)
+	viper.SetDefault("master.cookie_secure", defaultConfig.Master.CookieSecure)
+	viper.SetDefault("master.cookie_same_site", defaultConfig.Master.CookieSameSite)
+	viper.SetDefault("master.population_threshold", defaultConfig.Master.PopulationThreshold)
+	viper.SetDefault("master.max_upload_size", defaultConfig.Master.MaxUploadSize)
+	viper.SetDefault("master.debug_mode", defaultConfig.Master.DebugMode)
+	viper.SetDefault("master.stats_refresh", defaultConfig.Master.StatsRefresh)
 	// [server]
 	viper.SetDefault("server.api_key", defaultConfig.Server.APIKey)
 	viper.SetDefault("server.default_n", defaultConfig.Server.DefaultN)
@@ -491,20 +625,38 @@ func setDefault() {
 	viper.SetDefault("server.auto_insert_user", defaultConfig.Server.AutoInsertUser)
 	viper.SetDefault("server.auto_insert_item", defaultConfig.Server.AutoInsertItem)
 	viper.SetDefault("server.cache_expire", defaultConfig.Server.CacheExpire)
+	viper.SetDefault("server.idempotency_ttl", defaultConfig.Server.IdempotencyTTL)
+	viper.SetDefault("server.strict_feedback_type", defaultConfig.Server.StrictFeedbackType)
+	viper.SetDefault("server.reject_duplicate_users", defaultConfig.Server.RejectDuplicateUsers)
+	viper.SetDefault("server.request_timeout", defaultConfig.Server.RequestTimeout)
+	viper.SetDefault("server.recommend_timeout", defaultConfig.Server.RecommendTimeout)
+	viper.SetDefault("server.search_timeout", defaultConfig.Server.SearchTimeout)
+	viper.SetDefault("server.dashboard_timeout", defaultConfig.Server.DashboardTimeout)
+	viper.SetDefault("server.import_timeout", defaultConfig.Server.ImportTimeout)
 	// [recommend]
 	viper.SetDefault("recommend.cache_size", defaultConfig.Recommend.CacheSize)
 	viper.SetDefault("recommend.cache_expire", defaultConfig.Recommend.CacheExpire)
+	viper.SetDefault("recommend.custom_scorer_timeout", defaultConfig.Recommend.CustomScorerTimeout)
 	// [recommend.popular]
 	viper.SetDefault("recommend.popular.popular_window", defaultConfig.Recommend.Popular.PopularWindow)
 	// [recommend.user_neighbors]
 	viper.SetDefault("recommend.user_neighbors.neighbor_type", defaultConfig.Recommend.UserNeighbors.NeighborType)
+	viper.SetDefault("recommend.user_neighbors.num_neighbors", defaultConfig.Recommend.UserNeighbors.NumNeighbors)
 	// [recommend.item_neighbors]
 	viper.SetDefault("recommend.item_neighbors.neighbor_type", defaultConfig.Recommend.ItemNeighbors.NeighborType)
+	viper.SetDefault("recommend.item_neighbors.num_neighbors", defaultConfig.Recommend.ItemNeighbors.NumNeighbors)
+	viper.SetDefault("recommend.item_neighbors.embedding_label", defaultConfig.Recommend.ItemNeighbors.EmbeddingLabel)
+	viper.SetDefault("recommend.item_neighbors.max_connection", defaultConfig.Recommend.ItemNeighbors.MaxConnection)
+	viper.SetDefault("recommend.item_neighbors.ef_construction", defaultConfig.Recommend.ItemNeighbors.EfConstruction)
+	viper.SetDefault("recommend.item_neighbors.recall_target", defaultConfig.Recommend.ItemNeighbors.RecallTarget)
 	// [recommend.collaborative]
 	viper.SetDefault("recommend.collaborative.model_fit_period", defaultConfig.Recommend.Collaborative.ModelFitPeriod)
 	viper.SetDefault("recommend.collaborative.model_search_period", defaultConfig.Recommend.Collaborative.ModelSearchPeriod)
 	viper.SetDefault("recommend.collaborative.model_search_epoch", defaultConfig.Recommend.Collaborative.ModelSearchEpoch)
 	viper.SetDefault("recommend.collaborative.model_search_trials", defaultConfig.Recommend.Collaborative.ModelSearchTrials)
+	viper.SetDefault("recommend.collaborative.negative_sampler", defaultConfig.Recommend.Collaborative.NegativeSampler)
+	viper.SetDefault("recommend.collaborative.split_strategy", defaultConfig.Recommend.Collaborative.SplitStrategy)
+	viper.SetDefault("recommend.collaborative.split_ratio", defaultConfig.Recommend.Collaborative.SplitRatio)
 	// [recommend.replacement]
 	viper.SetDefault("recommend.replacement.enable_replacement", defaultConfig.Recommend.Replacement.EnableReplacement)
 	viper.SetDefault("recommend.replacement.positive_replacement_decay", defaultConfig.Recommend.Replacement.PositiveReplacementDecay)
@@ -519,8 +671,13 @@ func setDefault() {
 	viper.SetDefault("recommend.offline.enable_collaborative_recommend", defaultConfig.Recommend.Offline.EnableColRecommend)
 	viper.SetDefault("recommend.offline.enable_click_through_prediction", defaultConfig.Recommend.Offline.EnableClickThroughPrediction)
 	// [recommend.online]
+	viper.SetDefault("recommend.online.mode", defaultConfig.Recommend.Online.Mode)
+	viper.SetDefault("recommend.online.normalization", defaultConfig.Recommend.Online.Normalization)
 	viper.SetDefault("recommend.online.fallback_recommend", defaultConfig.Recommend.Online.FallbackRecommend)
 	viper.SetDefault("recommend.online.num_feedback_fallback_item_based", defaultConfig.Recommend.Online.NumFeedbackFallbackItemBased)
+	viper.SetDefault("recommend.online.max_recommend_size", defaultConfig.Recommend.Online.MaxRecommendSize)
+	viper.SetDefault("recommend.online.write_back_recommend", defaultConfig.Recommend.Online.WriteBackRecommend)
+	viper.SetDefault("recommend.online.write_back_recommend_ttl", defaultConfig.Recommend.Online.WriteBackRecommendTTL)
 	// [tracing]
 	viper.SetDefault("tracing.exporter", defaultConfig.Tracing.Exporter)
 	viper.SetDefault("tracing.sampler", defaultConfig.Tracing.Sampler)
@@ -654,6 +811,12 @@ func (config *Config) Validate(oneModel bool) error {
 	}); err != nil {
 		return errors.Trace(err)
 	}
+	if err := validate.RegisterValidation("score_expr", func(fl validator.FieldLevel) bool {
+		_, err := CompileScoreExpr(fl.Field().String())
+		return err == nil
+	}); err != nil {
+		return errors.Trace(err)
+	}
 	validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
 		return strings.SplitN(fld.Tag.Get("mapstructure"), ",", 2)[0]
 	})
@@ -688,6 +851,14 @@ func (config *Config) Validate(oneModel bool) error {
 		}); err != nil {
 			return errors.Trace(err)
 		}
+		if err := validate.RegisterTranslation("score_expr", trans, func(ut ut.Translator) error {
+			return ut.Add("score_expr", "invalid score expression", true)
+		}, func(ut ut.Translator, fe validator.FieldError) string {
+			t, _ := ut.T("score_expr", fe.Field())
+			return t
+		}); err != nil {
+			return errors.Trace(err)
+		}
 		errs := err.(validator.ValidationErrors)
 		for _, e := range errs {
 			return errors.New(e.Translate(trans))
@@ -39,10 +39,39 @@ func TestUnmarshal(t *testing.T) {
 	text = strings.Replace(text, "dashboard_user_name = \"\"", "dashboard_user_name = \"admin\"", -1)
 	text = strings.Replace(text, "dashboard_password = \"\"", "dashboard_password = \"password\"", -1)
 	text = strings.Replace(text, "admin_api_key = \"\"", "admin_api_key = \"super_api_key\"", -1)
+	text = strings.Replace(text, "# [[master.webhooks]]", "[[master.webhooks]]", -1)
+	text = strings.Replace(text, "# url = ", "url = ", -1)
+	text = strings.Replace(text, "# events = ", "events = ", -1)
+	text = strings.Replace(text, "# secret = ", "secret = ", -1)
+	text = strings.Replace(text, "# [[master.dashboard_users]]", "[[master.dashboard_users]]", -1)
+	text = strings.Replace(text, "# name = \"viewer\"", "name = \"viewer\"", -1)
+	text = strings.Replace(text, "# password = \"viewer_pass\"", "password = \"viewer_pass\"", -1)
+	text = strings.Replace(text, "# role = \"read_only\"", "role = \"read_only\"", -1)
 	text = strings.Replace(text, "api_key = \"\"", "api_key = \"19260817\"", -1)
 	text = strings.Replace(text, "table_prefix = \"\"", "table_prefix = \"gorse_\"", -1)
 	text = strings.Replace(text, "cache_table_prefix = \"gorse_\"", "cache_table_prefix = \"gorse_cache_\"", -1)
 	text = strings.Replace(text, "data_table_prefix = \"gorse_\"", "data_table_prefix = \"gorse_data_\"", -1)
+	text = strings.Replace(text, "feedback_dedup_window = \"0s\"", "feedback_dedup_window = \"1m\"", -1)
+	text = strings.Replace(text, "max_recommend_size = 0", "max_recommend_size = 100", -1)
+	text = strings.Replace(text, "write_back_recommend = false", "write_back_recommend = true", -1)
+	text = strings.Replace(text, "auto_prune_dead_nodes = true", "auto_prune_dead_nodes = false", -1)
+	text = strings.Replace(text, "restore_version_check = false", "restore_version_check = true", -1)
+	text = strings.Replace(text, "warm_up_cache = false", "warm_up_cache = true", -1)
+	text = strings.Replace(text, "cookie_same_site = \"lax\"", "cookie_same_site = \"strict\"", -1)
+	text = strings.Replace(text, "cookie_domain = \"\"", "cookie_domain = \"gorse.io\"", -1)
+	text = strings.Replace(text, "cookie_max_age = \"0s\"", "cookie_max_age = \"24h\"", -1)
+	text = strings.Replace(text, "population_threshold = 0", "population_threshold = 100", -1)
+	text = strings.Replace(text, "max_upload_size = 1073741824", "max_upload_size = 1048576", -1)
+	text = strings.Replace(text, "debug_mode = false", "debug_mode = true", -1)
+	text = strings.Replace(text, "stats_refresh = \"10s\"", "stats_refresh = \"30s\"", -1)
+	text = strings.Replace(text, "num_neighbors = 0", "num_neighbors = 20", -1)
+	text = strings.Replace(text, "embedding_label = \"\"", "embedding_label = \"embedding\"", -1)
+	text = strings.Replace(text, "negative_sampler = \"uniform\"", "negative_sampler = \"popularity\"", -1)
+	text = strings.Replace(text, "split_strategy = \"leave_one_out\"", "split_strategy = \"temporal\"", -1)
+	text = strings.Replace(text, "excluded_feedback_types = []", "excluded_feedback_types = [\"bot\"]", -1)
+	text = strings.Replace(text, "mode = \"fallback\"", "mode = \"blend\"", -1)
+	text = strings.Replace(text, "blend_weights = {}", "blend_weights = { collaborative = 0.7, popular = 0.3 }", -1)
+	text = strings.Replace(text, "normalization = \"min_max\"", "normalization = \"z_score\"", -1)
 	text = strings.Replace(text, "http_cors_domains = []", "http_cors_domains = [\".*\"]", -1)
 	text = strings.Replace(text, "http_cors_methods = []", "http_cors_methods = [\"GET\",\"PATCH\",\"POST\"]", -1)
 	text = strings.Replace(text, "issuer = \"\"", "issuer = \"https://accounts.google.com\"", -1)
@@ -69,6 +98,7 @@ func TestUnmarshal(t *testing.T) {
 			assert.Equal(t, "gorse_", config.Database.TablePrefix)
 			assert.Equal(t, "gorse_cache_", config.Database.CacheTablePrefix)
 			assert.Equal(t, "gorse_data_", config.Database.DataTablePrefix)
+			assert.Equal(t, time.Minute, config.Database.FeedbackDedupWindow)
 			assert.Equal(t, "READ-UNCOMMITTED", config.Database.MySQL.IsolationLevel)
 			// [master]
 			assert.Equal(t, 8086, config.Master.Port)
@@ -83,9 +113,31 @@ func TestUnmarshal(t *testing.T) {
 			assert.Equal(t, []string{"GET", "PATCH", "POST"}, config.Master.HttpCorsMethods)
 			assert.Equal(t, 1, config.Master.NumJobs)
 			assert.Equal(t, 10*time.Second, config.Master.MetaTimeout)
+			assert.False(t, config.Master.AutoPruneDeadNodes)
 			assert.Equal(t, "admin", config.Master.DashboardUserName)
 			assert.Equal(t, "password", config.Master.DashboardPassword)
 			assert.Equal(t, "super_api_key", config.Master.AdminAPIKey)
+			assert.True(t, config.Master.RestoreVersionCheck)
+			assert.True(t, config.Master.WarmUpCache)
+			assert.Equal(t, 5*time.Minute, config.Master.WarmUpTimeout)
+			assert.True(t, config.Master.CookieSecure)
+			assert.Equal(t, "strict", config.Master.CookieSameSite)
+			assert.Equal(t, "gorse.io", config.Master.CookieDomain)
+			assert.Equal(t, 24*time.Hour, config.Master.CookieMaxAge)
+			assert.Equal(t, 100, config.Master.PopulationThreshold)
+			assert.Equal(t, int64(1048576), config.Master.MaxUploadSize)
+			assert.True(t, config.Master.DebugMode)
+			assert.Equal(t, 30*time.Second, config.Master.StatsRefresh)
+			assert.Equal(t, []WebhookConfig{{
+				URL:    "http://localhost:8000/webhook",
+				Events: []string{"model.retrained", "data.purged"},
+				Secret: "webhook_secret",
+			}}, config.Master.Webhooks)
+			assert.Equal(t, []DashboardUserConfig{{
+				Name:     "viewer",
+				Password: "viewer_pass",
+				Role:     "read_only",
+			}}, config.Master.DashboardUsers)
 			// [server]
 			assert.Equal(t, 10, config.Server.DefaultN)
 			assert.Equal(t, "19260817", config.Server.APIKey)
@@ -93,14 +145,27 @@ func TestUnmarshal(t *testing.T) {
 			assert.True(t, config.Server.AutoInsertUser)
 			assert.True(t, config.Server.AutoInsertItem)
 			assert.Equal(t, 10*time.Second, config.Server.CacheExpire)
+			assert.Equal(t, 24*time.Hour, config.Server.IdempotencyTTL)
+			assert.False(t, config.Server.StrictFeedbackType)
+			assert.False(t, config.Server.RejectDuplicateUsers)
+			assert.Equal(t, time.Minute, config.Server.RequestTimeout)
+			assert.Equal(t, 30*time.Second, config.Server.RecommendTimeout)
+			assert.Equal(t, 30*time.Second, config.Server.SearchTimeout)
+			assert.Equal(t, 5*time.Minute, config.Server.DashboardTimeout)
+			assert.Equal(t, time.Hour, config.Server.ImportTimeout)
 			// [recommend]
 			assert.Equal(t, 100, config.Recommend.CacheSize)
 			assert.Equal(t, 72*time.Hour, config.Recommend.CacheExpire)
+			assert.Empty(t, config.Recommend.CustomScorers)
+			assert.Equal(t, time.Second, config.Recommend.CustomScorerTimeout)
 			// [recommend.data_source]
 			assert.Equal(t, []string{"star", "like"}, config.Recommend.DataSource.PositiveFeedbackTypes)
 			assert.Equal(t, []string{"read"}, config.Recommend.DataSource.ReadFeedbackTypes)
+			assert.Equal(t, []string{"bot"}, config.Recommend.DataSource.ExcludedFeedbackTypes)
 			assert.Equal(t, uint(0), config.Recommend.DataSource.PositiveFeedbackTTL)
 			assert.Equal(t, uint(0), config.Recommend.DataSource.ItemTTL)
+			assert.Zero(t, config.Recommend.DataSource.MaxLabelsPerUser)
+			assert.False(t, config.Recommend.DataSource.TruncateLabels)
 			// [recommend.popular]
 			assert.Equal(t, 30*24*time.Hour, config.Recommend.Popular.PopularWindow)
 			// [recommend.leaderboards]
@@ -110,14 +175,23 @@ func TestUnmarshal(t *testing.T) {
 			assert.Equal(t, "(now() - item.Timestamp).Hours() < 168", config.Recommend.NonPersonalized[0].Filter)
 			// [recommend.user_neighbors]
 			assert.Equal(t, "related", config.Recommend.UserNeighbors.NeighborType)
+			assert.Equal(t, 20, config.Recommend.UserNeighbors.NumNeighbors)
 			// [recommend.item_neighbors]
 			assert.Equal(t, "similar", config.Recommend.ItemNeighbors.NeighborType)
+			assert.Equal(t, 20, config.Recommend.ItemNeighbors.NumNeighbors)
+			assert.Equal(t, "embedding", config.Recommend.ItemNeighbors.EmbeddingLabel)
+			assert.Equal(t, 48, config.Recommend.ItemNeighbors.MaxConnection)
+			assert.Equal(t, 100, config.Recommend.ItemNeighbors.EfConstruction)
+			assert.Equal(t, float32(0.9), config.Recommend.ItemNeighbors.RecallTarget)
 			// [recommend.collaborative]
 			assert.Equal(t, 60*time.Minute, config.Recommend.Collaborative.ModelFitPeriod)
 			assert.Equal(t, 360*time.Minute, config.Recommend.Collaborative.ModelSearchPeriod)
 			assert.Equal(t, 100, config.Recommend.Collaborative.ModelSearchEpoch)
 			assert.Equal(t, 10, config.Recommend.Collaborative.ModelSearchTrials)
 			assert.False(t, config.Recommend.Collaborative.EnableModelSizeSearch)
+			assert.Equal(t, "popularity", config.Recommend.Collaborative.NegativeSampler)
+			assert.Equal(t, "temporal", config.Recommend.Collaborative.SplitStrategy)
+			assert.Equal(t, float32(0.2), config.Recommend.Collaborative.SplitRatio)
 			// [recommend.replacement]
 			assert.False(t, config.Recommend.Replacement.EnableReplacement)
 			assert.Equal(t, 0.8, config.Recommend.Replacement.PositiveReplacementDecay)
@@ -141,8 +215,15 @@ func TestUnmarshal(t *testing.T) {
 			_, exist = config.Recommend.Offline.GetExploreRecommend("unknown")
 			assert.Equal(t, false, exist)
 			// [recommend.online]
+			assert.Equal(t, "blend", config.Recommend.Online.Mode)
+			assert.Equal(t, map[string]float64{"collaborative": 0.7, "popular": 0.3}, config.Recommend.Online.BlendWeights)
+			assert.Equal(t, "z_score", config.Recommend.Online.Normalization)
 			assert.Equal(t, []string{"item_based", "latest"}, config.Recommend.Online.FallbackRecommend)
 			assert.Equal(t, 10, config.Recommend.Online.NumFeedbackFallbackItemBased)
+			assert.Equal(t, 100, config.Recommend.Online.MaxRecommendSize)
+			assert.True(t, config.Recommend.Online.WriteBackRecommend)
+			assert.Equal(t, 24*time.Hour, config.Recommend.Online.WriteBackRecommendTTL)
+			assert.Empty(t, config.Recommend.Online.ScoreExpr)
 			// [tracing]
 			assert.False(t, config.Tracing.EnableTracing)
 			assert.Equal(t, "jaeger", config.Tracing.Exporter)
@@ -426,6 +507,19 @@ func TestConfig_OfflineRecommendDigest(t *testing.T) {
 	assert.Equal(t, cfg1.OfflineRecommendDigest(), cfg2.OfflineRecommendDigest())
 }
 
+func TestCompileScoreExpr(t *testing.T) {
+	_, err := CompileScoreExpr("score * (item.Labels?.in_stock == true ? 2 : 1) - age.Hours() / 24")
+	assert.NoError(t, err)
+	// type-invalid expression
+	_, err = CompileScoreExpr("item.ItemId")
+	assert.Error(t, err)
+	_, err = CompileScoreExpr("item.Price")
+	assert.Error(t, err)
+	// syntax-invalid expression
+	_, err = CompileScoreExpr("score +")
+	assert.Error(t, err)
+}
+
 func TestItemToItemConfig_Hash(t *testing.T) {
 	a := ItemToItemConfig{}
 	b := ItemToItemConfig{}
@@ -442,4 +536,8 @@ func TestItemToItemConfig_Hash(t *testing.T) {
 	a = ItemToItemConfig{Column: "a"}
 	b = ItemToItemConfig{Column: "b"}
 	assert.NotEqual(t, a.Hash(), b.Hash())
+
+	a = ItemToItemConfig{Shrinkage: 0}
+	b = ItemToItemConfig{Shrinkage: 10}
+	assert.NotEqual(t, a.Hash(), b.Hash())
 }
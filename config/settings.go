@@ -15,6 +15,8 @@
 package config
 
 import (
+	"sync/atomic"
+
 	"github.com/zhenghaoz/gorse/model/click"
 	"github.com/zhenghaoz/gorse/model/ranking"
 	"github.com/zhenghaoz/gorse/storage/cache"
@@ -24,6 +26,10 @@ import (
 type Settings struct {
 	Config *Config
 
+	// feedbackTypes overrides feedback types of the data source in Config. It is replaced as a whole instead of
+	// modifying Config in place, so readers never race with updates.
+	feedbackTypes atomic.Pointer[FeedbackTypes]
+
 	// database clients
 	CacheClient cache.Database
 	DataClient  data.Database
@@ -42,3 +48,38 @@ func NewSettings() *Settings {
 		DataClient:  data.NoDatabase{},
 	}
 }
+
+// FeedbackTypes are feedback types of the data source.
+type FeedbackTypes struct {
+	Positive []string
+	Read     []string
+	Excluded []string
+}
+
+// FeedbackTypes returns feedback types of the data source. Feedback types overridden by SetFeedbackTypes take
+// precedence over Config. Returned slices must not be modified.
+func (s *Settings) FeedbackTypes() FeedbackTypes {
+	if feedbackTypes := s.feedbackTypes.Load(); feedbackTypes != nil {
+		return *feedbackTypes
+	}
+	return FeedbackTypes{
+		Positive: s.Config.Recommend.DataSource.PositiveFeedbackTypes,
+		Read:     s.Config.Recommend.DataSource.ReadFeedbackTypes,
+		Excluded: s.Config.Recommend.DataSource.ExcludedFeedbackTypes,
+	}
+}
+
+// SetFeedbackTypes overrides feedback types of the data source.
+func (s *Settings) SetFeedbackTypes(feedbackTypes FeedbackTypes) {
+	s.feedbackTypes.Store(&feedbackTypes)
+}
+
+// EffectiveConfig returns a copy of Config with overridden feedback types.
+func (s *Settings) EffectiveConfig() *Config {
+	cfg := *s.Config
+	feedbackTypes := s.FeedbackTypes()
+	cfg.Recommend.DataSource.PositiveFeedbackTypes = feedbackTypes.Positive
+	cfg.Recommend.DataSource.ReadFeedbackTypes = feedbackTypes.Read
+	cfg.Recommend.DataSource.ExcludedFeedbackTypes = feedbackTypes.Excluded
+	return &cfg
+}
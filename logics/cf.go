@@ -51,11 +51,17 @@ func (mf *MatrixFactorization) Add(item *data.Item, v []float32) {
 	}
 	// Push item
 	mf.items = append(mf.items, item)
-	_ = mf.index.Add(v)
+	if _, err := mf.index.Add(v); err != nil {
+		log.Logger().Error("failed to add vector to index", zap.Error(err))
+	}
 }
 
 func (mf *MatrixFactorization) Search(v []float32, n int) []cache.Score {
-	scores := mf.index.SearchVector(v, n, false)
+	scores, err := mf.index.SearchVector(v, n, false)
+	if err != nil {
+		log.Logger().Error("failed to search index", zap.Error(err))
+		return nil
+	}
 	return lo.Map(scores, func(v lo.Tuple2[int, float32], _ int) cache.Score {
 		return cache.Score{
 			Id:         mf.items[v.A].ItemId,
@@ -15,7 +15,13 @@
 package logics
 
 import (
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"sort"
 	"time"
 
@@ -24,6 +30,7 @@ import (
 	"github.com/expr-lang/expr"
 	"github.com/expr-lang/expr/vm"
 	"github.com/samber/lo"
+	"github.com/zhenghaoz/gorse/base/encoding"
 	"github.com/zhenghaoz/gorse/base/floats"
 	"github.com/zhenghaoz/gorse/base/log"
 	"github.com/zhenghaoz/gorse/common/ann"
@@ -37,6 +44,15 @@ import (
 type ItemToItemOptions struct {
 	TagsIDF  []float32
 	UsersIDF []float32
+	// EmbeddingLabel is the label containing item embeddings. If set, neighbors of items with
+	// embeddings are found by cosine similarity, and other items fall back to the configured type.
+	EmbeddingLabel string
+	MaxConnection  int
+	EfConstruction int
+	// RecallTarget is the recall of the index over embeddings to reach by tuning ef. Disabled if zero.
+	RecallTarget float32
+	// IndexPath is the file to persist the index over embeddings between runs. Disabled if empty.
+	IndexPath string
 }
 
 type ItemToItem interface {
@@ -46,6 +62,15 @@ type ItemToItem interface {
 }
 
 func NewItemToItem(cfg config.ItemToItemConfig, n int, timestamp time.Time, opts *ItemToItemOptions) (ItemToItem, error) {
+	if opts != nil && opts.EmbeddingLabel != "" {
+		fallbackOpts := *opts
+		fallbackOpts.EmbeddingLabel = ""
+		fallback, err := NewItemToItem(cfg, n, timestamp, &fallbackOpts)
+		if err != nil {
+			return nil, err
+		}
+		return newEmbeddingLabelItemToItem(cfg, n, timestamp, opts, fallback), nil
+	}
 	switch cfg.Type {
 	case "embedding":
 		return newEmbeddingItemToItem(cfg, n, timestamp)
@@ -151,9 +176,209 @@ func (e *embeddingItemToItem) Push(item *data.Item, _ []dataset.ID) {
 	}
 	// Push item
 	e.items = append(e.items, item)
-	_ = e.index.Add(v)
+	if _, err := e.index.Add(v); err != nil {
+		log.Logger().Error("failed to add vector to index", zap.Error(err))
+	}
+}
+
+// embeddingLabelItemToItem finds neighbors of items having an embedding label by cosine similarity
+// between embeddings. Neighbors of items without embeddings are found by the fallback recommender.
+type embeddingLabelItemToItem struct {
+	baseItemToItem[[]float32]
+	label          string
+	dimension      int
+	vectors        [][]float32
+	maxConnection  int
+	efConstruction int
+	recallTarget   float32
+	indexPath      string
+	fallback       ItemToItem
+	embedded       mapset.Set[string]
+}
+
+func newEmbeddingLabelItemToItem(cfg config.ItemToItemConfig, n int, timestamp time.Time, opts *ItemToItemOptions, fallback ItemToItem) *embeddingLabelItemToItem {
+	e := &embeddingLabelItemToItem{
+		baseItemToItem: baseItemToItem[[]float32]{
+			name:      cfg.Name,
+			n:         n,
+			timestamp: timestamp,
+		},
+		label:          opts.EmbeddingLabel,
+		maxConnection:  opts.MaxConnection,
+		efConstruction: opts.EfConstruction,
+		recallTarget:   opts.RecallTarget,
+		indexPath:      opts.IndexPath,
+		fallback:       fallback,
+		embedded:       mapset.NewSet[string](),
+	}
+	e.index = e.newIndex()
+	return e
+}
+
+func (e *embeddingLabelItemToItem) newIndex() *ann.HNSW[[]float32] {
+	index := ann.NewHNSW[[]float32](cosineDistance)
+	if e.maxConnection > 0 && e.efConstruction > 0 {
+		index.SetParams(e.maxConnection, e.efConstruction)
+	}
+	return index
+}
+
+func (e *embeddingLabelItemToItem) Items() []*data.Item {
+	return e.fallback.Items()
+}
+
+func (e *embeddingLabelItemToItem) Push(item *data.Item, feedback []dataset.ID) {
+	e.fallback.Push(item, feedback)
+	// Check if hidden
+	if item.IsHidden {
+		return
+	}
+	// Extract embedding
+	labels, ok := item.Labels.(map[string]any)
+	if !ok {
+		return
+	}
+	v, ok := toEmbedding(labels[e.label])
+	if !ok || len(v) == 0 {
+		return
+	}
+	// Check dimension
+	if e.dimension == 0 {
+		e.dimension = len(v)
+	} else if e.dimension != len(v) {
+		log.Logger().Error("invalid embedding dimension",
+			zap.String("item_id", item.ItemId), zap.Int("dimension", len(v)))
+		return
+	}
+	// Push item
+	e.items = append(e.items, item)
+	e.embedded.Add(item.ItemId)
+	e.vectors = append(e.vectors, v)
+}
+
+func (e *embeddingLabelItemToItem) PopAll(callback func(itemId string, score []cache.Score)) {
+	e.buildIndex()
+	e.baseItemToItem.PopAll(callback)
+	e.fallback.PopAll(func(itemId string, score []cache.Score) {
+		if !e.embedded.Contains(itemId) {
+			callback(itemId, score)
+		}
+	})
+}
+
+// buildIndex loads the index over embeddings if the persisted one was built from the same embeddings,
+// otherwise builds and persists a new one. Then ef is tuned to reach the recall target.
+//
+// The persisted index is reused only if the digest is identical, that is, item IDs, embeddings and index
+// parameters are unchanged and in the same order. There is no incremental reuse: adding, removing or updating
+// a single item rebuilds the whole index.
+func (e *embeddingLabelItemToItem) buildIndex() {
+	digest := e.digest()
+	if e.indexPath != "" && e.loadIndex(digest) {
+		log.Logger().Info("load index over embeddings", zap.String("path", e.indexPath))
+	} else {
+		for _, v := range e.vectors {
+			if _, err := e.index.Add(v); err != nil {
+				log.Logger().Error("failed to add vector to index", zap.Error(err))
+			}
+		}
+		if e.indexPath != "" {
+			if err := e.saveIndex(digest); err != nil {
+				log.Logger().Error("failed to save index over embeddings",
+					zap.String("path", e.indexPath), zap.Error(err))
+			}
+		}
+	}
+	if e.recallTarget > 0 && len(e.vectors) > 0 {
+		ef, recall := e.index.TuneEf(e.n, e.recallTarget, numRecallSamples)
+		log.Logger().Info("tune ef of index over embeddings",
+			zap.Int("ef", ef), zap.Float32("recall", recall), zap.Float32("target", e.recallTarget))
+	}
+}
+
+// digest returns the digest of item IDs, embeddings and index parameters.
+func (e *embeddingLabelItemToItem) digest() string {
+	hash := md5.New()
+	_, _ = fmt.Fprintf(hash, "%d-%d", e.maxConnection, e.efConstruction)
+	for i, item := range e.items {
+		_, _ = fmt.Fprintf(hash, "-%s", item.ItemId)
+		_ = binary.Write(hash, binary.LittleEndian, e.vectors[i])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (e *embeddingLabelItemToItem) loadIndex(digest string) bool {
+	f, err := os.Open(e.indexPath)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	if d, err := encoding.ReadString(f); err != nil || d != digest {
+		return false
+	}
+	index := e.newIndex()
+	if err = index.Unmarshal(f); err != nil {
+		log.Logger().Warn("failed to load index over embeddings",
+			zap.String("path", e.indexPath), zap.Error(err))
+		return false
+	}
+	e.index = index
+	return true
+}
+
+func (e *embeddingLabelItemToItem) saveIndex(digest string) error {
+	if err := os.MkdirAll(filepath.Dir(e.indexPath), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.Create(e.indexPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err = encoding.WriteString(f, digest); err != nil {
+		return err
+	}
+	return e.index.Marshal(f)
 }
 
+func cosineDistance(a, b []float32) float32 {
+	norm := math32.Sqrt(floats.Dot(a, a)) * math32.Sqrt(floats.Dot(b, b))
+	if norm == 0 {
+		return 1
+	}
+	return 1 - floats.Dot(a, b)/norm
+}
+
+// toEmbedding converts a label value to an embedding. Values decoded from JSON are arrays of float64.
+func toEmbedding(o any) ([]float32, bool) {
+	switch typed := o.(type) {
+	case []float32:
+		return typed, true
+	case []float64:
+		return lo.Map(typed, func(v float64, _ int) float32 { return float32(v) }), true
+	case []any:
+		v := make([]float32, len(typed))
+		for i, e := range typed {
+			switch n := e.(type) {
+			case float64:
+				v[i] = float32(n)
+			case float32:
+				v[i] = n
+			case int:
+				v[i] = float32(n)
+			default:
+				return nil, false
+			}
+		}
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+// numRecallSamples is the number of queries sampled to estimate recall of the index over embeddings.
+const numRecallSamples = 100
+
 type tagsItemToItem struct {
 	baseItemToItem[[]dataset.ID]
 	IDF
@@ -201,19 +426,22 @@ func (t *tagsItemToItem) Push(item *data.Item, _ []dataset.ID) {
 	})
 	// Push item
 	t.items = append(t.items, item)
-	_ = t.index.Add(v)
+	if _, err := t.index.Add(v); err != nil {
+		log.Logger().Error("failed to add vector to index", zap.Error(err))
+	}
 }
 
 type usersItemToItem struct {
 	baseItemToItem[[]dataset.ID]
 	IDF
+	shrinkage float32
 }
 
 func newUsersItemToItem(cfg config.ItemToItemConfig, n int, timestamp time.Time, idf []float32) (ItemToItem, error) {
 	if cfg.Column != "" {
 		return nil, errors.New("column is not supported in users item-to-item")
 	}
-	u := &usersItemToItem{IDF: idf}
+	u := &usersItemToItem{IDF: idf, shrinkage: cfg.Shrinkage}
 	u.baseItemToItem = baseItemToItem[[]dataset.ID]{
 		name:      cfg.Name,
 		n:         n,
@@ -234,19 +462,27 @@ func (u *usersItemToItem) Push(item *data.Item, feedback []dataset.ID) {
 	})
 	// Push item
 	u.items = append(u.items, item)
-	_ = u.index.Add(feedback)
+	if _, err := u.index.Add(feedback); err != nil {
+		log.Logger().Error("failed to add vector to index", zap.Error(err))
+	}
+}
+
+func (u *usersItemToItem) distance(a, b []dataset.ID) float32 {
+	return u.IDF.shrunkDistance(a, b, u.shrinkage)
 }
 
 type autoItemToItem struct {
 	baseItemToItem[lo.Tuple2[[]dataset.ID, []dataset.ID]]
-	tIDF IDF
-	uIDF IDF
+	tIDF      IDF
+	uIDF      IDF
+	shrinkage float32
 }
 
 func newAutoItemToItem(cfg config.ItemToItemConfig, n int, timestamp time.Time, tIDF, uIDF []float32) (ItemToItem, error) {
 	a := &autoItemToItem{
-		tIDF: tIDF,
-		uIDF: uIDF,
+		tIDF:      tIDF,
+		uIDF:      uIDF,
+		shrinkage: cfg.Shrinkage,
 	}
 	a.baseItemToItem = baseItemToItem[lo.Tuple2[[]dataset.ID, []dataset.ID]]{
 		name:      cfg.Name,
@@ -275,30 +511,43 @@ func (a *autoItemToItem) Push(item *data.Item, feedback []dataset.ID) {
 	})
 	// Push item
 	a.items = append(a.items, item)
-	_ = a.index.Add(lo.Tuple2[[]dataset.ID, []dataset.ID]{A: v, B: feedback})
+	if _, err := a.index.Add(lo.Tuple2[[]dataset.ID, []dataset.ID]{A: v, B: feedback}); err != nil {
+		log.Logger().Error("failed to add vector to index", zap.Error(err))
+	}
 }
 
 func (a *autoItemToItem) distance(u, v lo.Tuple2[[]dataset.ID, []dataset.ID]) float32 {
-	return (a.tIDF.distance(u.A, v.A) + a.uIDF.distance(u.B, v.B)) / 2
+	return (a.tIDF.distance(u.A, v.A) + a.uIDF.shrunkDistance(u.B, v.B, a.shrinkage)) / 2
 }
 
 type IDF []float32
 
 func (idf IDF) distance(a, b []dataset.ID) float32 {
+	return idf.shrunkDistance(a, b, 0)
+}
+
+// shrunkDistance is the distance whose similarity is multiplied by count / (count + shrinkage), where count is the
+// number of common elements. It is the same as distance if shrinkage is zero.
+func (idf IDF) shrunkDistance(a, b []dataset.ID, shrinkage float32) float32 {
 	commonSum, commonCount := idf.weightedSumCommonElements(a, b)
+	var similarity float32
 	if len(a) == len(b) && commonCount == float32(len(a)) {
 		// If two items have the same tags, its distance is zero.
-		return 0
+		similarity = 1
 	} else if commonCount > 0 && len(a) > 0 && len(b) > 0 {
 		// Add shrinkage to avoid division by zero
-		return 1 - commonSum*commonCount/
-			math32.Sqrt(idf.weightedSum(a))/
-			math32.Sqrt(idf.weightedSum(b))/
-			(commonCount+100)
+		similarity = commonSum * commonCount /
+			math32.Sqrt(idf.weightedSum(a)) /
+			math32.Sqrt(idf.weightedSum(b)) /
+			(commonCount + 100)
 	} else {
 		// If two items have no common tags, its distance is one.
 		return 1
 	}
+	if shrinkage > 0 {
+		similarity *= commonCount / (commonCount + shrinkage)
+	}
+	return 1 - similarity
 }
 
 func (idf IDF) weightedSumCommonElements(a, b []dataset.ID) (float32, float32) {
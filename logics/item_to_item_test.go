@@ -15,10 +15,15 @@
 package logics
 
 import (
+	"math"
+	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
 
+	"github.com/chewxy/math32"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/suite"
 	"github.com/zhenghaoz/gorse/config"
 	"github.com/zhenghaoz/gorse/dataset"
@@ -149,7 +154,7 @@ func (suite *ItemToItemTestSuite) TestUsers() {
 	for i := range idf {
 		idf[i] = 1
 	}
-	item2item, err := newUsersItemToItem(config.ItemToItemConfig{}, 10, timestamp, idf)
+	item2item, err := newUsersItemToItem(config.ItemToItemConfig{}, 10, timestamp, idf, 0)
 	suite.NoError(err)
 
 	for i := 0; i < 100; i++ {
@@ -172,13 +177,42 @@ func (suite *ItemToItemTestSuite) TestUsers() {
 	}
 }
 
+func (suite *ItemToItemTestSuite) TestUsersShrinkage() {
+	idf := make([]float32, 101)
+	for i := range idf {
+		idf[i] = 1
+	}
+	// low co-occurrence pair shares one user
+	lowA, lowB := []dataset.ID{1}, []dataset.ID{1, 2}
+	// high co-occurrence pair shares ten users
+	highA, highB := []dataset.ID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, []dataset.ID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	// zero shrinkage preserves distances
+	suite.Equal(IDF(idf).distance(lowA, lowB), IDF(idf).shrunkDistance(lowA, lowB, 0))
+	suite.Equal(IDF(idf).distance(highA, highB), IDF(idf).shrunkDistance(highA, highB, 0))
+	// low co-occurrence pairs are downweighted
+	lowSim := 1 - IDF(idf).distance(lowA, lowB)
+	highSim := 1 - IDF(idf).distance(highA, highB)
+	shrunkLowSim := 1 - IDF(idf).shrunkDistance(lowA, lowB, 10)
+	shrunkHighSim := 1 - IDF(idf).shrunkDistance(highA, highB, 10)
+	suite.InDelta(lowSim/11, shrunkLowSim, 1e-6)
+	suite.InDelta(highSim/2, shrunkHighSim, 1e-6)
+	suite.Less(shrunkLowSim/lowSim, shrunkHighSim/highSim)
+	// disjoint items are still dissimilar
+	suite.Equal(float32(1), IDF(idf).shrunkDistance([]dataset.ID{1}, []dataset.ID{2}, 10))
+	// shrinkage is read from the item-to-item config
+	recommender, err := NewItemToItem(config.ItemToItemConfig{Name: "users", Type: "users", Shrinkage: 10}, 10, time.Now(),
+		&ItemToItemOptions{UsersIDF: idf})
+	suite.NoError(err)
+	suite.Equal(IDF(idf).shrunkDistance(lowA, lowB, 10), recommender.(*usersItemToItem).distance(lowA, lowB))
+}
+
 func (suite *ItemToItemTestSuite) TestAuto() {
 	timestamp := time.Now()
 	idf := make([]float32, 101)
 	for i := range idf {
 		idf[i] = 1
 	}
-	item2item, err := newAutoItemToItem(config.ItemToItemConfig{}, 10, timestamp, idf, idf)
+	item2item, err := newAutoItemToItem(config.ItemToItemConfig{}, 10, timestamp, idf, idf, 0)
 	suite.NoError(err)
 
 	for i := 0; i < 100; i++ {
@@ -216,6 +250,106 @@ func (suite *ItemToItemTestSuite) TestAuto() {
 	}
 }
 
+func (suite *ItemToItemTestSuite) TestEmbeddingLabel() {
+	timestamp := time.Now()
+	idf := make([]float32, 100)
+	for i := range idf {
+		idf[i] = 1
+	}
+	item2item, err := NewItemToItem(config.ItemToItemConfig{Type: "users"}, 10, timestamp, &ItemToItemOptions{
+		UsersIDF:       idf,
+		EmbeddingLabel: "embedding",
+		MaxConnection:  16,
+		EfConstruction: 50,
+	})
+	suite.NoError(err)
+
+	// Items with embeddings on a unit circle, decoded from JSON
+	for i := 0; i < 50; i++ {
+		angle := 0.01 * float64(i)
+		item2item.Push(&data.Item{
+			ItemId: strconv.Itoa(i),
+			Labels: map[string]any{
+				"embedding": []any{math.Cos(angle) * float64(i+1), math.Sin(angle) * float64(i+1)},
+			},
+		}, []dataset.ID{1, 2, 3})
+	}
+	// Items without embeddings
+	for i := 50; i < 60; i++ {
+		item2item.Push(&data.Item{ItemId: strconv.Itoa(i)}, []dataset.ID{1, 2, dataset.ID(i)})
+	}
+	suite.Len(item2item.Items(), 60)
+
+	counts := make(map[string]int)
+	var scores0, scores50 []cache.Score
+	item2item.PopAll(func(itemId string, score []cache.Score) {
+		counts[itemId]++
+		if itemId == "0" {
+			scores0 = score
+		} else if itemId == "50" {
+			scores50 = score
+		}
+	})
+	suite.Len(counts, 60)
+	for _, count := range counts {
+		suite.Equal(1, count)
+	}
+	// Nearest neighbors are found by cosine similarity regardless of magnitude
+	suite.Len(scores0, 10)
+	for i := 1; i <= 10; i++ {
+		suite.Equal(strconv.Itoa(i), scores0[i-1].Id)
+	}
+	// Items without embeddings fall back to users similarity
+	suite.NotEmpty(scores50)
+}
+
+func (suite *ItemToItemTestSuite) TestEmbeddingLabelIndex() {
+	path := filepath.Join(suite.T().TempDir(), "neighbors.hnsw")
+	popAll := func() map[string][]cache.Score {
+		item2item, err := NewItemToItem(config.ItemToItemConfig{Type: "users"}, 10, time.Now(), &ItemToItemOptions{
+			UsersIDF:       []float32{1},
+			EmbeddingLabel: "embedding",
+			RecallTarget:   0.99,
+			IndexPath:      path,
+		})
+		suite.NoError(err)
+		for i := 0; i < 100; i++ {
+			angle := 0.01 * float32(i)
+			item2item.Push(&data.Item{
+				ItemId: strconv.Itoa(i),
+				Labels: map[string]any{
+					"embedding": []float32{math32.Cos(angle), math32.Sin(angle)},
+				},
+			}, nil)
+		}
+		result := make(map[string][]cache.Score)
+		item2item.PopAll(func(itemId string, score []cache.Score) {
+			result[itemId] = score
+		})
+		return result
+	}
+
+	// Build and persist index
+	result := popAll()
+	suite.FileExists(path)
+	for i := 1; i <= 10; i++ {
+		suite.Equal(strconv.Itoa(i), result["0"][i-1].Id)
+	}
+
+	// Load index
+	stat, err := os.Stat(path)
+	suite.NoError(err)
+	loaded := popAll()
+	suite.Equal(lo.MapValues(result, scoreIds), lo.MapValues(loaded, scoreIds))
+	newStat, err := os.Stat(path)
+	suite.NoError(err)
+	suite.Equal(stat.ModTime(), newStat.ModTime())
+}
+
+func scoreIds(scores []cache.Score, _ string) []string {
+	return lo.Map(scores, func(score cache.Score, _ int) string { return score.Id })
+}
+
 func TestItemToItem(t *testing.T) {
 	suite.Run(t, new(ItemToItemTestSuite))
 }
@@ -0,0 +1,68 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logics
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/storage/data"
+)
+
+// ScoreExpr evaluates the score of an item by an expression of the original score, the item and the age of the
+// item. It is used to blend business rules into recommendation scores, e.g. boost items in stock.
+type ScoreExpr struct {
+	program *vm.Program
+}
+
+// NewScoreExpr compiles a score expression. See config.CompileScoreExpr for the environment of the expression.
+func NewScoreExpr(expression string) (*ScoreExpr, error) {
+	program, err := config.CompileScoreExpr(expression)
+	if err != nil {
+		return nil, err
+	}
+	return &ScoreExpr{program: program}, nil
+}
+
+// Eval returns the score of an item at the given time.
+func (e *ScoreExpr) Eval(score float64, item data.Item, now time.Time) (float64, error) {
+	result, err := expr.Run(e.program, map[string]any{
+		"score": score,
+		"item":  item,
+		"age":   now.Sub(item.Timestamp),
+	})
+	if err != nil {
+		return 0, err
+	}
+	switch typed := result.(type) {
+	case float64:
+		return typed, nil
+	case int:
+		return float64(typed), nil
+	case int8:
+		return float64(typed), nil
+	case int16:
+		return float64(typed), nil
+	case int32:
+		return float64(typed), nil
+	case int64:
+		return float64(typed), nil
+	default:
+		return 0, fmt.Errorf("score expression must return float64 but got %v", result)
+	}
+}
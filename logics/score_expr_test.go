@@ -0,0 +1,51 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/storage/data"
+)
+
+func TestScoreExpr(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	scoreExpr, err := NewScoreExpr("score * (item.Labels?.in_stock == true ? 2 : 1) - age.Hours() / 24")
+	assert.NoError(t, err)
+	// boost items in stock
+	score, err := scoreExpr.Eval(10, data.Item{ItemId: "1", Labels: map[string]any{"in_stock": true}, Timestamp: now}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 20.0, score)
+	score, err = scoreExpr.Eval(10, data.Item{ItemId: "2", Labels: map[string]any{"in_stock": false}, Timestamp: now}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, score)
+	// penalize old items
+	score, err = scoreExpr.Eval(10, data.Item{ItemId: "3", Timestamp: now.Add(-48 * time.Hour)}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 8.0, score)
+	// integer results
+	scoreExpr, err = NewScoreExpr("len(item.Categories)")
+	assert.NoError(t, err)
+	score, err = scoreExpr.Eval(10, data.Item{ItemId: "1", Categories: []string{"a", "b"}}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, score)
+	// invalid expressions
+	_, err = NewScoreExpr("item.ItemId")
+	assert.Error(t, err)
+	_, err = NewScoreExpr("score +")
+	assert.Error(t, err)
+}
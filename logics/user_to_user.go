@@ -146,7 +146,9 @@ func (e *embeddingUserToUser) Push(user *data.User, feedback []dataset.ID) {
 	}
 	// Push user
 	e.users = append(e.users, user)
-	_ = e.index.Add(v)
+	if _, err := e.index.Add(v); err != nil {
+		log.Logger().Error("failed to add vector to index", zap.Error(err))
+	}
 }
 
 type tagsUserToUser struct {
@@ -191,7 +193,9 @@ func (t *tagsUserToUser) Push(user *data.User, feedback []dataset.ID) {
 	})
 	// Push user
 	t.users = append(t.users, user)
-	_ = t.index.Add(v)
+	if _, err := t.index.Add(v); err != nil {
+		log.Logger().Error("failed to add vector to index", zap.Error(err))
+	}
 }
 
 type itemsUserToUser struct {
@@ -220,7 +224,9 @@ func (i *itemsUserToUser) Push(user *data.User, feedback []dataset.ID) {
 	})
 	// Push user
 	i.users = append(i.users, user)
-	_ = i.index.Add(feedback)
+	if _, err := i.index.Add(feedback); err != nil {
+		log.Logger().Error("failed to add vector to index", zap.Error(err))
+	}
 }
 
 type autoUserToUser struct {
@@ -257,7 +263,9 @@ func (a *autoUserToUser) Push(user *data.User, feedback []dataset.ID) {
 	})
 	// Push user
 	a.users = append(a.users, user)
-	_ = a.index.Add(lo.Tuple2[[]dataset.ID, []dataset.ID]{A: t, B: feedback})
+	if _, err := a.index.Add(lo.Tuple2[[]dataset.ID, []dataset.ID]{A: t, B: feedback}); err != nil {
+		log.Logger().Error("failed to add vector to index", zap.Error(err))
+	}
 }
 
 func (a *autoUserToUser) distance(u, v lo.Tuple2[[]dataset.ID, []dataset.ID]) float32 {
@@ -0,0 +1,219 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package master
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/juju/errors"
+	"github.com/zhenghaoz/gorse/server"
+	"github.com/zhenghaoz/gorse/storage/data"
+)
+
+const (
+	// DefaultCSVDelimiter separates categories and flattened labels in a CSV cell.
+	DefaultCSVDelimiter = ";"
+	// LabelEncodingJSON writes labels as a JSON object in a CSV cell.
+	LabelEncodingJSON = "json"
+	// LabelEncodingFlat writes labels as key=value pairs in a CSV cell.
+	LabelEncodingFlat = "flat"
+)
+
+// itemCSVHeader is the header of items in CSV format.
+var itemCSVHeader = []string{"item_id", "is_hidden", "categories", "timestamp", "labels", "comment",
+	"available_from", "available_until"}
+
+// CSVOptions are options to export and import items in CSV format. They are parsed from query parameters:
+//   - delimiter: the delimiter of categories and flattened labels. The default value is ";".
+//   - labels: the encoding of labels, either "json" or "flat". The default value is "json". Flattened labels are
+//     key=value pairs, values of a list are written as pairs with the same key. Values of flattened labels are
+//     imported as strings.
+type CSVOptions struct {
+	Delimiter     string
+	LabelEncoding string
+}
+
+func parseCSVOptions(request *http.Request) (CSVOptions, error) {
+	options := CSVOptions{
+		Delimiter:     request.URL.Query().Get("delimiter"),
+		LabelEncoding: request.URL.Query().Get("labels"),
+	}
+	if options.Delimiter == "" {
+		options.Delimiter = DefaultCSVDelimiter
+	} else if utf8.RuneCountInString(options.Delimiter) != 1 || strings.ContainsAny(options.Delimiter, ",\"=\r\n") {
+		return CSVOptions{}, fmt.Errorf("invalid delimiter `%s`", options.Delimiter)
+	}
+	if options.LabelEncoding == "" {
+		options.LabelEncoding = LabelEncodingJSON
+	} else if options.LabelEncoding != LabelEncodingJSON && options.LabelEncoding != LabelEncodingFlat {
+		return CSVOptions{}, fmt.Errorf("unknown label encoding `%s`", options.LabelEncoding)
+	}
+	return options, nil
+}
+
+// encodeItemCSV converts an item to a CSV record in the order of itemCSVHeader.
+func encodeItemCSV(item data.Item, options CSVOptions) ([]string, error) {
+	for _, category := range item.Categories {
+		if strings.Contains(category, options.Delimiter) {
+			return nil, fmt.Errorf("category `%s` of item `%s` contains delimiter", category, item.ItemId)
+		}
+	}
+	labels, err := encodeCSVLabels(item.Labels, options)
+	if err != nil {
+		return nil, errors.Annotatef(err, "item `%s`", item.ItemId)
+	}
+	record := []string{
+		item.ItemId,
+		strconv.FormatBool(item.IsHidden),
+		strings.Join(item.Categories, options.Delimiter),
+		item.Timestamp.Format(time.RFC3339),
+		labels,
+		item.Comment,
+		"",
+		"",
+	}
+	if item.AvailableFrom != nil {
+		record[6] = item.AvailableFrom.Format(time.RFC3339)
+	}
+	if item.AvailableUntil != nil {
+		record[7] = item.AvailableUntil.Format(time.RFC3339)
+	}
+	return record, nil
+}
+
+// decodeItemCSV converts a CSV record to an item. Columns are located by the header, missing columns are left empty.
+func decodeItemCSV(header, record []string, options CSVOptions) (server.Item, error) {
+	var item server.Item
+	for i, column := range header {
+		if i >= len(record) {
+			break
+		}
+		value := record[i]
+		switch strings.ReplaceAll(strings.ToLower(strings.TrimSpace(column)), "_", "") {
+		case "itemid":
+			item.ItemId = value
+		case "ishidden":
+			if value != "" {
+				isHidden, err := strconv.ParseBool(value)
+				if err != nil {
+					return server.Item{}, fmt.Errorf("invalid is_hidden `%s`", value)
+				}
+				item.IsHidden = isHidden
+			}
+		case "categories":
+			for _, category := range strings.Split(value, options.Delimiter) {
+				if category = strings.TrimSpace(category); category != "" {
+					item.Categories = append(item.Categories, category)
+				}
+			}
+		case "timestamp":
+			item.Timestamp = value
+		case "labels":
+			labels, err := decodeCSVLabels(value, options)
+			if err != nil {
+				return server.Item{}, err
+			}
+			item.Labels = labels
+		case "comment":
+			item.Comment = value
+		case "availablefrom":
+			item.AvailableFrom = value
+		case "availableuntil":
+			item.AvailableUntil = value
+		}
+	}
+	return item, nil
+}
+
+func encodeCSVLabels(labels any, options CSVOptions) (string, error) {
+	if labels == nil {
+		return "", nil
+	}
+	if options.LabelEncoding == LabelEncodingJSON {
+		bytes, err := json.Marshal(labels)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		return string(bytes), nil
+	}
+	fields, ok := labels.(map[string]any)
+	if !ok {
+		return "", errors.New("only labels of object could be flattened")
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, key := range keys {
+		values, isList := fields[key].([]any)
+		if !isList {
+			values = []any{fields[key]}
+		}
+		for _, value := range values {
+			var s string
+			switch v := value.(type) {
+			case string:
+				s = v
+			case float64:
+				s = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				s = fmt.Sprint(v)
+			}
+			if strings.ContainsAny(key, "="+options.Delimiter) || strings.Contains(s, options.Delimiter) {
+				return "", fmt.Errorf("label `%s=%s` contains delimiter", key, s)
+			}
+			pairs = append(pairs, key+"="+s)
+		}
+	}
+	return strings.Join(pairs, options.Delimiter), nil
+}
+
+func decodeCSVLabels(s string, options CSVOptions) (any, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if options.LabelEncoding == LabelEncodingJSON {
+		var labels any
+		if err := json.Unmarshal([]byte(s), &labels); err != nil {
+			return nil, fmt.Errorf("invalid labels `%s`", s)
+		}
+		return labels, nil
+	}
+	labels := make(map[string]any)
+	for _, pair := range strings.Split(s, options.Delimiter) {
+		key, value, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid label `%s`", pair)
+		}
+		switch v := labels[key].(type) {
+		case nil:
+			labels[key] = value
+		case string:
+			labels[key] = []any{v, value}
+		case []any:
+			labels[key] = append(v, value)
+		}
+	}
+	return labels, nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package master
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/storage/data"
+)
+
+func TestParseCSVOptions(t *testing.T) {
+	// default options
+	options, err := parseCSVOptions(httptest.NewRequest("GET", "https://example.com/", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, CSVOptions{Delimiter: ";", LabelEncoding: LabelEncodingJSON}, options)
+	// custom options
+	options, err = parseCSVOptions(httptest.NewRequest("GET", "https://example.com/?delimiter=|&labels=flat", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, CSVOptions{Delimiter: "|", LabelEncoding: LabelEncodingFlat}, options)
+	// invalid options
+	_, err = parseCSVOptions(httptest.NewRequest("GET", "https://example.com/?delimiter=,", nil))
+	assert.Error(t, err)
+	_, err = parseCSVOptions(httptest.NewRequest("GET", "https://example.com/?delimiter=ab", nil))
+	assert.Error(t, err)
+	_, err = parseCSVOptions(httptest.NewRequest("GET", "https://example.com/?labels=xml", nil))
+	assert.Error(t, err)
+}
+
+func TestEncodeCSVLabels(t *testing.T) {
+	labels := map[string]any{"genre": []any{"comedy", "sci-fi"}, "year": 2020.0, "lang": "en"}
+	options := CSVOptions{Delimiter: ";", LabelEncoding: LabelEncodingFlat}
+	s, err := encodeCSVLabels(labels, options)
+	assert.NoError(t, err)
+	assert.Equal(t, "genre=comedy;genre=sci-fi;lang=en;year=2020", s)
+	decoded, err := decodeCSVLabels(s, options)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"genre": []any{"comedy", "sci-fi"}, "year": "2020", "lang": "en"}, decoded)
+	// values containing delimiter could not be flattened
+	_, err = encodeCSVLabels(map[string]any{"title": "a;b"}, options)
+	assert.Error(t, err)
+	// only objects could be flattened
+	_, err = encodeCSVLabels([]any{"a"}, options)
+	assert.Error(t, err)
+}
+
+func TestMaster_ItemsCSVRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	items := []data.Item{
+		{
+			ItemId:     "1",
+			Categories: []string{"x"},
+			Timestamp:  time.Date(2020, 1, 1, 1, 1, 1, 0, time.UTC),
+			Labels:     map[string]any{"genre": []string{"comedy", "sci-fi"}},
+			Comment:    "o,n,e",
+		},
+		{
+			ItemId:     "2",
+			Categories: []string{"x", "y"},
+			Timestamp:  time.Date(2021, 1, 1, 1, 1, 1, 0, time.UTC),
+			Labels:     map[string]any{"genre": "documentary", "lang": "en"},
+			Comment:    "t\r\nw\r\no",
+		},
+		{
+			ItemId:    "3",
+			IsHidden:  true,
+			Timestamp: time.Date(2022, 1, 1, 1, 1, 1, 0, time.UTC),
+			Comment:   "\"three\"",
+		},
+	}
+	for _, delimiter := range []string{"", "|", " "} {
+		for _, encoding := range []string{"", LabelEncodingJSON, LabelEncodingFlat} {
+			t.Run(delimiter+"/"+encoding, func(t *testing.T) {
+				query := url.Values{}
+				if delimiter != "" {
+					query.Set("delimiter", delimiter)
+				}
+				if encoding != "" {
+					query.Set("labels", encoding)
+				}
+				// export items
+				src, cookie := newMockServer(t)
+				defer src.Close(t)
+				err := src.DataClient.BatchInsertItems(ctx, items)
+				assert.NoError(t, err)
+				exportQuery := url.Values{"format": {"csv"}}
+				for k, v := range query {
+					exportQuery[k] = v
+				}
+				req := httptest.NewRequest("GET", "https://example.com/?"+exportQuery.Encode(), nil)
+				req.Header.Set("Cookie", cookie)
+				w := httptest.NewRecorder()
+				src.importExportItems(w, req)
+				assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+				assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+				assert.Equal(t, "attachment;filename=items.csv", w.Header().Get("Content-Disposition"))
+				// import items
+				dst, cookie := newMockServer(t)
+				defer dst.Close(t)
+				buf := bytes.NewBuffer(nil)
+				writer := multipart.NewWriter(buf)
+				file, err := writer.CreateFormFile("file", "items.csv")
+				assert.NoError(t, err)
+				_, err = file.Write(w.Body.Bytes())
+				assert.NoError(t, err)
+				err = writer.Close()
+				assert.NoError(t, err)
+				req = httptest.NewRequest("POST", "https://example.com/?"+query.Encode(), buf)
+				req.Header.Set("Cookie", cookie)
+				req.Header.Set("Content-Type", writer.FormDataContentType())
+				w = httptest.NewRecorder()
+				dst.importExportItems(w, req)
+				assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+				assert.JSONEq(t, marshal(t, ImportResult{RowAffected: 3}), w.Body.String())
+				// compare items
+				_, expected, err := src.DataClient.GetItems(ctx, "", 100, nil)
+				assert.NoError(t, err)
+				_, actual, err := dst.DataClient.GetItems(ctx, "", 100, nil)
+				assert.NoError(t, err)
+				assert.Equal(t, expected, actual)
+			})
+		}
+	}
+}
@@ -160,7 +160,8 @@ func (c *LocalCache) WriteLocalCache() error {
 }
 
 const (
-	ModelFile = "model.bin"
+	ModelFile         = "model.bin"
+	NeighborIndexFile = "neighbors.hnsw"
 )
 
 func (c *LocalCache) GetFilePath(file string) string {
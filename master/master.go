@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
+	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/emicklei/go-restful/v3"
 	"github.com/jellydator/ttlcache/v3"
 	"github.com/juju/errors"
@@ -73,7 +74,9 @@ type Master struct {
 	managedMode    bool
 
 	// cluster meta cache
-	metaStore meta.Database
+	metaStore  meta.Database
+	nodes      mapset.Set[string] // UUIDs of registered nodes, loaded from the meta store lazily
+	nodesMutex sync.Mutex
 
 	// ranking dataset
 	rankingTrainSet  *ranking.DataSet
@@ -91,6 +94,10 @@ type Master struct {
 	rankingModelMutex    sync.RWMutex
 	rankingModelSearcher *ranking.ModelSearcher
 
+	// ranking model training progress
+	trainingProgress      TrainingProgress
+	trainingProgressMutex sync.RWMutex
+
 	// click model
 	clickScore         click.Score
 	clickModelMutex    sync.RWMutex
@@ -109,6 +116,21 @@ type Master struct {
 	loadDataChan *parallel.ConditionChannel // dataset loaded events
 	triggerChan  *parallel.ConditionChannel // manually trigger events
 
+	// dashboard stats
+	stats         *Status
+	statsETag     string
+	statsWarnings []string // warnings of the last refresh
+	statsMutex    sync.RWMutex
+
+	// cache info
+	cacheInfo      []CacheCollectionStat
+	cacheInfoTime  time.Time
+	cacheInfoMutex sync.Mutex
+
+	// label index of items for cold-start recommendation
+	coldStartIndex      map[string][]string
+	coldStartIndexMutex sync.RWMutex
+
 	scheduleState         ScheduleState
 	workerScheduleHandler http.HandlerFunc
 }
@@ -163,6 +185,15 @@ func NewMaster(cfg *config.Config, cacheFile string, managedMode bool) *Master {
 		loadDataChan: parallel.NewConditionChannel(),
 		triggerChan:  parallel.NewConditionChannel(),
 	}
+	if cfg.Master.SSLMode {
+		m.TLSConfig = &util.TLSConfig{
+			SSLCA:   cfg.Master.SSLCA,
+			SSLCert: cfg.Master.SSLCert,
+			SSLKey:  cfg.Master.SSLKey,
+		}
+	}
+	// notify webhooks of items inserted via the REST API
+	m.ItemsInserted = m.notifyItemsInserted
 
 	// enable deep learning
 	if cfg.Experimental.EnableDeepLearning {
@@ -225,10 +256,15 @@ func (m *Master) Serve() {
 	if err = m.metaStore.Init(); err != nil {
 		log.Logger().Fatal("failed to init meta database", zap.Error(err))
 	}
+	if err = m.loadFeedbackTypes(); err != nil {
+		log.Logger().Error("failed to load feedback types", zap.Error(err))
+	}
 
 	// connect data database
 	m.DataClient, err = data.Open(m.Config.Database.DataStore, m.Config.Database.DataTablePrefix,
-		storage.WithIsolationLevel(m.Config.Database.MySQL.IsolationLevel))
+		storage.WithIsolationLevel(m.Config.Database.MySQL.IsolationLevel),
+		storage.WithFeedbackDedupWindow(m.Config.Database.FeedbackDedupWindow),
+		storage.WithUserLabelLimit(m.Config.Recommend.DataSource.MaxLabelsPerUser, m.Config.Recommend.DataSource.TruncateLabels))
 	if err != nil {
 		log.Logger().Fatal("failed to connect data database", zap.Error(err),
 			zap.String("database", log.RedactDBURL(m.Config.Database.DataStore)))
@@ -248,6 +284,23 @@ func (m *Master) Serve() {
 		log.Logger().Fatal("failed to init database", zap.Error(err))
 	}
 
+	// warm up cache
+	if m.Config.Master.WarmUpCache {
+		go func() {
+			defer base.CheckPanic()
+			ctx, cancel := context.WithTimeout(context.Background(), m.Config.Master.WarmUpTimeout)
+			defer cancel()
+			if err := m.warmUpCache(ctx); err != nil {
+				log.Logger().Error("failed to warm up cache", zap.Error(err))
+			}
+		}()
+	}
+
+	// refresh dashboard stats
+	go m.refreshStatsLoop()
+	// prune dead nodes
+	go m.pruneDeadNodesLoop()
+
 	if m.managedMode {
 		go m.RunManagedTasksLoop()
 	} else {
@@ -292,20 +345,8 @@ func (m *Master) Serve() {
 	}()
 
 	if m.Config.OIDC.Enable {
-		provider, err := oidc.NewProvider(context.Background(), m.Config.OIDC.Issuer)
-		if err != nil {
+		if err := m.setupOIDC(context.Background()); err != nil {
 			log.Logger().Error("failed to create oidc provider", zap.Error(err))
-		} else {
-			m.verifier = provider.Verifier(&oidc.Config{ClientID: m.Config.OIDC.ClientID})
-			m.oauth2Config = oauth2.Config{
-				ClientID:     m.Config.OIDC.ClientID,
-				ClientSecret: m.Config.OIDC.ClientSecret,
-				RedirectURL:  m.Config.OIDC.RedirectURL,
-				Endpoint:     provider.Endpoint(),
-				Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
-			}
-			m.tokenCache = ttlcache.New(ttlcache.WithTTL[string, UserInfo](time.Hour))
-			go m.tokenCache.Start()
 		}
 	}
 
@@ -313,12 +354,35 @@ func (m *Master) Serve() {
 	m.StartHttpServer()
 }
 
+// setupOIDC discovers the OIDC provider and prepares the authorization code flow for dashboard login.
+func (m *Master) setupOIDC(ctx context.Context) error {
+	provider, err := oidc.NewProvider(ctx, m.Config.OIDC.Issuer)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	m.verifier = provider.Verifier(&oidc.Config{ClientID: m.Config.OIDC.ClientID})
+	m.oauth2Config = oauth2.Config{
+		ClientID:     m.Config.OIDC.ClientID,
+		ClientSecret: m.Config.OIDC.ClientSecret,
+		RedirectURL:  m.Config.OIDC.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+	}
+	m.tokenCache = ttlcache.New(ttlcache.WithTTL[string, UserInfo](time.Hour))
+	go m.tokenCache.Start()
+	return nil
+}
+
 func (m *Master) Shutdown() {
 	// stop http server
 	err := m.HttpServer.Shutdown(context.TODO())
 	if err != nil {
 		log.Logger().Error("failed to shutdown http server", zap.Error(err))
 	}
+	if err = m.FlushExposure(context.TODO()); err != nil {
+		log.Logger().Error("failed to flush exposure", zap.Error(err))
+	}
+	m.CloseCustomScorers()
 	// stop grpc server
 	m.grpcServer.GracefulStop()
 }
@@ -356,7 +420,7 @@ func (m *Master) RunPrivilegedTasksLoop() {
 		}
 		if m.rankingTrainSet.UserCount() == 0 && m.rankingTrainSet.ItemCount() == 0 && m.rankingTrainSet.Count() == 0 {
 			log.Logger().Warn("empty ranking dataset",
-				zap.Strings("positive_feedback_type", m.Config.Recommend.DataSource.PositiveFeedbackTypes))
+				zap.Strings("positive_feedback_type", m.FeedbackTypes().Positive))
 			continue
 		}
 
@@ -457,7 +521,7 @@ func (m *Master) RunManagedTasksLoop() {
 			}
 			if m.rankingTrainSet.UserCount() == 0 && m.rankingTrainSet.ItemCount() == 0 && m.rankingTrainSet.Count() == 0 {
 				log.Logger().Warn("empty ranking dataset",
-					zap.Strings("positive_feedback_type", m.Config.Recommend.DataSource.PositiveFeedbackTypes))
+					zap.Strings("positive_feedback_type", m.FeedbackTypes().Positive))
 				return
 			}
 
@@ -173,6 +173,11 @@ var (
 		Subsystem: "master",
 		Name:      "inactive_users_total",
 	})
+	RecommendationCoverage = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "gorse",
+		Subsystem: "master",
+		Name:      "recommendation_coverage",
+	})
 	ItemsTotal = promauto.NewGauge(prometheus.GaugeOpts{
 		Namespace: "gorse",
 		Subsystem: "master",
@@ -17,7 +17,9 @@ package master
 import (
 	"context"
 	"encoding/json"
+	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/juju/errors"
+	"github.com/samber/lo"
 	"github.com/zhenghaoz/gorse/base/log"
 	"github.com/zhenghaoz/gorse/model/click"
 	"github.com/zhenghaoz/gorse/model/ranking"
@@ -38,11 +40,15 @@ func (m *Master) GetMeta(ctx context.Context, nodeInfo *protocol.NodeInfo) (*pro
 		Version:    nodeInfo.BinaryVersion,
 		UpdateTime: time.Now().UTC(),
 	}
-	if err := m.metaStore.UpdateNode(node); err != nil {
+	joined, err := m.registerNode(node)
+	if err != nil {
 		return nil, err
 	}
+	if joined {
+		m.notifyWebhooks(EventNodeJoined, node)
+	}
 	// marshall config
-	s, err := json.Marshal(m.Config)
+	s, err := json.Marshal(m.EffectiveConfig())
 	if err != nil {
 		return nil, err
 	}
@@ -85,6 +91,59 @@ func (m *Master) GetMeta(ctx context.Context, nodeInfo *protocol.NodeInfo) (*pro
 	}, nil
 }
 
+// registerNode updates a node in the meta store and returns true if the node joins the cluster. UUIDs of nodes are
+// tracked in memory, so the meta store is listed only once instead of on every heartbeat.
+func (m *Master) registerNode(node *meta.Node) (bool, error) {
+	m.nodesMutex.Lock()
+	defer m.nodesMutex.Unlock()
+	if m.nodes == nil {
+		nodes, err := m.metaStore.ListAllNodes()
+		if err != nil {
+			return false, err
+		}
+		m.nodes = mapset.NewThreadUnsafeSet(lo.Map(nodes, func(n *meta.Node, _ int) string { return n.UUID })...)
+	}
+	if err := m.metaStore.UpdateNode(node); err != nil {
+		return false, err
+	}
+	return m.nodes.Add(node.UUID), nil
+}
+
+// pruneDeadNodesLoop prunes dead nodes periodically if auto pruning is enabled.
+func (m *Master) pruneDeadNodesLoop() {
+	for {
+		if m.Config.Master.AutoPruneDeadNodes {
+			if err := m.pruneDeadNodes(); err != nil {
+				log.Logger().Error("failed to prune dead nodes", zap.Error(err))
+			}
+		}
+		time.Sleep(m.Config.Master.MetaTimeout)
+	}
+}
+
+// pruneDeadNodes removes nodes without heartbeat in twice of the meta timeout.
+func (m *Master) pruneDeadNodes() error {
+	nodes, err := m.metaStore.ListAllNodes()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, node := range nodes {
+		if m.nodeStatus(node, now) == NodeDead {
+			if err = m.metaStore.DeleteNode(node.UUID); err != nil {
+				return err
+			}
+			m.nodesMutex.Lock()
+			if m.nodes != nil {
+				m.nodes.Remove(node.UUID)
+			}
+			m.nodesMutex.Unlock()
+			m.notifyWebhooks(EventNodeLeft, node)
+		}
+	}
+	return nil
+}
+
 // GetRankingModel returns latest ranking model.
 func (m *Master) GetRankingModel(version *protocol.VersionInfo, sender protocol.Master_GetRankingModelServer) error {
 	m.rankingModelMutex.RLock()
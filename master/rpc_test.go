@@ -16,6 +16,7 @@ package master
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net"
@@ -39,6 +40,7 @@ import (
 	"github.com/zhenghaoz/gorse/storage/data"
 	"github.com/zhenghaoz/gorse/storage/meta"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
@@ -227,6 +229,13 @@ func TestSSL(t *testing.T) {
 	_, err = client.GetMeta(context.Background(), &protocol.NodeInfo{NodeType: protocol.NodeType_Server, Uuid: "server1", Hostname: "yoga"})
 	assert.Error(t, err)
 
+	// client without certificate
+	conn, err = grpc.Dial(address, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{InsecureSkipVerify: true})))
+	assert.NoError(t, err)
+	client = protocol.NewMasterClient(conn)
+	_, err = client.GetMeta(context.Background(), &protocol.NodeInfo{NodeType: protocol.NodeType_Server, Uuid: "server1", Hostname: "yoga"})
+	assert.Error(t, err)
+
 	// certificate mismatch
 	caFile2, certFile2, keyFile2 := generateToTempFile(t)
 	o2 := &util.TLSConfig{
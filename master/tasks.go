@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -37,6 +38,7 @@ import (
 	"github.com/zhenghaoz/gorse/logics"
 	"github.com/zhenghaoz/gorse/model/click"
 	"github.com/zhenghaoz/gorse/model/ranking"
+	"github.com/zhenghaoz/gorse/server"
 	"github.com/zhenghaoz/gorse/storage/cache"
 	"github.com/zhenghaoz/gorse/storage/data"
 	"go.uber.org/zap"
@@ -45,6 +47,13 @@ import (
 
 const (
 	PositiveFeedbackRate = "PositiveFeedbackRate"
+	// ModelTrainingDuration is the prefix of time series of training durations in milliseconds. The format of name:
+	//	Training durations of a model - ModelTrainingDuration/{ranking|click}
+	ModelTrainingDuration = "ModelTrainingDuration"
+	// PopulationHistory is the prefix of time series of the number of users and items. The format of name:
+	//	Number of users - PopulationHistory/users
+	//	Number of items - PopulationHistory/items
+	PopulationHistory = "PopulationHistory"
 
 	TaskFitRankingModel        = "Fit collaborative filtering model"
 	TaskFitClickModel          = "Fit click-through rate prediction model"
@@ -68,27 +77,20 @@ func (m *Master) runLoadDatasetTask() error {
 
 	// Build non-personalized recommenders
 	initialStartTime := time.Now()
-	nonPersonalizedRecommenders := []*logics.NonPersonalized{
-		logics.NewLatest(m.Config.Recommend.CacheSize, initialStartTime),
-		logics.NewPopular(m.Config.Recommend.Popular.PopularWindow, m.Config.Recommend.CacheSize, initialStartTime),
-	}
-	for _, cfg := range m.Config.Recommend.NonPersonalized {
-		recommender, err := logics.NewNonPersonalized(cfg, m.Config.Recommend.CacheSize, initialStartTime)
-		if err != nil {
-			return errors.Trace(err)
-		}
-		nonPersonalizedRecommenders = append(nonPersonalizedRecommenders, recommender)
+	nonPersonalizedRecommenders, err := m.newNonPersonalizedRecommenders(initialStartTime)
+	if err != nil {
+		return errors.Trace(err)
 	}
 
 	log.Logger().Info("load dataset",
-		zap.Strings("positive_feedback_types", m.Config.Recommend.DataSource.PositiveFeedbackTypes),
-		zap.Strings("read_feedback_types", m.Config.Recommend.DataSource.ReadFeedbackTypes),
+		zap.Strings("positive_feedback_types", m.FeedbackTypes().Positive),
+		zap.Strings("read_feedback_types", m.FeedbackTypes().Read),
 		zap.Uint("item_ttl", m.Config.Recommend.DataSource.ItemTTL),
 		zap.Uint("feedback_ttl", m.Config.Recommend.DataSource.PositiveFeedbackTTL))
 	evaluator := NewOnlineEvaluator()
 	rankingDataset, clickDataset, dataSet, err := m.LoadDataFromDatabase(ctx, m.DataClient,
-		m.Config.Recommend.DataSource.PositiveFeedbackTypes,
-		m.Config.Recommend.DataSource.ReadFeedbackTypes,
+		m.FeedbackTypes().Positive,
+		m.FeedbackTypes().Read,
 		m.Config.Recommend.DataSource.ItemTTL,
 		m.Config.Recommend.DataSource.PositiveFeedbackTTL,
 		evaluator,
@@ -98,18 +100,8 @@ func (m *Master) runLoadDatasetTask() error {
 	}
 
 	// save non-personalized recommenders to cache
-	for _, recommender := range nonPersonalizedRecommenders {
-		scores := recommender.PopAll()
-		if err = m.CacheClient.AddScores(ctx, cache.NonPersonalized, recommender.Name(), scores); err != nil {
-			log.Logger().Error("failed to cache non-personalized recommenders", zap.Error(err))
-		}
-		if err = m.CacheClient.DeleteScores(ctx, []string{cache.NonPersonalized},
-			cache.ScoreCondition{
-				Subset: proto.String(recommender.Name()),
-				Before: lo.ToPtr(recommender.Timestamp()),
-			}); err != nil {
-			log.Logger().Error("failed to reclaim outdated items", zap.Error(err))
-		}
+	if err = m.saveNonPersonalizedRecommenders(ctx, nonPersonalizedRecommenders); err != nil {
+		log.Logger().Error("failed to cache non-personalized recommenders", zap.Error(err))
 	}
 
 	// write statistics to database
@@ -121,6 +113,7 @@ func (m *Master) runLoadDatasetTask() error {
 	if err = m.CacheClient.Set(ctx, cache.Integer(cache.Key(cache.GlobalMeta, cache.NumItems), rankingDataset.ItemCount())); err != nil {
 		log.Logger().Error("failed to write number of items", zap.Error(err))
 	}
+	m.recordPopulation(ctx, rankingDataset.UserCount(), rankingDataset.ItemCount(), time.Now())
 	ImplicitFeedbacksTotal.Set(float64(rankingDataset.Count()))
 	if err = m.CacheClient.Set(ctx, cache.Integer(cache.Key(cache.GlobalMeta, cache.NumTotalPosFeedbacks), rankingDataset.Count())); err != nil {
 		log.Logger().Error("failed to write number of positive feedbacks", zap.Error(err))
@@ -175,10 +168,20 @@ func (m *Master) runLoadDatasetTask() error {
 		log.Logger().Error("failed to write categories to cache", zap.Error(err))
 	}
 
+	// write items without feedback to cache
+	if err = m.updateNoFeedbackItems(ctx); err != nil {
+		log.Logger().Error("failed to update items without feedback", zap.Error(err))
+	}
+
 	// split ranking dataset
 	startTime := time.Now()
 	m.rankingDataMutex.Lock()
-	m.rankingTrainSet, m.rankingTestSet = rankingDataset.Split(0, 0)
+	m.rankingTrainSet, m.rankingTestSet, err = rankingDataset.SplitBy(
+		m.Config.Recommend.Collaborative.SplitStrategy, m.Config.Recommend.Collaborative.SplitRatio, 0)
+	if err != nil {
+		log.Logger().Error("failed to split ranking dataset, fallback to leave-one-out", zap.Error(err))
+		m.rankingTrainSet, m.rankingTestSet = rankingDataset.Split(0, 0)
+	}
 	rankingDataset = nil
 	m.rankingDataMutex.Unlock()
 	LoadDatasetStepSecondsVec.WithLabelValues("split_ranking_dataset").Set(time.Since(startTime).Seconds())
@@ -201,11 +204,103 @@ func (m *Master) runLoadDatasetTask() error {
 	if err = m.updateItemToItem(dataSet); err != nil {
 		log.Logger().Error("failed to update item-to-item recommendation", zap.Error(err))
 	}
+	if err = m.updateRecommendationCoverage(ctx, len(dataSet.GetUsers())); err != nil {
+		log.Logger().Error("failed to update recommendation coverage", zap.Error(err))
+	}
+	if err = m.pruneItemFeedbackSeries(ctx); err != nil {
+		log.Logger().Error("failed to prune item feedback series", zap.Error(err))
+	}
+	if err = m.pruneRecommendHistory(ctx); err != nil {
+		log.Logger().Error("failed to prune recommendation history", zap.Error(err))
+	}
+	if err = m.pruneExposure(ctx); err != nil {
+		log.Logger().Error("failed to prune exposure", zap.Error(err))
+	}
 
 	LoadDatasetTotalSeconds.Set(time.Since(initialStartTime).Seconds())
 	return nil
 }
 
+// updateRecommendationCoverage computes the fraction of users with non-empty offline recommendations. Users are
+// counted from the loaded dataset and covered users by a single count query to the cache store.
+func (m *Master) updateRecommendationCoverage(ctx context.Context, numUsers int) error {
+	numCovered, err := m.CacheClient.CountSubsets(ctx, cache.OfflineRecommend)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var coverage float64
+	if numUsers > 0 {
+		// recommendations of deleted users are not removed until expired
+		coverage = min(float64(numCovered)/float64(numUsers), 1)
+	}
+	RecommendationCoverage.Set(coverage)
+	return m.CacheClient.Set(ctx, cache.String(cache.Key(cache.GlobalMeta, cache.RecommendationCoverage),
+		strconv.FormatFloat(coverage, 'f', -1, 64)))
+}
+
+// pruneItemFeedbackSeries removes points of item feedback series out of the feedback window. Items without feedback
+// in the window are removed from active items.
+func (m *Master) pruneItemFeedbackSeries(ctx context.Context) error {
+	activeItems, err := m.CacheClient.SearchScores(ctx, server.ItemFeedbackActive, "", []string{""}, 0, -1)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	windowStart := time.Now().Add(-server.ItemFeedbackWindow)
+	for _, item := range activeItems {
+		if err = m.CacheClient.DeleteTimeSeriesPoints(ctx, cache.Key(server.ItemFeedbackPerMinute, item.Id), windowStart); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return m.CacheClient.DeleteScores(ctx, []string{server.ItemFeedbackActive}, cache.ScoreCondition{Before: &windowStart})
+}
+
+// pruneRecommendHistory removes events of served recommendations out of the history window, including events of
+// users who are no longer served. Items of these events have expired already.
+func (m *Master) pruneRecommendHistory(ctx context.Context) error {
+	windowStart := time.Now().Add(-server.RecommendHistoryWindow)
+	return m.CacheClient.DeleteScores(ctx, []string{server.RecommendHistory}, cache.ScoreCondition{Before: &windowStart})
+}
+
+// pruneExposure removes exposure of items to users not served the items within the exposure window.
+func (m *Master) pruneExposure(ctx context.Context) error {
+	windowStart := time.Now().Add(-server.ExposureWindow)
+	return m.CacheClient.DeleteScores(ctx, []string{server.Exposure}, cache.ScoreCondition{Before: &windowStart})
+}
+
+// startTrainingProgress resets the training progress when fitting of the ranking model starts.
+func (m *Master) startTrainingProgress(name string) {
+	m.trainingProgressMutex.Lock()
+	defer m.trainingProgressMutex.Unlock()
+	m.trainingProgress = TrainingProgress{
+		Running:   true,
+		Model:     name,
+		StartTime: time.Now(),
+	}
+}
+
+// updateTrainingProgress publishes the progress reported by the training loop of the ranking model.
+func (m *Master) updateTrainingProgress(p ranking.Progress) {
+	m.trainingProgressMutex.Lock()
+	defer m.trainingProgressMutex.Unlock()
+	m.trainingProgress.Epoch = p.Epoch
+	m.trainingProgress.NumEpochs = p.NumEpochs
+	m.trainingProgress.Loss = p.Loss
+	if p.Score.NDCG > m.trainingProgress.BestScore.NDCG {
+		m.trainingProgress.BestScore = p.Score
+	}
+	if p.Epoch > 0 {
+		elapsed := time.Since(m.trainingProgress.StartTime)
+		m.trainingProgress.ETA = time.Now().Add(elapsed / time.Duration(p.Epoch) * time.Duration(p.NumEpochs-p.Epoch))
+	}
+}
+
+// finishTrainingProgress marks fitting of the ranking model as finished.
+func (m *Master) finishTrainingProgress() {
+	m.trainingProgressMutex.Lock()
+	defer m.trainingProgressMutex.Unlock()
+	m.trainingProgress.Running = false
+}
+
 type FitRankingModelTask struct {
 	*Master
 	lastNumFeedback int
@@ -261,9 +356,20 @@ func (t *FitRankingModelTask) run(ctx context.Context, j *task.JobsAllocator) er
 		return nil
 	}
 
+	sampler, err := ranking.NewNegativeSampler(t.Config.Recommend.Collaborative.NegativeSampler)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
 	startFitTime := time.Now()
-	score := rankingModel.Fit(newCtx, t.rankingTrainSet, t.rankingTestSet, ranking.NewFitConfig().SetJobsAllocator(j))
+	t.startTrainingProgress(t.rankingModelName)
+	score := rankingModel.Fit(newCtx, t.rankingTrainSet, t.rankingTestSet, ranking.NewFitConfig().
+		SetJobsAllocator(j).
+		SetNegativeSampler(sampler).
+		SetCallback(t.updateTrainingProgress))
+	t.finishTrainingProgress()
 	CollaborativeFilteringFitSeconds.Set(time.Since(startFitTime).Seconds())
+	t.recordTrainingDuration(ctx, "ranking", startFitTime, time.Now())
 
 	// update ranking model
 	t.rankingModelMutex.Lock()
@@ -280,6 +386,12 @@ func (t *FitRankingModelTask) run(ctx context.Context, j *task.JobsAllocator) er
 	if err := t.CacheClient.Set(ctx, cache.Time(cache.Key(cache.GlobalMeta, cache.LastFitMatchingModelTime), time.Now())); err != nil {
 		log.Logger().Error("failed to write meta", zap.Error(err))
 	}
+	t.notifyWebhooks(EventModelRetrained, map[string]any{
+		"Type":    "ranking",
+		"Model":   t.rankingModelName,
+		"Version": fmt.Sprintf("%x", t.RankingModelVersion),
+		"Score":   score,
+	})
 
 	// caching model
 	t.rankingModelMutex.RLock()
@@ -305,6 +417,29 @@ func (t *FitRankingModelTask) run(ctx context.Context, j *task.JobsAllocator) er
 	return nil
 }
 
+// recordTrainingDuration appends the duration of a training run to the ModelTrainingDuration series of the model.
+func (m *Master) recordTrainingDuration(ctx context.Context, model string, start, end time.Time) {
+	if err := m.CacheClient.AddTimeSeriesPoints(ctx, []cache.TimeSeriesPoint{{
+		Name:      cache.Key(ModelTrainingDuration, model),
+		Timestamp: end,
+		Value:     float64(end.Sub(start).Milliseconds()),
+	}}); err != nil {
+		log.Logger().Error("failed to write training duration", zap.String("model", model), zap.Error(err))
+	}
+}
+
+// recordPopulation appends the number of users and items in the dataset to the PopulationHistory series. A point is
+// written on every run, so the history covers any window even if the population is stable. Changes within the
+// threshold are dropped when the history is read.
+func (m *Master) recordPopulation(ctx context.Context, numUsers, numItems int, timestamp time.Time) {
+	if err := m.CacheClient.AddTimeSeriesPoints(ctx, []cache.TimeSeriesPoint{
+		{Name: cache.Key(PopulationHistory, "users"), Timestamp: timestamp, Value: float64(numUsers)},
+		{Name: cache.Key(PopulationHistory, "items"), Timestamp: timestamp, Value: float64(numItems)},
+	}); err != nil {
+		log.Logger().Error("failed to write population history", zap.Error(err))
+	}
+}
+
 // FitClickModelTask fits click model using latest data. After model fitted, following states are changed:
 // 1. Click model version are increased.
 // 2. Click model score are updated.
@@ -342,7 +477,7 @@ func (t *FitClickModelTask) run(ctx context.Context, j *task.JobsAllocator) erro
 
 	if t.clickTrainSet == nil || numUsers == 0 || numItems == 0 || numFeedback == 0 {
 		log.Logger().Warn("empty ranking dataset",
-			zap.Strings("positive_feedback_type", t.Config.Recommend.DataSource.PositiveFeedbackTypes))
+			zap.Strings("positive_feedback_type", t.FeedbackTypes().Positive))
 		return nil
 	} else if numUsers != t.lastNumUsers ||
 		numItems != t.lastNumItems ||
@@ -378,6 +513,7 @@ func (t *FitClickModelTask) run(ctx context.Context, j *task.JobsAllocator) erro
 	score := clickModel.Fit(newCtx, t.clickTrainSet, t.clickTestSet, click.NewFitConfig().
 		SetJobsAllocator(j))
 	RankingFitSeconds.Set(time.Since(startFitTime).Seconds())
+	t.recordTrainingDuration(ctx, "click", startFitTime, time.Now())
 
 	// update match model
 	t.clickModelMutex.Lock()
@@ -394,6 +530,11 @@ func (t *FitClickModelTask) run(ctx context.Context, j *task.JobsAllocator) erro
 	if err := t.CacheClient.Set(ctx, cache.Time(cache.Key(cache.GlobalMeta, cache.LastFitRankingModelTime), time.Now())); err != nil {
 		log.Logger().Error("failed to write meta", zap.Error(err))
 	}
+	t.notifyWebhooks(EventModelRetrained, map[string]any{
+		"Type":    "click",
+		"Version": fmt.Sprintf("%x", t.ClickModelVersion),
+		"Score":   score,
+	})
 
 	// caching model
 	t.clickModelMutex.RLock()
@@ -453,7 +594,7 @@ func (t *SearchRankingModelTask) run(ctx context.Context, j *task.JobsAllocator)
 
 	if numUsers == 0 || numItems == 0 || numFeedback == 0 {
 		log.Logger().Warn("empty ranking dataset",
-			zap.Strings("positive_feedback_type", t.Config.Recommend.DataSource.PositiveFeedbackTypes))
+			zap.Strings("positive_feedback_type", t.FeedbackTypes().Positive))
 		// t.taskMonitor.Fail(TaskSearchRankingModel, "No feedback found.")
 		return nil
 	} else if numUsers == t.lastNumUsers &&
@@ -514,7 +655,7 @@ func (t *SearchClickModelTask) run(ctx context.Context, j *task.JobsAllocator) e
 
 	if numUsers == 0 || numItems == 0 || numFeedback == 0 {
 		log.Logger().Warn("empty click dataset",
-			zap.Strings("positive_feedback_type", t.Config.Recommend.DataSource.PositiveFeedbackTypes))
+			zap.Strings("positive_feedback_type", t.FeedbackTypes().Positive))
 		return nil
 	} else if numUsers == t.lastNumUsers &&
 		numItems == t.lastNumItems &&
@@ -788,6 +929,7 @@ func (m *Master) LoadDataFromDatabase(
 		zap.Int32("n_item_labels", itemLabelIndex.Len()),
 		zap.Duration("used_time", time.Since(start)))
 	LoadDatasetStepSecondsVec.WithLabelValues("load_items").Set(time.Since(start).Seconds())
+	m.updateColdStartIndex(items)
 
 	// create positive set
 	popularCount := make([]int32, rankingDataset.ItemCount())
@@ -801,6 +943,7 @@ func (m *Master) LoadDataFromDatabase(
 		return items[i].ItemId < items[j].ItemId
 	})
 	itemGroups := parallel.Split(items, m.Config.Master.NumJobs)
+	excludedFeedbackTypes := mapset.NewSet(m.FeedbackTypes().Excluded...)
 
 	// STEP 3: pull positive feedback
 	var mu sync.Mutex
@@ -819,6 +962,10 @@ func (m *Master) LoadDataFromDatabase(
 			data.WithOrderByItemId())
 		for feedback := range feedbackChan {
 			for _, f := range feedback {
+				// skip feedback excluded from training
+				if excludedFeedbackTypes.Contains(f.FeedbackType) {
+					continue
+				}
 				// convert user and item id to index
 				userIndex := rankingDataset.UserIndex.ToNumber(f.UserId)
 				if userIndex == base.NotId {
@@ -834,7 +981,11 @@ func (m *Master) LoadDataFromDatabase(
 				mu.Lock()
 				posFeedbackCount++
 				// insert feedback to ranking dataset
-				rankingDataset.AddFeedback(f.UserId, f.ItemId, false)
+				if m.Config.Recommend.Collaborative.SplitStrategy == ranking.TemporalSplit {
+					rankingDataset.AddTimedFeedback(f.UserId, f.ItemId, f.Timestamp, false)
+				} else {
+					rankingDataset.AddFeedback(f.UserId, f.ItemId, false)
+				}
 				// insert feedback to popularity counter
 				if f.Timestamp.After(timeWindowLimit) && !rankingDataset.HiddenItems[itemIndex] {
 					popularCount[itemIndex]++
@@ -911,6 +1062,9 @@ func (m *Master) LoadDataFromDatabase(
 			data.WithFeedbackTypes(readTypes...))
 		for feedback := range feedbackChan {
 			for _, f := range feedback {
+				if excludedFeedbackTypes.Contains(f.FeedbackType) {
+					continue
+				}
 				userIndex := rankingDataset.UserIndex.ToNumber(f.UserId)
 				if userIndex == base.NotId {
 					continue
@@ -1011,10 +1165,22 @@ func (m *Master) updateItemToItem(dataset *dataset.Dataset) error {
 	// Build item-to-item recommenders
 	itemToItemRecommenders := make([]logics.ItemToItem, 0, len(itemToItemConfigs))
 	for _, cfg := range itemToItemConfigs {
-		recommender, err := logics.NewItemToItem(cfg, m.Config.Recommend.CacheSize, dataset.GetTimestamp(), &logics.ItemToItemOptions{
+		n := m.Config.Recommend.CacheSize
+		opts := &logics.ItemToItemOptions{
 			TagsIDF:  dataset.GetItemColumnValuesIDF(),
 			UsersIDF: dataset.GetUserIDF(),
-		})
+		}
+		if cfg.Name == cache.Neighbors {
+			n = m.Config.Recommend.ItemNeighbors.GetNumNeighbors(n)
+			opts.EmbeddingLabel = m.Config.Recommend.ItemNeighbors.EmbeddingLabel
+			opts.MaxConnection = m.Config.Recommend.ItemNeighbors.MaxConnection
+			opts.EfConstruction = m.Config.Recommend.ItemNeighbors.EfConstruction
+			opts.RecallTarget = m.Config.Recommend.ItemNeighbors.RecallTarget
+			if m.localCache != nil {
+				opts.IndexPath = m.localCache.GetFilePath(NeighborIndexFile)
+			}
+		}
+		recommender, err := logics.NewItemToItem(cfg, n, dataset.GetTimestamp(), opts)
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -1117,7 +1283,7 @@ func (m *Master) updateUserToUser(dataset *dataset.Dataset) error {
 	case config.NeighborTypeAuto:
 		cfg.Type = "auto"
 	}
-	userToUserRecommender, err := logics.NewUserToUser(cfg, m.Config.Recommend.CacheSize, dataset.GetTimestamp(), &logics.UserToUserOptions{
+	userToUserRecommender, err := logics.NewUserToUser(cfg, m.Config.Recommend.UserNeighbors.GetNumNeighbors(m.Config.Recommend.CacheSize), dataset.GetTimestamp(), &logics.UserToUserOptions{
 		TagsIDF:  dataset.GetUserColumnValuesIDF(),
 		ItemsIDF: dataset.GetItemIDF(),
 	})
@@ -1189,3 +1355,110 @@ func (m *Master) needUpdateUserToUser(userId string) bool {
 	}
 	return updateTime.Before(time.Now().Add(-m.Config.Recommend.CacheExpire))
 }
+
+// updateNoFeedbackItems populates the set of items without any feedback. It scans all feedback and items, so it runs
+// in the load dataset task rather than on startup.
+func (m *Master) updateNoFeedbackItems(ctx context.Context) error {
+	// collect items with feedback
+	itemsWithFeedback := mapset.NewSet[string]()
+	feedbackChan, errChan := m.DataClient.GetFeedbackStream(ctx, batchSize)
+	for feedback := range feedbackChan {
+		for _, f := range feedback {
+			itemsWithFeedback.Add(f.ItemId)
+		}
+	}
+	if err := <-errChan; err != nil {
+		return errors.Trace(err)
+	}
+	// collect items without feedback
+	var noFeedbackItems []string
+	itemChan, errChan := m.DataClient.GetItemStream(ctx, batchSize, nil)
+	for items := range itemChan {
+		for _, item := range items {
+			if !itemsWithFeedback.Contains(item.ItemId) {
+				noFeedbackItems = append(noFeedbackItems, item.ItemId)
+			}
+		}
+	}
+	if err := <-errChan; err != nil {
+		return errors.Trace(err)
+	}
+	if len(noFeedbackItems) == 0 {
+		members, err := m.CacheClient.GetSet(ctx, cache.NoFeedbackItems)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		return m.CacheClient.RemSet(ctx, cache.NoFeedbackItems, members...)
+	}
+	return m.CacheClient.SetSet(ctx, cache.NoFeedbackItems, noFeedbackItems...)
+}
+
+// newNonPersonalizedRecommenders creates latest, popular and configured non-personalized recommenders.
+func (m *Master) newNonPersonalizedRecommenders(startTime time.Time) ([]*logics.NonPersonalized, error) {
+	recommenders := []*logics.NonPersonalized{
+		logics.NewLatest(m.Config.Recommend.CacheSize, startTime),
+		logics.NewPopular(m.Config.Recommend.Popular.PopularWindow, m.Config.Recommend.CacheSize, startTime),
+	}
+	for _, cfg := range m.Config.Recommend.NonPersonalized {
+		recommender, err := logics.NewNonPersonalized(cfg, m.Config.Recommend.CacheSize, startTime)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		recommenders = append(recommenders, recommender)
+	}
+	return recommenders, nil
+}
+
+// saveNonPersonalizedRecommenders writes items of non-personalized recommenders to cache and removes outdated items.
+func (m *Master) saveNonPersonalizedRecommenders(ctx context.Context, recommenders []*logics.NonPersonalized) error {
+	for _, recommender := range recommenders {
+		if err := m.CacheClient.AddScores(ctx, cache.NonPersonalized, recommender.Name(), recommender.PopAll()); err != nil {
+			return errors.Trace(err)
+		}
+		if err := m.CacheClient.DeleteScores(ctx, []string{cache.NonPersonalized},
+			cache.ScoreCondition{
+				Subset: proto.String(recommender.Name()),
+				Before: lo.ToPtr(recommender.Timestamp()),
+			}); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// warmUpCache populates non-personalized recommendations and item categories from the data store, so that the
+// cache is usable right after it is flushed. The dataset is loaded in the same way as the load dataset task. It is
+// skipped if latest items are cached already, and stops when ctx is done.
+func (m *Master) warmUpCache(ctx context.Context) error {
+	startTime := time.Now()
+	latest, err := m.CacheClient.SearchScores(ctx, cache.NonPersonalized, cache.Latest, []string{""}, 0, 1)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(latest) > 0 {
+		log.Logger().Info("skip warming up cache since cache is warm")
+		return nil
+	}
+	recommenders, err := m.newNonPersonalizedRecommenders(startTime)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	rankingDataset, _, _, err := m.LoadDataFromDatabase(ctx, m.DataClient,
+		m.FeedbackTypes().Positive,
+		m.FeedbackTypes().Read,
+		m.Config.Recommend.DataSource.ItemTTL,
+		m.Config.Recommend.DataSource.PositiveFeedbackTTL,
+		NewOnlineEvaluator(),
+		recommenders)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = m.saveNonPersonalizedRecommenders(ctx, recommenders); err != nil {
+		return errors.Trace(err)
+	}
+	if err = m.CacheClient.SetSet(ctx, cache.ItemCategories, rankingDataset.CategorySet.ToSlice()...); err != nil {
+		return errors.Trace(err)
+	}
+	log.Logger().Info("warm up cache", zap.Duration("duration", time.Since(startTime)))
+	return nil
+}
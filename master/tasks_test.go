@@ -22,6 +22,7 @@ import (
 
 	"github.com/samber/lo"
 	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/server"
 	"github.com/zhenghaoz/gorse/storage/cache"
 	"github.com/zhenghaoz/gorse/storage/data"
 )
@@ -63,7 +64,7 @@ func (s *MasterTestSuite) TestFindItemNeighbors() {
 	var err error
 	err = s.DataClient.BatchInsertItems(ctx, items)
 	s.NoError(err)
-	err = s.DataClient.BatchInsertFeedback(ctx, feedbacks, true, true, true)
+	_, err = s.DataClient.BatchInsertFeedback(ctx, feedbacks, true, true, true)
 	s.NoError(err)
 
 	// insert hidden item
@@ -74,7 +75,7 @@ func (s *MasterTestSuite) TestFindItemNeighbors() {
 	}})
 	s.NoError(err)
 	for i := 0; i <= 10; i++ {
-		err = s.DataClient.BatchInsertFeedback(ctx, []data.Feedback{{
+		_, err = s.DataClient.BatchInsertFeedback(ctx, []data.Feedback{{
 			FeedbackKey: data.FeedbackKey{UserId: strconv.Itoa(i), ItemId: "10", FeedbackType: "FeedbackType"},
 		}}, true, true, true)
 		s.NoError(err)
@@ -125,6 +126,32 @@ func (s *MasterTestSuite) TestFindItemNeighbors() {
 	s.Equal([]string{"7", "5", "3"}, cache.ConvertDocumentsToValues(similar))
 }
 
+func (s *MasterTestSuite) TestFindItemNeighborsWithNumNeighbors() {
+	ctx := context.Background()
+	// create config
+	s.Config = &config.Config{}
+	s.Config.Recommend.CacheSize = 3
+	s.Config.Recommend.ItemNeighbors.NumNeighbors = 2
+	s.Config.Recommend.ItemNeighbors.NeighborType = config.NeighborTypeSimilar
+	s.Config.Master.NumJobs = 4
+	items := []data.Item{
+		{ItemId: "0", Timestamp: time.Now(), Labels: []string{"a", "b", "c", "d"}},
+		{ItemId: "2", Timestamp: time.Now(), Labels: []string{"b", "c", "d"}},
+		{ItemId: "4", Timestamp: time.Now(), Labels: []string{"b", "c"}},
+		{ItemId: "6", Timestamp: time.Now(), Labels: []string{"c"}},
+		{ItemId: "8", Timestamp: time.Now(), Labels: []string{"a", "b", "c", "d", "e"}},
+	}
+	err := s.DataClient.BatchInsertItems(ctx, items)
+	s.NoError(err)
+	_, _, dataSet, err := s.LoadDataFromDatabase(context.Background(), s.DataClient, []string{"FeedbackType"},
+		nil, 0, 0, NewOnlineEvaluator(), nil)
+	s.NoError(err)
+	s.NoError(s.updateItemToItem(dataSet))
+	similar, err := s.CacheClient.SearchScores(ctx, cache.ItemToItem, cache.Key(cache.Neighbors, "8"), nil, 0, 100)
+	s.NoError(err)
+	s.Equal([]string{"0", "2"}, cache.ConvertDocumentsToValues(similar))
+}
+
 func (s *MasterTestSuite) TestFindUserNeighbors() {
 	ctx := context.Background()
 	// create config
@@ -162,7 +189,7 @@ func (s *MasterTestSuite) TestFindUserNeighbors() {
 	var err error
 	err = s.DataClient.BatchInsertUsers(ctx, users)
 	s.NoError(err)
-	err = s.DataClient.BatchInsertFeedback(ctx, feedbacks, true, true, true)
+	_, err = s.DataClient.BatchInsertFeedback(ctx, feedbacks, true, true, true)
 	s.NoError(err)
 	dataset, _, dataSet, err := s.LoadDataFromDatabase(context.Background(), s.DataClient, []string{"FeedbackType"},
 		nil, 0, 0, NewOnlineEvaluator(), nil)
@@ -200,6 +227,56 @@ func (s *MasterTestSuite) TestFindUserNeighbors() {
 	s.Equal([]string{"7", "5", "3"}, cache.ConvertDocumentsToValues(similar))
 }
 
+func (s *MasterTestSuite) TestLoadDataFromDatabaseWithExcludedFeedbackTypes() {
+	ctx := context.Background()
+	s.Config = config.GetDefaultConfig()
+	s.Config.Recommend.DataSource.ExcludedFeedbackTypes = []string{"bot"}
+
+	// insert users and items
+	var users []data.User
+	var items []data.Item
+	for i := 0; i < 4; i++ {
+		users = append(users, data.User{UserId: strconv.Itoa(i)})
+		items = append(items, data.Item{ItemId: strconv.Itoa(i)})
+	}
+	err := s.DataClient.BatchInsertUsers(ctx, users)
+	s.NoError(err)
+	err = s.DataClient.BatchInsertItems(ctx, items)
+	s.NoError(err)
+
+	// insert feedback
+	_, err = s.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "positive", UserId: "0", ItemId: "0"}},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "positive", UserId: "1", ItemId: "1"}},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "bot", UserId: "2", ItemId: "2"}},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "bot", UserId: "0", ItemId: "3"}},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "bot", UserId: "3", ItemId: "0"}},
+	}, false, false, true)
+	s.NoError(err)
+
+	// excluded feedback is still stored
+	feedback, err := s.DataClient.GetUserFeedback(ctx, "0", s.Config.Now(), "bot")
+	s.NoError(err)
+	s.Len(feedback, 1)
+
+	// excluded feedback doesn't appear in training data
+	dataset, _, _, err := s.LoadDataFromDatabase(ctx, s.DataClient, []string{"positive", "bot"},
+		nil, 0, 0, NewOnlineEvaluator(), nil)
+	s.NoError(err)
+	s.Equal(2, dataset.Count())
+	userFeedback := func(userId string) []string {
+		return lo.Map(dataset.UserFeedback[dataset.UserIndex.ToNumber(userId)], func(itemIndex int32, _ int) string {
+			return dataset.ItemIndex.ToName(itemIndex)
+		})
+	}
+	s.Equal([]string{"0"}, userFeedback("0"))
+	s.Equal([]string{"1"}, userFeedback("1"))
+	s.Empty(userFeedback("2"))
+	s.Empty(userFeedback("3"))
+	s.Empty(dataset.ItemFeedback[dataset.ItemIndex.ToNumber("2")])
+	s.Empty(dataset.ItemFeedback[dataset.ItemIndex.ToNumber("3")])
+}
+
 func (s *MasterTestSuite) TestLoadDataFromDatabase() {
 	ctx := context.Background()
 	// create config
@@ -271,7 +348,7 @@ func (s *MasterTestSuite) TestLoadDataFromDatabase() {
 			})
 		}
 	}
-	err = s.DataClient.BatchInsertFeedback(ctx, feedbacks, false, false, true)
+	_, err = s.DataClient.BatchInsertFeedback(ctx, feedbacks, false, false, true)
 	s.NoError(err)
 
 	// load dataset
@@ -390,7 +467,7 @@ func (s *MasterTestSuite) TestNonPersonalizedRecommend() {
 			}
 		}
 	}
-	err = s.DataClient.BatchInsertFeedback(ctx, feedbacks, false, false, true)
+	_, err = s.DataClient.BatchInsertFeedback(ctx, feedbacks, false, false, true)
 	s.NoError(err)
 
 	// load dataset
@@ -484,3 +561,145 @@ func (s *MasterTestSuite) TestNeedUpdateUserToUser() {
 	s.NoError(err)
 	s.False(s.needUpdateUserToUser("1"))
 }
+
+func (s *MasterTestSuite) TestPruneItemFeedbackSeries() {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Minute)
+	err := s.CacheClient.AddTimeSeriesPoints(ctx, []cache.TimeSeriesPoint{
+		{Name: cache.Key(server.ItemFeedbackPerMinute, "active"), Timestamp: now.Add(-time.Minute), Value: 1},
+		{Name: cache.Key(server.ItemFeedbackPerMinute, "active"), Timestamp: now.Add(-3 * time.Hour), Value: 1},
+		{Name: cache.Key(server.ItemFeedbackPerMinute, "inactive"), Timestamp: now.Add(-3 * time.Hour), Value: 1},
+	})
+	s.NoError(err)
+	err = s.CacheClient.AddScores(ctx, server.ItemFeedbackActive, "", []cache.Score{
+		{Id: "active", Score: 1, Categories: []string{""}, Timestamp: now},
+		{Id: "inactive", Score: 1, Categories: []string{""}, Timestamp: now.Add(-3 * time.Hour)},
+	})
+	s.NoError(err)
+
+	err = s.pruneItemFeedbackSeries(ctx)
+	s.NoError(err)
+	points, err := s.CacheClient.GetTimeSeriesPoints(ctx, cache.Key(server.ItemFeedbackPerMinute, "active"), now.Add(-4*time.Hour), now)
+	s.NoError(err)
+	s.Len(points, 1)
+	points, err = s.CacheClient.GetTimeSeriesPoints(ctx, cache.Key(server.ItemFeedbackPerMinute, "inactive"), now.Add(-4*time.Hour), now)
+	s.NoError(err)
+	s.Empty(points)
+	activeItems, err := s.CacheClient.SearchScores(ctx, server.ItemFeedbackActive, "", []string{""}, 0, -1)
+	s.NoError(err)
+	s.Equal([]string{"active"}, lo.Map(activeItems, func(item cache.Score, _ int) string { return item.Id }))
+}
+
+func (s *MasterTestSuite) TestPruneRecommendHistory() {
+	ctx := context.Background()
+	now := time.Now()
+	for userId, timestamp := range map[string]time.Time{
+		"active":   now,
+		"inactive": now.Add(-server.RecommendHistoryWindow - time.Hour),
+	} {
+		err := server.AddRecommendHistory(ctx, s.CacheClient, userId, server.RecommendEvent{
+			Items:     []cache.Score{{Id: "1", Score: 1}},
+			Timestamp: timestamp,
+		})
+		s.NoError(err)
+	}
+	err := s.pruneRecommendHistory(ctx)
+	s.NoError(err)
+	events, err := s.CacheClient.SearchScores(ctx, server.RecommendHistory, "active", []string{""}, 0, -1)
+	s.NoError(err)
+	s.Len(events, 1)
+	events, err = s.CacheClient.SearchScores(ctx, server.RecommendHistory, "inactive", []string{""}, 0, -1)
+	s.NoError(err)
+	s.Empty(events)
+}
+
+func (s *MasterTestSuite) TestPruneExposure() {
+	ctx := context.Background()
+	now := time.Now()
+	err := s.CacheClient.AddScores(ctx, server.Exposure, "1", []cache.Score{
+		{Id: "active", Score: 1, Categories: []string{""}, Timestamp: now},
+		{Id: "inactive", Score: 2, Categories: []string{""}, Timestamp: now.Add(-server.ExposureWindow - time.Hour)},
+	})
+	s.NoError(err)
+	err = s.pruneExposure(ctx)
+	s.NoError(err)
+	users, err := s.CacheClient.SearchScores(ctx, server.Exposure, "1", []string{""}, 0, -1)
+	s.NoError(err)
+	s.Equal([]string{"active"}, lo.Map(users, func(user cache.Score, _ int) string { return user.Id }))
+}
+
+func (s *MasterTestSuite) TestUpdateRecommendationCoverage() {
+	ctx := context.Background()
+	// insert recommendations for half of users
+	for _, userId := range []string{"0", "2"} {
+		err := s.CacheClient.AddScores(ctx, cache.OfflineRecommend, userId, []cache.Score{
+			{Id: "1", Score: 1, Categories: []string{""}},
+		})
+		s.NoError(err)
+	}
+
+	err := s.updateRecommendationCoverage(ctx, 4)
+	s.NoError(err)
+	coverage, err := s.CacheClient.Get(ctx, cache.Key(cache.GlobalMeta, cache.RecommendationCoverage)).String()
+	s.NoError(err)
+	s.Equal("0.5", coverage)
+}
+
+func (s *MasterTestSuite) TestWarmUpCache() {
+	ctx := context.Background()
+	s.Config = config.GetDefaultConfig()
+	s.Config.Recommend.CacheSize = 3
+	s.Config.Recommend.DataSource.PositiveFeedbackTypes = []string{"positive"}
+
+	// insert items and feedback
+	var items []data.Item
+	var feedback []data.Feedback
+	for i := 0; i < 5; i++ {
+		items = append(items, data.Item{
+			ItemId:     strconv.Itoa(i),
+			Timestamp:  time.Now().Add(-time.Duration(i) * time.Hour),
+			Categories: []string{strconv.Itoa(i % 2)},
+		})
+		for j := 0; j < i; j++ {
+			feedback = append(feedback, data.Feedback{
+				FeedbackKey: data.FeedbackKey{ItemId: strconv.Itoa(i), UserId: strconv.Itoa(j), FeedbackType: "positive"},
+				Timestamp:   time.Now().Add(-time.Minute),
+			})
+		}
+	}
+	err := s.DataClient.BatchInsertItems(ctx, items)
+	s.NoError(err)
+	_, err = s.DataClient.BatchInsertFeedback(ctx, feedback, true, false, true)
+	s.NoError(err)
+
+	// warm up empty cache
+	err = s.warmUpCache(ctx)
+	s.NoError(err)
+	popular, err := s.CacheClient.SearchScores(ctx, cache.NonPersonalized, cache.Popular, []string{""}, 0, -1)
+	s.NoError(err)
+	s.Equal([]cache.Score{
+		{Id: "4", Score: 4},
+		{Id: "3", Score: 3},
+		{Id: "2", Score: 2},
+	}, lo.Map(popular, func(document cache.Score, _ int) cache.Score {
+		return cache.Score{Id: document.Id, Score: document.Score}
+	}))
+	popular, err = s.CacheClient.SearchScores(ctx, cache.NonPersonalized, cache.Popular, []string{"1"}, 0, -1)
+	s.NoError(err)
+	s.Equal([]string{"3", "1"}, lo.Map(popular, func(document cache.Score, _ int) string { return document.Id }))
+	latest, err := s.CacheClient.SearchScores(ctx, cache.NonPersonalized, cache.Latest, []string{""}, 0, -1)
+	s.NoError(err)
+	s.Equal([]string{"0", "1", "2"}, lo.Map(latest, func(document cache.Score, _ int) string { return document.Id }))
+	categories, err := s.CacheClient.GetSet(ctx, cache.ItemCategories)
+	s.NoError(err)
+	s.ElementsMatch([]string{"0", "1"}, categories)
+
+	// skip warm cache
+	err = s.DataClient.BatchInsertItems(ctx, []data.Item{{ItemId: "5", Timestamp: time.Now(), Categories: []string{"2"}}})
+	s.NoError(err)
+	err = s.warmUpCache(ctx)
+	s.NoError(err)
+	latest, err = s.CacheClient.SearchScores(ctx, cache.NonPersonalized, cache.Latest, []string{""}, 0, -1)
+	s.NoError(err)
+	s.Equal([]string{"0", "1", "2"}, lo.Map(latest, func(document cache.Score, _ int) string { return document.Id }))
+}
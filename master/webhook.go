@@ -0,0 +1,147 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package master
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/samber/lo"
+	"github.com/zhenghaoz/gorse/base/log"
+	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/storage/data"
+	"go.uber.org/zap"
+)
+
+const (
+	EventModelRetrained = "model.retrained"
+	EventNodeJoined     = "cluster.node_joined"
+	EventNodeLeft       = "cluster.node_left"
+	EventDataPurged     = "data.purged"
+	EventItemInserted   = "item.inserted"
+
+	// WebhookSignatureHeader is the header of the HMAC-SHA256 signature of the payload.
+	WebhookSignatureHeader = "X-Gorse-Signature"
+
+	webhookTimeout     = 10 * time.Second
+	maxWebhookAttempts = 5
+	// maxPendingWebhooks is the maximum number of deliveries in flight, including deliveries waiting for retries.
+	maxPendingWebhooks = 64
+)
+
+// pendingWebhooks limits the number of deliveries in flight. Deliveries are dropped if it is full.
+var pendingWebhooks = make(chan struct{}, maxPendingWebhooks)
+
+// webhookBackoff is the delay before the first retry, which is doubled after each retry.
+var webhookBackoff = time.Second
+
+// WebhookPayload is the payload posted to webhooks.
+type WebhookPayload struct {
+	Event     string
+	Timestamp time.Time
+	Data      any
+}
+
+// signWebhookPayload signs the payload by HMAC-SHA256 with the secret.
+func signWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// notifyWebhooks posts an event to subscribed webhooks in background. At most maxPendingWebhooks deliveries are in
+// flight at the same time.
+func (m *Master) notifyWebhooks(event string, data any) {
+	if len(m.Config.Master.Webhooks) == 0 {
+		return
+	}
+	body, err := json.Marshal(WebhookPayload{Event: event, Timestamp: time.Now(), Data: data})
+	if err != nil {
+		log.Logger().Error("failed to marshal webhook payload", zap.String("event", event), zap.Error(err))
+		return
+	}
+	for _, webhook := range m.Config.Master.Webhooks {
+		if len(webhook.Events) > 0 && !lo.Contains(webhook.Events, event) {
+			continue
+		}
+		select {
+		case pendingWebhooks <- struct{}{}:
+		default:
+			log.Logger().Error("too many pending webhooks, delivery dropped", zap.String("event", event),
+				zap.String("url", webhook.URL))
+			continue
+		}
+		go func(webhook config.WebhookConfig) {
+			defer func() { <-pendingWebhooks }()
+			if err := deliverWebhook(webhook, body); err != nil {
+				log.Logger().Error("failed to deliver webhook", zap.String("event", event),
+					zap.String("url", webhook.URL), zap.Error(err))
+			}
+		}(webhook)
+	}
+}
+
+// notifyItemsInserted notifies webhooks of items inserted or modified.
+func (m *Master) notifyItemsInserted(items []data.Item) {
+	if len(items) > 0 {
+		m.notifyWebhooks(EventItemInserted, items)
+	}
+}
+
+// deliverWebhook posts the payload to a webhook. Failed deliveries are retried with exponential backoff.
+func deliverWebhook(webhook config.WebhookConfig, body []byte) error {
+	var err error
+	backoff := webhookBackoff
+	for attempt := 0; attempt < maxWebhookAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		if err = postWebhook(webhook, body); err == nil {
+			return nil
+		}
+	}
+	return errors.Annotatef(err, "failed after %d attempts", maxWebhookAttempts)
+}
+
+func postWebhook(webhook config.WebhookConfig, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if webhook.Secret != "" {
+		request.Header.Set(WebhookSignatureHeader, signWebhookPayload(webhook.Secret, body))
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+	return nil
+}
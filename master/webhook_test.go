@@ -0,0 +1,235 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package master
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/steinfletcher/apitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/protocol"
+	"github.com/zhenghaoz/gorse/server"
+	"github.com/zhenghaoz/gorse/storage/meta"
+)
+
+type webhookRequest struct {
+	Signature string
+	Payload   WebhookPayload
+}
+
+func newWebhookServer(t *testing.T, failures int32) (*httptest.Server, chan webhookRequest) {
+	var attempts atomic.Int32
+	requests := make(chan webhookRequest, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		var payload WebhookPayload
+		assert.NoError(t, json.Unmarshal(body, &payload))
+		assert.Equal(t, signWebhookPayload("secret", body), r.Header.Get(WebhookSignatureHeader))
+		requests <- webhookRequest{Signature: r.Header.Get(WebhookSignatureHeader), Payload: payload}
+	}))
+	return ts, requests
+}
+
+func TestMaster_NotifyWebhooks(t *testing.T) {
+	s, _ := newMockServer(t)
+	defer s.Close(t)
+	ts, requests := newWebhookServer(t, 0)
+	defer ts.Close()
+	s.Config.Master.Webhooks = []config.WebhookConfig{{
+		URL:    ts.URL,
+		Events: []string{EventModelRetrained},
+		Secret: "secret",
+	}}
+
+	// unsubscribed event
+	s.notifyWebhooks(EventDataPurged, nil)
+	// subscribed event
+	s.notifyWebhooks(EventModelRetrained, map[string]any{"Model": "bpr"})
+	select {
+	case req := <-requests:
+		assert.True(t, strings.HasPrefix(req.Signature, "sha256="))
+		assert.Equal(t, EventModelRetrained, req.Payload.Event)
+		assert.Equal(t, map[string]any{"Model": "bpr"}, req.Payload.Data)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not delivered")
+	}
+	select {
+	case req := <-requests:
+		t.Fatalf("unexpected webhook %v", req.Payload.Event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestMaster_NotifyWebhooksRetry(t *testing.T) {
+	backoff := webhookBackoff
+	webhookBackoff = 10 * time.Millisecond
+	defer func() { webhookBackoff = backoff }()
+
+	s, cookie := newMockServer(t)
+	defer s.Close(t)
+	ts, requests := newWebhookServer(t, 2)
+	defer ts.Close()
+	s.Config.Master.Webhooks = []config.WebhookConfig{{URL: ts.URL, Secret: "secret"}}
+
+	// purge data
+	req := httptest.NewRequest("POST", "https://example.com/",
+		strings.NewReader("check_list=delete_users,delete_items,delete_feedback,delete_cache"))
+	req.Header.Set("Cookie", cookie)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	s.purge(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	select {
+	case req := <-requests:
+		assert.Equal(t, EventDataPurged, req.Payload.Event)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not delivered")
+	}
+}
+
+func TestMaster_NotifyWebhooksNodes(t *testing.T) {
+	s, _ := newMockServer(t)
+	defer s.Close(t)
+	ts, requests := newWebhookServer(t, 0)
+	defer ts.Close()
+	s.Config.Master.Webhooks = []config.WebhookConfig{{
+		URL:    ts.URL,
+		Events: []string{EventNodeJoined, EventNodeLeft},
+		Secret: "secret",
+	}}
+	expectEvent := func(event string) {
+		select {
+		case req := <-requests:
+			assert.Equal(t, event, req.Payload.Event)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("webhook %v not delivered", event)
+		}
+	}
+
+	// a node joins once regardless of heartbeats
+	nodeInfo := &protocol.NodeInfo{NodeType: protocol.NodeType_Worker, Uuid: "worker1"}
+	for i := 0; i < 3; i++ {
+		_, err := s.GetMeta(context.Background(), nodeInfo)
+		assert.NoError(t, err)
+	}
+	expectEvent(EventNodeJoined)
+	// a node leaves after being pruned
+	err := s.metaStore.UpdateNode(&meta.Node{
+		UUID:       "worker1",
+		Type:       protocol.NodeType_Worker.String(),
+		UpdateTime: time.Now().Add(-s.Config.Master.MetaTimeout * 3).UTC(),
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, s.pruneDeadNodes())
+	expectEvent(EventNodeLeft)
+	// a pruned node joins again
+	_, err = s.GetMeta(context.Background(), nodeInfo)
+	assert.NoError(t, err)
+	expectEvent(EventNodeJoined)
+	select {
+	case req := <-requests:
+		t.Fatalf("unexpected webhook %v", req.Payload.Event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestMaster_NotifyWebhooksItems(t *testing.T) {
+	s, cookie := newMockServer(t)
+	defer s.Close(t)
+	ts, requests := newWebhookServer(t, 0)
+	defer ts.Close()
+	s.Config.Master.Webhooks = []config.WebhookConfig{{
+		URL:    ts.URL,
+		Events: []string{EventItemInserted},
+		Secret: "secret",
+	}}
+	expectItems := func(itemIds ...string) {
+		select {
+		case req := <-requests:
+			assert.Equal(t, EventItemInserted, req.Payload.Event)
+			items, ok := req.Payload.Data.([]any)
+			if assert.True(t, ok) && assert.Len(t, items, len(itemIds)) {
+				for i, item := range items {
+					assert.Equal(t, itemIds[i], item.(map[string]any)["ItemId"])
+				}
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("webhook not delivered")
+		}
+	}
+
+	// insert items via the REST API
+	apitest.New().
+		Handler(s.handler).
+		Post("/api/items").
+		Header("Cookie", cookie).
+		JSON([]server.Item{{ItemId: "1"}, {ItemId: "2"}}).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+	expectItems("1", "2")
+	// modify an item via the REST API
+	apitest.New().
+		Handler(s.handler).
+		Patch("/api/item/1").
+		Header("Cookie", cookie).
+		JSON(`{"Comment": "one"}`).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+	expectItems("1")
+	// import items
+	importItems := func(dryRun bool) {
+		buf := bytes.NewBuffer(nil)
+		writer := multipart.NewWriter(buf)
+		file, err := writer.CreateFormFile("file", "items.jsonl")
+		assert.NoError(t, err)
+		_, err = file.Write([]byte(`{"ItemId":"3"}
+{"ItemId":"4"}`))
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Close())
+		req := httptest.NewRequest("POST", "https://example.com/?dry_run="+strconv.FormatBool(dryRun), buf)
+		req.Header.Set("Cookie", cookie)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		s.importExportItems(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	importItems(true)
+	importItems(false)
+	expectItems("3", "4")
+	select {
+	case req := <-requests:
+		t.Fatalf("unexpected webhook %v", req.Payload.Event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/juju/errors"
@@ -31,18 +32,21 @@ import (
 
 // DataSet contains preprocessed data structures for recommendation models.
 type DataSet struct {
-	UserIndex      base.Index
-	ItemIndex      base.Index
-	FeedbackUsers  base.Array[int32]
-	FeedbackItems  base.Array[int32]
-	UserFeedback   [][]int32
-	ItemFeedback   [][]int32
-	Negatives      [][]int32
-	ItemFeatures   [][]lo.Tuple2[int32, float32]
-	UserFeatures   [][]lo.Tuple2[int32, float32]
-	HiddenItems    []bool
-	ItemCategories [][]string
-	CategorySet    mapset.Set[string]
+	UserIndex     base.Index
+	ItemIndex     base.Index
+	FeedbackUsers base.Array[int32]
+	FeedbackItems base.Array[int32]
+	UserFeedback  [][]int32
+	ItemFeedback  [][]int32
+	Negatives     [][]int32
+	// UserFeedbackTimes are timestamps (in Unix nanoseconds) aligned with UserFeedback. It is only filled by
+	// AddTimedFeedback and required by temporal split.
+	UserFeedbackTimes [][]int64
+	ItemFeatures      [][]lo.Tuple2[int32, float32]
+	UserFeatures      [][]lo.Tuple2[int32, float32]
+	HiddenItems       []bool
+	ItemCategories    [][]string
+	CategorySet       mapset.Set[string]
 	// statistics
 	NumItemLabels    int32
 	NumUserLabels    int32
@@ -105,6 +109,25 @@ func (dataset *DataSet) AddFeedback(userId, itemId string, insertUserItem bool)
 	}
 }
 
+// AddTimedFeedback adds feedback with its timestamp. Feedback already in the dataset is skipped.
+func (dataset *DataSet) AddTimedFeedback(userId, itemId string, timestamp time.Time, insertUserItem bool) {
+	userIndex := dataset.UserIndex.ToNumber(userId)
+	itemIndex := dataset.ItemIndex.ToNumber(itemId)
+	if userIndex != base.NotId && itemIndex != base.NotId && int(userIndex) < len(dataset.UserFeedback) &&
+		lo.Contains(dataset.UserFeedback[userIndex], itemIndex) {
+		return
+	}
+	count := dataset.Count()
+	dataset.AddFeedback(userId, itemId, insertUserItem)
+	if dataset.Count() > count {
+		userIndex = dataset.UserIndex.ToNumber(userId)
+		for int(userIndex) >= len(dataset.UserFeedbackTimes) {
+			dataset.UserFeedbackTimes = append(dataset.UserFeedbackTimes, make([]int64, 0))
+		}
+		dataset.UserFeedbackTimes[userIndex] = append(dataset.UserFeedbackTimes[userIndex], timestamp.UnixNano())
+	}
+}
+
 func (dataset *DataSet) AddRawFeedback(userIndex, itemIndex int32) {
 	dataset.FeedbackUsers.Append(userIndex)
 	dataset.FeedbackItems.Append(itemIndex)
@@ -176,20 +199,7 @@ func (dataset *DataSet) NegativeSample(excludeSet *DataSet, numCandidates int) [
 // set. If numTestUsers is equal or greater than the number of total users or numTestUsers <= 0, all users are presented
 // in the test set.
 func (dataset *DataSet) Split(numTestUsers int, seed int64) (*DataSet, *DataSet) {
-	trainSet, testSet := new(DataSet), new(DataSet)
-	trainSet.NumItemLabels, testSet.NumItemLabels = dataset.NumItemLabels, dataset.NumItemLabels
-	trainSet.NumUserLabels, testSet.NumUserLabels = dataset.NumUserLabels, dataset.NumUserLabels
-	trainSet.HiddenItems, testSet.HiddenItems = dataset.HiddenItems, dataset.HiddenItems
-	trainSet.ItemCategories, testSet.ItemCategories = dataset.ItemCategories, dataset.ItemCategories
-	trainSet.CategorySet, testSet.CategorySet = dataset.CategorySet, dataset.CategorySet
-	trainSet.ItemFeatures, testSet.ItemFeatures = dataset.ItemFeatures, dataset.ItemFeatures
-	trainSet.UserFeatures, testSet.UserFeatures = dataset.UserFeatures, dataset.UserFeatures
-	trainSet.NumItemLabelUsed, testSet.NumItemLabelUsed = dataset.NumItemLabelUsed, dataset.NumItemLabelUsed
-	trainSet.NumUserLabelUsed, testSet.NumUserLabelUsed = dataset.NumUserLabelUsed, dataset.NumUserLabelUsed
-	trainSet.UserIndex, testSet.UserIndex = dataset.UserIndex, dataset.UserIndex
-	trainSet.ItemIndex, testSet.ItemIndex = dataset.ItemIndex, dataset.ItemIndex
-	trainSet.UserFeedback, testSet.UserFeedback = createSliceOfSlice(dataset.UserCount()), createSliceOfSlice(dataset.UserCount())
-	trainSet.ItemFeedback, testSet.ItemFeedback = createSliceOfSlice(dataset.ItemCount()), createSliceOfSlice(dataset.ItemCount())
+	trainSet, testSet := dataset.newSplit()
 	rng := base.NewRandomGenerator(seed)
 	if numTestUsers >= dataset.UserCount() || numTestUsers <= 0 {
 		for userIndex := int32(0); userIndex < int32(dataset.UserCount()); userIndex++ {
@@ -243,6 +253,103 @@ func (dataset *DataSet) Split(numTestUsers int, seed int64) (*DataSet, *DataSet)
 	return trainSet, testSet
 }
 
+const (
+	LeaveOneOutSplit = "leave_one_out"
+	RandomSplit      = "random"
+	TemporalSplit    = "temporal"
+)
+
+// SplitBy splits dataset by a strategy:
+//   - leave_one_out: a random feedback of each user is held out.
+//   - random: a ratio of feedback is held out randomly.
+//   - temporal: the latest feedback of each user is held out, which requires feedback timestamps.
+func (dataset *DataSet) SplitBy(strategy string, ratio float32, seed int64) (*DataSet, *DataSet, error) {
+	switch strategy {
+	case "", LeaveOneOutSplit:
+		trainSet, testSet := dataset.Split(0, seed)
+		return trainSet, testSet, nil
+	case RandomSplit:
+		return dataset.randomSplit(ratio, seed)
+	case TemporalSplit:
+		return dataset.temporalSplit()
+	default:
+		return nil, nil, errors.NotValidf("split strategy %s", strategy)
+	}
+}
+
+func (dataset *DataSet) randomSplit(ratio float32, seed int64) (*DataSet, *DataSet, error) {
+	if ratio <= 0 || ratio >= 1 {
+		return nil, nil, errors.NotValidf("split ratio %v", ratio)
+	}
+	trainSet, testSet := dataset.newSplit()
+	rng := base.NewRandomGenerator(seed)
+	perm := rng.Perm(dataset.Count())
+	numTest := int(float32(dataset.Count()) * ratio)
+	for i, j := range perm {
+		userIndex, itemIndex := dataset.GetIndex(j)
+		if i < numTest {
+			testSet.appendFeedback(userIndex, itemIndex)
+		} else {
+			trainSet.appendFeedback(userIndex, itemIndex)
+		}
+	}
+	return trainSet, testSet, nil
+}
+
+func (dataset *DataSet) temporalSplit() (*DataSet, *DataSet, error) {
+	if dataset.Count() > 0 && len(dataset.UserFeedbackTimes) == 0 {
+		return nil, nil, errors.NotValidf("temporal split without feedback timestamps")
+	}
+	trainSet, testSet := dataset.newSplit()
+	for userIndex := int32(0); userIndex < int32(dataset.UserCount()); userIndex++ {
+		if int(userIndex) >= len(dataset.UserFeedback) || len(dataset.UserFeedback[userIndex]) == 0 {
+			continue
+		}
+		times := dataset.UserFeedbackTimes[userIndex]
+		latest := 0
+		for i := range times {
+			if times[i] > times[latest] {
+				latest = i
+			}
+		}
+		for i, itemIndex := range dataset.UserFeedback[userIndex] {
+			if i == latest {
+				testSet.appendFeedback(userIndex, itemIndex)
+			} else {
+				trainSet.appendFeedback(userIndex, itemIndex)
+			}
+		}
+	}
+	return trainSet, testSet, nil
+}
+
+// newSplit creates empty train set and test set sharing indices and features with the dataset.
+func (dataset *DataSet) newSplit() (*DataSet, *DataSet) {
+	trainSet, testSet := new(DataSet), new(DataSet)
+	trainSet.NumItemLabels, testSet.NumItemLabels = dataset.NumItemLabels, dataset.NumItemLabels
+	trainSet.NumUserLabels, testSet.NumUserLabels = dataset.NumUserLabels, dataset.NumUserLabels
+	trainSet.HiddenItems, testSet.HiddenItems = dataset.HiddenItems, dataset.HiddenItems
+	trainSet.ItemCategories, testSet.ItemCategories = dataset.ItemCategories, dataset.ItemCategories
+	trainSet.CategorySet, testSet.CategorySet = dataset.CategorySet, dataset.CategorySet
+	trainSet.ItemFeatures, testSet.ItemFeatures = dataset.ItemFeatures, dataset.ItemFeatures
+	trainSet.UserFeatures, testSet.UserFeatures = dataset.UserFeatures, dataset.UserFeatures
+	trainSet.NumItemLabelUsed, testSet.NumItemLabelUsed = dataset.NumItemLabelUsed, dataset.NumItemLabelUsed
+	trainSet.NumUserLabelUsed, testSet.NumUserLabelUsed = dataset.NumUserLabelUsed, dataset.NumUserLabelUsed
+	trainSet.UserIndex, testSet.UserIndex = dataset.UserIndex, dataset.UserIndex
+	trainSet.ItemIndex, testSet.ItemIndex = dataset.ItemIndex, dataset.ItemIndex
+	trainSet.UserFeedback, testSet.UserFeedback = createSliceOfSlice(dataset.UserCount()), createSliceOfSlice(dataset.UserCount())
+	trainSet.ItemFeedback, testSet.ItemFeedback = createSliceOfSlice(dataset.ItemCount()), createSliceOfSlice(dataset.ItemCount())
+	return trainSet, testSet
+}
+
+// appendFeedback appends feedback to a split whose feedback slices have been allocated.
+func (dataset *DataSet) appendFeedback(userIndex, itemIndex int32) {
+	dataset.FeedbackUsers.Append(userIndex)
+	dataset.FeedbackItems.Append(itemIndex)
+	dataset.UserFeedback[userIndex] = append(dataset.UserFeedback[userIndex], itemIndex)
+	dataset.ItemFeedback[itemIndex] = append(dataset.ItemFeedback[itemIndex], userIndex)
+}
+
 // GetIndex gets the i-th record by <user index, item index, rating>.
 func (dataset *DataSet) GetIndex(i int) (int32, int32) {
 	return dataset.FeedbackUsers.Get(i), dataset.FeedbackItems.Get(i)
@@ -18,6 +18,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"strconv"
 	"testing"
+	"time"
 )
 
 func TestNewMapIndexDataset(t *testing.T) {
@@ -69,3 +70,69 @@ func TestDataSet_Split(t *testing.T) {
 	assert.Equal(t, numItems, test2.ItemCount())
 	assert.Equal(t, 2, test2.Count())
 }
+
+func newSplitTestDataSet(timed bool) *DataSet {
+	numUsers, numItems := 3, 5
+	dataset := NewMapIndexDataset()
+	for i := 0; i < numUsers; i++ {
+		dataset.AddUser(fmt.Sprintf("user%v", i))
+	}
+	for i := 0; i < numItems; i++ {
+		dataset.AddItem(fmt.Sprintf("item%v", i))
+	}
+	for i := 0; i < numUsers; i++ {
+		for j := i + 1; j < numItems; j++ {
+			if timed {
+				timestamp := time.Date(2000, 1, j, 0, 0, 0, 0, time.UTC)
+				dataset.AddTimedFeedback(fmt.Sprintf("user%v", i), fmt.Sprintf("item%v", j), timestamp, false)
+			} else {
+				dataset.AddFeedback(fmt.Sprintf("user%v", i), fmt.Sprintf("item%v", j), false)
+			}
+		}
+	}
+	return dataset
+}
+
+func TestDataSet_SplitBy(t *testing.T) {
+	dataset := newSplitTestDataSet(false)
+	// leave one out
+	train, test, err := dataset.SplitBy(LeaveOneOutSplit, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, train.Count())
+	assert.Equal(t, 3, test.Count())
+	for userIndex := 0; userIndex < 3; userIndex++ {
+		assert.Len(t, test.UserFeedback[userIndex], 1)
+	}
+	// random
+	train, test, err = dataset.SplitBy(RandomSplit, 0.4, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, train.Count())
+	assert.Equal(t, 3, test.Count())
+	assert.Equal(t, 3, train.UserCount())
+	assert.Equal(t, 5, test.ItemCount())
+	_, _, err = dataset.SplitBy(RandomSplit, 1, 0)
+	assert.Error(t, err)
+	// temporal without timestamps
+	_, _, err = dataset.SplitBy(TemporalSplit, 0, 0)
+	assert.Error(t, err)
+	// unknown strategy
+	_, _, err = dataset.SplitBy("unknown", 0, 0)
+	assert.Error(t, err)
+}
+
+func TestDataSet_TemporalSplit(t *testing.T) {
+	dataset := newSplitTestDataSet(true)
+	// duplicate feedback doesn't add timestamps
+	dataset.AddTimedFeedback("user0", "item1", time.Date(2000, 2, 1, 0, 0, 0, 0, time.UTC), false)
+	assert.Equal(t, 9, dataset.Count())
+	train, test, err := dataset.SplitBy(TemporalSplit, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, train.Count())
+	assert.Equal(t, 3, test.Count())
+	// the latest item is held out for each user
+	latest := dataset.ItemIndex.ToNumber("item4")
+	for userIndex := 0; userIndex < 3; userIndex++ {
+		assert.Equal(t, []int32{latest}, test.UserFeedback[userIndex])
+		assert.NotContains(t, train.UserFeedback[userIndex], latest)
+	}
+}
@@ -49,6 +49,19 @@ type FitConfig struct {
 	Verbose    int
 	Candidates int
 	TopK       int
+	Callback   func(progress Progress)
+	// NegativeSampler draws negative items for pairwise models. Negative items are drawn uniformly if nil.
+	NegativeSampler NegativeSampler
+}
+
+// Progress is the progress of fitting a model, reported after each epoch.
+type Progress struct {
+	Epoch     int
+	NumEpochs int
+	// Loss is the average training loss of the epoch. It is zero if the model has no loss.
+	Loss float32
+	// Score is the latest score on the validation set.
+	Score Score
 }
 
 func NewFitConfig() *FitConfig {
@@ -69,6 +82,29 @@ func (config *FitConfig) SetJobsAllocator(allocator *task.JobsAllocator) *FitCon
 	return config
 }
 
+// SetNegativeSampler sets the sampler of negative items.
+func (config *FitConfig) SetNegativeSampler(sampler NegativeSampler) *FitConfig {
+	config.NegativeSampler = sampler
+	return config
+}
+
+// SetCallback sets the function called with the progress after each epoch.
+func (config *FitConfig) SetCallback(callback func(progress Progress)) *FitConfig {
+	config.Callback = callback
+	return config
+}
+
+func (config *FitConfig) reportProgress(epoch, numEpochs int, loss float32, scores []float32) {
+	if config.Callback != nil {
+		config.Callback(Progress{
+			Epoch:     epoch,
+			NumEpochs: numEpochs,
+			Loss:      loss,
+			Score:     Score{NDCG: scores[0], Precision: scores[1], Recall: scores[2]},
+		})
+	}
+}
+
 func (config *FitConfig) LoadDefaultIfNil() *FitConfig {
 	if config == nil {
 		return NewFitConfig()
@@ -390,6 +426,11 @@ func (bpr *BPR) Fit(ctx context.Context, trainSet, valSet *DataSet, config *FitC
 	for i := 0; i < maxJobs; i++ {
 		rng[i] = base.NewRandomGenerator(bpr.GetRandomGenerator().Int63())
 	}
+	sampler := config.NegativeSampler
+	if sampler == nil {
+		sampler = &uniformSampler{}
+	}
+	sampler.Init(trainSet)
 	// Convert array to hashmap
 	userFeedback := make([]mapset.Set[int32], trainSet.UserCount())
 	for u := range userFeedback {
@@ -427,8 +468,14 @@ func (bpr *BPR) Fit(ctx context.Context, trainSet, valSet *DataSet, config *FitC
 			posIndex := trainSet.UserFeedback[userIndex][rng[workerId].Intn(ratingCount)]
 			// Select a negative sample
 			negIndex := int32(-1)
-			for {
-				temp := rng[workerId].Int31n(int32(trainSet.ItemCount()))
+			for attempt := 0; ; attempt++ {
+				var temp int32
+				if attempt < maxSampleAttempts {
+					temp = sampler.Sample(rng[workerId], userIndex, bpr.InternalPredict)
+				} else {
+					// Fall back to uniform sampling if the sampler keeps drawing positive items.
+					temp = rng[workerId].Int31n(int32(trainSet.ItemCount()))
+				}
 				if !userFeedback[userIndex].Contains(temp) {
 					negIndex = temp
 					break
@@ -469,6 +516,7 @@ func (bpr *BPR) Fit(ctx context.Context, trainSet, valSet *DataSet, config *FitC
 				zap.Float32(fmt.Sprintf("Precision@%v", config.TopK), scores[1]),
 				zap.Float32(fmt.Sprintf("Recall@%v", config.TopK), scores[2]))
 		}
+		config.reportProgress(epoch, bpr.nEpochs, lo.Sum(cost)/float32(trainSet.Count()), scores)
 		span.Add(1)
 	}
 	span.End()
@@ -819,6 +867,7 @@ func (ccd *CCD) Fit(ctx context.Context, trainSet, valSet *DataSet, config *FitC
 				zap.Float32(fmt.Sprintf("Precision@%v", config.TopK), scores[1]),
 				zap.Float32(fmt.Sprintf("Recall@%v", config.TopK), scores[2]))
 		}
+		config.reportProgress(ep, ccd.nEpochs, 0, scores)
 		span.Add(1)
 	}
 	span.End()
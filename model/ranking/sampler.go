@@ -0,0 +1,114 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ranking
+
+import (
+	"sort"
+
+	"github.com/juju/errors"
+	"github.com/zhenghaoz/gorse/base"
+)
+
+// maxSampleAttempts is the maximum number of negative candidates drawn by a sampler before falling back to
+// uniform sampling.
+const maxSampleAttempts = 100
+
+const (
+	UniformSampler      = "uniform"
+	PopularitySampler   = "popularity"
+	HardNegativeSampler = "hard"
+)
+
+// NegativeSampler draws negative items for users while training pairwise models.
+type NegativeSampler interface {
+	// Init prepares the sampler with the training set.
+	Init(trainSet *DataSet)
+	// Sample draws a negative candidate for a user. Candidates the user has interacted with are rejected by callers.
+	Sample(rng base.RandomGenerator, userIndex int32, predict func(userIndex, itemIndex int32) float32) int32
+}
+
+// NewNegativeSampler creates a negative sampler by name. The uniform sampler is used if the name is empty.
+func NewNegativeSampler(name string) (NegativeSampler, error) {
+	switch name {
+	case "", UniformSampler:
+		return &uniformSampler{}, nil
+	case PopularitySampler:
+		return &popularitySampler{}, nil
+	case HardNegativeSampler:
+		return &hardNegativeSampler{numCandidates: 5}, nil
+	default:
+		return nil, errors.NotValidf("negative sampler %s", name)
+	}
+}
+
+// uniformSampler draws negative items uniformly.
+type uniformSampler struct {
+	numItems int32
+}
+
+func (s *uniformSampler) Init(trainSet *DataSet) {
+	s.numItems = int32(trainSet.ItemCount())
+}
+
+func (s *uniformSampler) Sample(rng base.RandomGenerator, _ int32, _ func(userIndex, itemIndex int32) float32) int32 {
+	return rng.Int31n(s.numItems)
+}
+
+// popularitySampler draws negative items proportional to their number of feedback.
+type popularitySampler struct {
+	uniformSampler
+	cumulative []float64
+}
+
+func (s *popularitySampler) Init(trainSet *DataSet) {
+	s.uniformSampler.Init(trainSet)
+	s.cumulative = make([]float64, trainSet.ItemCount())
+	var sum float64
+	for i := range s.cumulative {
+		if i < len(trainSet.ItemFeedback) {
+			sum += float64(len(trainSet.ItemFeedback[i]))
+		}
+		s.cumulative[i] = sum
+	}
+}
+
+func (s *popularitySampler) Sample(rng base.RandomGenerator, userIndex int32, predict func(userIndex, itemIndex int32) float32) int32 {
+	if len(s.cumulative) == 0 || s.cumulative[len(s.cumulative)-1] == 0 {
+		// Fall back to uniform sampling if there is no feedback.
+		return s.uniformSampler.Sample(rng, userIndex, predict)
+	}
+	r := rng.Float64() * s.cumulative[len(s.cumulative)-1]
+	return int32(sort.Search(len(s.cumulative), func(i int) bool {
+		return s.cumulative[i] > r
+	}))
+}
+
+// hardNegativeSampler draws several candidates uniformly and returns the one with the highest predicted score.
+type hardNegativeSampler struct {
+	uniformSampler
+	numCandidates int
+}
+
+func (s *hardNegativeSampler) Sample(rng base.RandomGenerator, userIndex int32, predict func(userIndex, itemIndex int32) float32) int32 {
+	best := s.uniformSampler.Sample(rng, userIndex, predict)
+	bestScore := predict(userIndex, best)
+	for i := 1; i < s.numCandidates; i++ {
+		candidate := s.uniformSampler.Sample(rng, userIndex, predict)
+		if score := predict(userIndex, candidate); score > bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+	return best
+}
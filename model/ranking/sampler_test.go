@@ -0,0 +1,93 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ranking
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhenghaoz/gorse/base"
+)
+
+func newSamplerDataset() *DataSet {
+	dataSet := NewMapIndexDataset()
+	for i := 0; i < 5; i++ {
+		for j := i; j < 5; j++ {
+			dataSet.AddFeedback(strconv.Itoa(i), strconv.Itoa(j), true)
+		}
+	}
+	dataSet.AddItem("5")
+	return dataSet
+}
+
+func TestPopularitySampler(t *testing.T) {
+	dataSet := newSamplerDataset()
+	sampler, err := NewNegativeSampler(PopularitySampler)
+	assert.NoError(t, err)
+	sampler.Init(dataSet)
+
+	const numSamples = 100000
+	rng := base.NewRandomGenerator(0)
+	counts := make([]int, dataSet.ItemCount())
+	for i := 0; i < numSamples; i++ {
+		counts[sampler.Sample(rng, 0, nil)]++
+	}
+	// Item j has received j+1 feedback, 15 feedback in total.
+	for j := 0; j < 5; j++ {
+		assert.InDelta(t, float64(j+1)/15, float64(counts[j])/numSamples, 0.01)
+	}
+	// Items without feedback are never sampled.
+	assert.Zero(t, counts[5])
+}
+
+func TestUniformSampler(t *testing.T) {
+	dataSet := newSamplerDataset()
+	sampler, err := NewNegativeSampler("")
+	assert.NoError(t, err)
+	sampler.Init(dataSet)
+
+	rng := base.NewRandomGenerator(0)
+	counts := make([]int, dataSet.ItemCount())
+	for i := 0; i < 60000; i++ {
+		counts[sampler.Sample(rng, 0, nil)]++
+	}
+	for _, count := range counts {
+		assert.InDelta(t, 10000, count, 500)
+	}
+}
+
+func TestHardNegativeSampler(t *testing.T) {
+	dataSet := newSamplerDataset()
+	sampler, err := NewNegativeSampler(HardNegativeSampler)
+	assert.NoError(t, err)
+	sampler.Init(dataSet)
+
+	// The item with the highest score is sampled more often than uniformly.
+	rng := base.NewRandomGenerator(0)
+	counts := make([]int, dataSet.ItemCount())
+	for i := 0; i < 10000; i++ {
+		counts[sampler.Sample(rng, 0, func(_, itemIndex int32) float32 {
+			return float32(itemIndex)
+		})]++
+	}
+	assert.Greater(t, counts[5], counts[4])
+	assert.Greater(t, counts[5], 5000)
+}
+
+func TestNewNegativeSampler(t *testing.T) {
+	_, err := NewNegativeSampler("unknown")
+	assert.Error(t, err)
+}
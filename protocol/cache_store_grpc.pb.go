@@ -33,23 +33,28 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	CacheStore_Ping_FullMethodName                = "/protocol.CacheStore/Ping"
-	CacheStore_Get_FullMethodName                 = "/protocol.CacheStore/Get"
-	CacheStore_Set_FullMethodName                 = "/protocol.CacheStore/Set"
-	CacheStore_Delete_FullMethodName              = "/protocol.CacheStore/Delete"
-	CacheStore_GetSet_FullMethodName              = "/protocol.CacheStore/GetSet"
-	CacheStore_SetSet_FullMethodName              = "/protocol.CacheStore/SetSet"
-	CacheStore_AddSet_FullMethodName              = "/protocol.CacheStore/AddSet"
-	CacheStore_RemSet_FullMethodName              = "/protocol.CacheStore/RemSet"
-	CacheStore_Push_FullMethodName                = "/protocol.CacheStore/Push"
-	CacheStore_Pop_FullMethodName                 = "/protocol.CacheStore/Pop"
-	CacheStore_Remain_FullMethodName              = "/protocol.CacheStore/Remain"
-	CacheStore_AddScores_FullMethodName           = "/protocol.CacheStore/AddScores"
-	CacheStore_SearchScores_FullMethodName        = "/protocol.CacheStore/SearchScores"
-	CacheStore_DeleteScores_FullMethodName        = "/protocol.CacheStore/DeleteScores"
-	CacheStore_UpdateScores_FullMethodName        = "/protocol.CacheStore/UpdateScores"
-	CacheStore_AddTimeSeriesPoints_FullMethodName = "/protocol.CacheStore/AddTimeSeriesPoints"
-	CacheStore_GetTimeSeriesPoints_FullMethodName = "/protocol.CacheStore/GetTimeSeriesPoints"
+	CacheStore_Ping_FullMethodName                   = "/protocol.CacheStore/Ping"
+	CacheStore_Get_FullMethodName                    = "/protocol.CacheStore/Get"
+	CacheStore_Set_FullMethodName                    = "/protocol.CacheStore/Set"
+	CacheStore_Delete_FullMethodName                 = "/protocol.CacheStore/Delete"
+	CacheStore_GetSet_FullMethodName                 = "/protocol.CacheStore/GetSet"
+	CacheStore_SetSet_FullMethodName                 = "/protocol.CacheStore/SetSet"
+	CacheStore_AddSet_FullMethodName                 = "/protocol.CacheStore/AddSet"
+	CacheStore_RemSet_FullMethodName                 = "/protocol.CacheStore/RemSet"
+	CacheStore_Push_FullMethodName                   = "/protocol.CacheStore/Push"
+	CacheStore_Pop_FullMethodName                    = "/protocol.CacheStore/Pop"
+	CacheStore_Remain_FullMethodName                 = "/protocol.CacheStore/Remain"
+	CacheStore_AddScores_FullMethodName              = "/protocol.CacheStore/AddScores"
+	CacheStore_IncrScores_FullMethodName             = "/protocol.CacheStore/IncrScores"
+	CacheStore_SearchScores_FullMethodName           = "/protocol.CacheStore/SearchScores"
+	CacheStore_DeleteScores_FullMethodName           = "/protocol.CacheStore/DeleteScores"
+	CacheStore_UpdateScores_FullMethodName           = "/protocol.CacheStore/UpdateScores"
+	CacheStore_AddTimeSeriesPoints_FullMethodName    = "/protocol.CacheStore/AddTimeSeriesPoints"
+	CacheStore_IncrTimeSeriesPoints_FullMethodName   = "/protocol.CacheStore/IncrTimeSeriesPoints"
+	CacheStore_GetTimeSeriesPoints_FullMethodName    = "/protocol.CacheStore/GetTimeSeriesPoints"
+	CacheStore_DeleteTimeSeriesPoints_FullMethodName = "/protocol.CacheStore/DeleteTimeSeriesPoints"
+	CacheStore_TryLock_FullMethodName                = "/protocol.CacheStore/TryLock"
+	CacheStore_Unlock_FullMethodName                 = "/protocol.CacheStore/Unlock"
 )
 
 // CacheStoreClient is the client API for CacheStore service.
@@ -68,11 +73,16 @@ type CacheStoreClient interface {
 	Pop(ctx context.Context, in *PopRequest, opts ...grpc.CallOption) (*PopResponse, error)
 	Remain(ctx context.Context, in *RemainRequest, opts ...grpc.CallOption) (*RemainResponse, error)
 	AddScores(ctx context.Context, in *AddScoresRequest, opts ...grpc.CallOption) (*AddScoresResponse, error)
+	IncrScores(ctx context.Context, in *AddScoresRequest, opts ...grpc.CallOption) (*AddScoresResponse, error)
 	SearchScores(ctx context.Context, in *SearchScoresRequest, opts ...grpc.CallOption) (*SearchScoresResponse, error)
 	DeleteScores(ctx context.Context, in *DeleteScoresRequest, opts ...grpc.CallOption) (*DeleteScoresResponse, error)
 	UpdateScores(ctx context.Context, in *UpdateScoresRequest, opts ...grpc.CallOption) (*UpdateScoresResponse, error)
 	AddTimeSeriesPoints(ctx context.Context, in *AddTimeSeriesPointsRequest, opts ...grpc.CallOption) (*AddTimeSeriesPointsResponse, error)
+	IncrTimeSeriesPoints(ctx context.Context, in *AddTimeSeriesPointsRequest, opts ...grpc.CallOption) (*AddTimeSeriesPointsResponse, error)
 	GetTimeSeriesPoints(ctx context.Context, in *GetTimeSeriesPointsRequest, opts ...grpc.CallOption) (*GetTimeSeriesPointsResponse, error)
+	DeleteTimeSeriesPoints(ctx context.Context, in *DeleteTimeSeriesPointsRequest, opts ...grpc.CallOption) (*DeleteTimeSeriesPointsResponse, error)
+	TryLock(ctx context.Context, in *TryLockRequest, opts ...grpc.CallOption) (*TryLockResponse, error)
+	Unlock(ctx context.Context, in *UnlockRequest, opts ...grpc.CallOption) (*UnlockResponse, error)
 }
 
 type cacheStoreClient struct {
@@ -203,6 +213,16 @@ func (c *cacheStoreClient) AddScores(ctx context.Context, in *AddScoresRequest,
 	return out, nil
 }
 
+func (c *cacheStoreClient) IncrScores(ctx context.Context, in *AddScoresRequest, opts ...grpc.CallOption) (*AddScoresResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddScoresResponse)
+	err := c.cc.Invoke(ctx, CacheStore_IncrScores_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *cacheStoreClient) SearchScores(ctx context.Context, in *SearchScoresRequest, opts ...grpc.CallOption) (*SearchScoresResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(SearchScoresResponse)
@@ -243,6 +263,16 @@ func (c *cacheStoreClient) AddTimeSeriesPoints(ctx context.Context, in *AddTimeS
 	return out, nil
 }
 
+func (c *cacheStoreClient) IncrTimeSeriesPoints(ctx context.Context, in *AddTimeSeriesPointsRequest, opts ...grpc.CallOption) (*AddTimeSeriesPointsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddTimeSeriesPointsResponse)
+	err := c.cc.Invoke(ctx, CacheStore_IncrTimeSeriesPoints_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *cacheStoreClient) GetTimeSeriesPoints(ctx context.Context, in *GetTimeSeriesPointsRequest, opts ...grpc.CallOption) (*GetTimeSeriesPointsResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(GetTimeSeriesPointsResponse)
@@ -253,6 +283,36 @@ func (c *cacheStoreClient) GetTimeSeriesPoints(ctx context.Context, in *GetTimeS
 	return out, nil
 }
 
+func (c *cacheStoreClient) DeleteTimeSeriesPoints(ctx context.Context, in *DeleteTimeSeriesPointsRequest, opts ...grpc.CallOption) (*DeleteTimeSeriesPointsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteTimeSeriesPointsResponse)
+	err := c.cc.Invoke(ctx, CacheStore_DeleteTimeSeriesPoints_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cacheStoreClient) TryLock(ctx context.Context, in *TryLockRequest, opts ...grpc.CallOption) (*TryLockResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TryLockResponse)
+	err := c.cc.Invoke(ctx, CacheStore_TryLock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cacheStoreClient) Unlock(ctx context.Context, in *UnlockRequest, opts ...grpc.CallOption) (*UnlockResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnlockResponse)
+	err := c.cc.Invoke(ctx, CacheStore_Unlock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CacheStoreServer is the server API for CacheStore service.
 // All implementations must embed UnimplementedCacheStoreServer
 // for forward compatibility.
@@ -269,11 +329,16 @@ type CacheStoreServer interface {
 	Pop(context.Context, *PopRequest) (*PopResponse, error)
 	Remain(context.Context, *RemainRequest) (*RemainResponse, error)
 	AddScores(context.Context, *AddScoresRequest) (*AddScoresResponse, error)
+	IncrScores(context.Context, *AddScoresRequest) (*AddScoresResponse, error)
 	SearchScores(context.Context, *SearchScoresRequest) (*SearchScoresResponse, error)
 	DeleteScores(context.Context, *DeleteScoresRequest) (*DeleteScoresResponse, error)
 	UpdateScores(context.Context, *UpdateScoresRequest) (*UpdateScoresResponse, error)
 	AddTimeSeriesPoints(context.Context, *AddTimeSeriesPointsRequest) (*AddTimeSeriesPointsResponse, error)
+	IncrTimeSeriesPoints(context.Context, *AddTimeSeriesPointsRequest) (*AddTimeSeriesPointsResponse, error)
 	GetTimeSeriesPoints(context.Context, *GetTimeSeriesPointsRequest) (*GetTimeSeriesPointsResponse, error)
+	DeleteTimeSeriesPoints(context.Context, *DeleteTimeSeriesPointsRequest) (*DeleteTimeSeriesPointsResponse, error)
+	TryLock(context.Context, *TryLockRequest) (*TryLockResponse, error)
+	Unlock(context.Context, *UnlockRequest) (*UnlockResponse, error)
 	mustEmbedUnimplementedCacheStoreServer()
 }
 
@@ -320,6 +385,9 @@ func (UnimplementedCacheStoreServer) Remain(context.Context, *RemainRequest) (*R
 func (UnimplementedCacheStoreServer) AddScores(context.Context, *AddScoresRequest) (*AddScoresResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddScores not implemented")
 }
+func (UnimplementedCacheStoreServer) IncrScores(context.Context, *AddScoresRequest) (*AddScoresResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IncrScores not implemented")
+}
 func (UnimplementedCacheStoreServer) SearchScores(context.Context, *SearchScoresRequest) (*SearchScoresResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SearchScores not implemented")
 }
@@ -332,9 +400,21 @@ func (UnimplementedCacheStoreServer) UpdateScores(context.Context, *UpdateScores
 func (UnimplementedCacheStoreServer) AddTimeSeriesPoints(context.Context, *AddTimeSeriesPointsRequest) (*AddTimeSeriesPointsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddTimeSeriesPoints not implemented")
 }
+func (UnimplementedCacheStoreServer) IncrTimeSeriesPoints(context.Context, *AddTimeSeriesPointsRequest) (*AddTimeSeriesPointsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IncrTimeSeriesPoints not implemented")
+}
 func (UnimplementedCacheStoreServer) GetTimeSeriesPoints(context.Context, *GetTimeSeriesPointsRequest) (*GetTimeSeriesPointsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetTimeSeriesPoints not implemented")
 }
+func (UnimplementedCacheStoreServer) DeleteTimeSeriesPoints(context.Context, *DeleteTimeSeriesPointsRequest) (*DeleteTimeSeriesPointsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteTimeSeriesPoints not implemented")
+}
+func (UnimplementedCacheStoreServer) TryLock(context.Context, *TryLockRequest) (*TryLockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TryLock not implemented")
+}
+func (UnimplementedCacheStoreServer) Unlock(context.Context, *UnlockRequest) (*UnlockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Unlock not implemented")
+}
 func (UnimplementedCacheStoreServer) mustEmbedUnimplementedCacheStoreServer() {}
 func (UnimplementedCacheStoreServer) testEmbeddedByValue()                    {}
 
@@ -572,6 +652,24 @@ func _CacheStore_AddScores_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CacheStore_IncrScores_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddScoresRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheStoreServer).IncrScores(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CacheStore_IncrScores_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheStoreServer).IncrScores(ctx, req.(*AddScoresRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CacheStore_SearchScores_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(SearchScoresRequest)
 	if err := dec(in); err != nil {
@@ -644,6 +742,24 @@ func _CacheStore_AddTimeSeriesPoints_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CacheStore_IncrTimeSeriesPoints_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddTimeSeriesPointsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheStoreServer).IncrTimeSeriesPoints(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CacheStore_IncrTimeSeriesPoints_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheStoreServer).IncrTimeSeriesPoints(ctx, req.(*AddTimeSeriesPointsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CacheStore_GetTimeSeriesPoints_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetTimeSeriesPointsRequest)
 	if err := dec(in); err != nil {
@@ -662,6 +778,60 @@ func _CacheStore_GetTimeSeriesPoints_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CacheStore_DeleteTimeSeriesPoints_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteTimeSeriesPointsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheStoreServer).DeleteTimeSeriesPoints(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CacheStore_DeleteTimeSeriesPoints_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheStoreServer).DeleteTimeSeriesPoints(ctx, req.(*DeleteTimeSeriesPointsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CacheStore_TryLock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TryLockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheStoreServer).TryLock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CacheStore_TryLock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheStoreServer).TryLock(ctx, req.(*TryLockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CacheStore_Unlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CacheStoreServer).Unlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CacheStore_Unlock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CacheStoreServer).Unlock(ctx, req.(*UnlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // CacheStore_ServiceDesc is the grpc.ServiceDesc for CacheStore service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -717,6 +887,10 @@ var CacheStore_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AddScores",
 			Handler:    _CacheStore_AddScores_Handler,
 		},
+		{
+			MethodName: "IncrScores",
+			Handler:    _CacheStore_IncrScores_Handler,
+		},
 		{
 			MethodName: "SearchScores",
 			Handler:    _CacheStore_SearchScores_Handler,
@@ -733,10 +907,26 @@ var CacheStore_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AddTimeSeriesPoints",
 			Handler:    _CacheStore_AddTimeSeriesPoints_Handler,
 		},
+		{
+			MethodName: "IncrTimeSeriesPoints",
+			Handler:    _CacheStore_IncrTimeSeriesPoints_Handler,
+		},
 		{
 			MethodName: "GetTimeSeriesPoints",
 			Handler:    _CacheStore_GetTimeSeriesPoints_Handler,
 		},
+		{
+			MethodName: "DeleteTimeSeriesPoints",
+			Handler:    _CacheStore_DeleteTimeSeriesPoints_Handler,
+		},
+		{
+			MethodName: "TryLock",
+			Handler:    _CacheStore_TryLock_Handler,
+		},
+		{
+			MethodName: "Unlock",
+			Handler:    _CacheStore_Unlock_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "cache_store.proto",
@@ -0,0 +1,135 @@
+// Copyright 2024 gorse Project Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.0
+// source: custom_scorer.proto
+
+package protocol
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	CustomScorer_Score_FullMethodName = "/protocol.CustomScorer/Score"
+)
+
+// CustomScorerClient is the client API for CustomScorer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CustomScorerClient interface {
+	Score(ctx context.Context, in *ScoredItems, opts ...grpc.CallOption) (*ScoredItems, error)
+}
+
+type customScorerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCustomScorerClient(cc grpc.ClientConnInterface) CustomScorerClient {
+	return &customScorerClient{cc}
+}
+
+func (c *customScorerClient) Score(ctx context.Context, in *ScoredItems, opts ...grpc.CallOption) (*ScoredItems, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ScoredItems)
+	err := c.cc.Invoke(ctx, CustomScorer_Score_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CustomScorerServer is the server API for CustomScorer service.
+// All implementations must embed UnimplementedCustomScorerServer
+// for forward compatibility.
+type CustomScorerServer interface {
+	Score(context.Context, *ScoredItems) (*ScoredItems, error)
+	mustEmbedUnimplementedCustomScorerServer()
+}
+
+// UnimplementedCustomScorerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCustomScorerServer struct{}
+
+func (UnimplementedCustomScorerServer) Score(context.Context, *ScoredItems) (*ScoredItems, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Score not implemented")
+}
+func (UnimplementedCustomScorerServer) mustEmbedUnimplementedCustomScorerServer() {}
+func (UnimplementedCustomScorerServer) testEmbeddedByValue()                      {}
+
+// UnsafeCustomScorerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CustomScorerServer will
+// result in compilation errors.
+type UnsafeCustomScorerServer interface {
+	mustEmbedUnimplementedCustomScorerServer()
+}
+
+func RegisterCustomScorerServer(s grpc.ServiceRegistrar, srv CustomScorerServer) {
+	// If the following call pancis, it indicates UnimplementedCustomScorerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CustomScorer_ServiceDesc, srv)
+}
+
+func _CustomScorer_Score_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScoredItems)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CustomScorerServer).Score(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CustomScorer_Score_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CustomScorerServer).Score(ctx, req.(*ScoredItems))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CustomScorer_ServiceDesc is the grpc.ServiceDesc for CustomScorer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CustomScorer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "protocol.CustomScorer",
+	HandlerType: (*CustomScorerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Score",
+			Handler:    _CustomScorer_Score_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "custom_scorer.proto",
+}
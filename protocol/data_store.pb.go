@@ -1620,6 +1620,8 @@ type BatchInsertFeedbackResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
+
+	Feedback []*Feedback `protobuf:"bytes,1,rep,name=feedback,proto3" json:"feedback,omitempty"`
 }
 
 func (x *BatchInsertFeedbackResponse) Reset() {
@@ -1652,6 +1654,13 @@ func (*BatchInsertFeedbackResponse) Descriptor() ([]byte, []int) {
 	return file_data_store_proto_rawDescGZIP(), []int{31}
 }
 
+func (x *BatchInsertFeedbackResponse) GetFeedback() []*Feedback {
+	if x != nil {
+		return x.Feedback
+	}
+	return nil
+}
+
 type GetFeedbackRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -2490,9 +2499,12 @@ var file_data_store_proto_rawDesc = []byte{
 	0x65, 0x72, 0x74, 0x5f, 0x69, 0x74, 0x65, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a,
 	0x69, 0x6e, 0x73, 0x65, 0x72, 0x74, 0x49, 0x74, 0x65, 0x6d, 0x12, 0x1c, 0x0a, 0x09, 0x6f, 0x76,
 	0x65, 0x72, 0x77, 0x72, 0x69, 0x74, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x6f,
-	0x76, 0x65, 0x72, 0x77, 0x72, 0x69, 0x74, 0x65, 0x22, 0x1d, 0x0a, 0x1b, 0x42, 0x61, 0x74, 0x63,
+	0x76, 0x65, 0x72, 0x77, 0x72, 0x69, 0x74, 0x65, 0x22, 0x4d, 0x0a, 0x1b, 0x42, 0x61, 0x74, 0x63,
 	0x68, 0x49, 0x6e, 0x73, 0x65, 0x72, 0x74, 0x46, 0x65, 0x65, 0x64, 0x62, 0x61, 0x63, 0x6b, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0xd3, 0x01, 0x0a, 0x12, 0x47, 0x65, 0x74, 0x46,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2e, 0x0a, 0x08, 0x66, 0x65, 0x65, 0x64, 0x62,
+	0x61, 0x63, 0x6b, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x46, 0x65, 0x65, 0x64, 0x62, 0x61, 0x63, 0x6b, 0x52, 0x08, 0x66,
+	0x65, 0x65, 0x64, 0x62, 0x61, 0x63, 0x6b, 0x22, 0xd3, 0x01, 0x0a, 0x12, 0x47, 0x65, 0x74, 0x46,
 	0x65, 0x65, 0x64, 0x62, 0x61, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16,
 	0x0a, 0x06, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
 	0x63, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x12, 0x0c, 0x0a, 0x01, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28,
@@ -2767,67 +2779,68 @@ var file_data_store_proto_depIdxs = []int32{
 	48, // 12: protocol.GetUsersResponse.users:type_name -> protocol.User
 	46, // 13: protocol.GetUserFeedbackRequest.end_time:type_name -> google.protobuf.Timestamp
 	49, // 14: protocol.BatchInsertFeedbackRequest.feedback:type_name -> protocol.Feedback
-	46, // 15: protocol.GetFeedbackRequest.begin_time:type_name -> google.protobuf.Timestamp
-	46, // 16: protocol.GetFeedbackRequest.end_time:type_name -> google.protobuf.Timestamp
-	49, // 17: protocol.GetFeedbackResponse.feedback:type_name -> protocol.Feedback
-	48, // 18: protocol.GetUserStreamResponse.users:type_name -> protocol.User
-	46, // 19: protocol.GetItemStreamRequest.time_limit:type_name -> google.protobuf.Timestamp
-	47, // 20: protocol.GetItemStreamResponse.items:type_name -> protocol.Item
-	2,  // 21: protocol.GetFeedbackStreamRequest.scan_options:type_name -> protocol.ScanOptions
-	49, // 22: protocol.GetFeedbackStreamResponse.feedback:type_name -> protocol.Feedback
-	50, // 23: protocol.DataStore.Ping:input_type -> protocol.PingRequest
-	3,  // 24: protocol.DataStore.BatchInsertItems:input_type -> protocol.BatchInsertItemsRequest
-	5,  // 25: protocol.DataStore.BatchGetItems:input_type -> protocol.BatchGetItemsRequest
-	7,  // 26: protocol.DataStore.DeleteItem:input_type -> protocol.DeleteItemRequest
-	9,  // 27: protocol.DataStore.GetItem:input_type -> protocol.GetItemRequest
-	11, // 28: protocol.DataStore.ModifyItem:input_type -> protocol.ModifyItemRequest
-	13, // 29: protocol.DataStore.GetItems:input_type -> protocol.GetItemsRequest
-	15, // 30: protocol.DataStore.GetItemFeedback:input_type -> protocol.GetItemFeedbackRequest
-	16, // 31: protocol.DataStore.BatchInsertUsers:input_type -> protocol.BatchInsertUsersRequest
-	18, // 32: protocol.DataStore.DeleteUser:input_type -> protocol.DeleteUserRequest
-	20, // 33: protocol.DataStore.GetUser:input_type -> protocol.GetUserRequest
-	22, // 34: protocol.DataStore.ModifyUser:input_type -> protocol.ModifyUserRequest
-	24, // 35: protocol.DataStore.GetUsers:input_type -> protocol.GetUsersRequest
-	26, // 36: protocol.DataStore.GetUserFeedback:input_type -> protocol.GetUserFeedbackRequest
-	27, // 37: protocol.DataStore.GetUserItemFeedback:input_type -> protocol.GetUserItemFeedbackRequest
-	28, // 38: protocol.DataStore.DeleteUserItemFeedback:input_type -> protocol.DeleteUserItemFeedbackRequest
-	30, // 39: protocol.DataStore.BatchInsertFeedback:input_type -> protocol.BatchInsertFeedbackRequest
-	32, // 40: protocol.DataStore.GetFeedback:input_type -> protocol.GetFeedbackRequest
-	34, // 41: protocol.DataStore.GetUserStream:input_type -> protocol.GetUserStreamRequest
-	36, // 42: protocol.DataStore.GetItemStream:input_type -> protocol.GetItemStreamRequest
-	38, // 43: protocol.DataStore.GetFeedbackStream:input_type -> protocol.GetFeedbackStreamRequest
-	40, // 44: protocol.DataStore.CountUsers:input_type -> protocol.CountUsersRequest
-	42, // 45: protocol.DataStore.CountItems:input_type -> protocol.CountItemsRequest
-	44, // 46: protocol.DataStore.CountFeedback:input_type -> protocol.CountFeedbackRequest
-	51, // 47: protocol.DataStore.Ping:output_type -> protocol.PingResponse
-	4,  // 48: protocol.DataStore.BatchInsertItems:output_type -> protocol.BatchInsertItemsResponse
-	6,  // 49: protocol.DataStore.BatchGetItems:output_type -> protocol.BatchGetItemsResponse
-	8,  // 50: protocol.DataStore.DeleteItem:output_type -> protocol.DeleteItemResponse
-	10, // 51: protocol.DataStore.GetItem:output_type -> protocol.GetItemResponse
-	12, // 52: protocol.DataStore.ModifyItem:output_type -> protocol.ModifyItemResponse
-	14, // 53: protocol.DataStore.GetItems:output_type -> protocol.GetItemsResponse
-	33, // 54: protocol.DataStore.GetItemFeedback:output_type -> protocol.GetFeedbackResponse
-	17, // 55: protocol.DataStore.BatchInsertUsers:output_type -> protocol.BatchInsertUsersResponse
-	19, // 56: protocol.DataStore.DeleteUser:output_type -> protocol.DeleteUserResponse
-	21, // 57: protocol.DataStore.GetUser:output_type -> protocol.GetUserResponse
-	23, // 58: protocol.DataStore.ModifyUser:output_type -> protocol.ModifyUserResponse
-	25, // 59: protocol.DataStore.GetUsers:output_type -> protocol.GetUsersResponse
-	33, // 60: protocol.DataStore.GetUserFeedback:output_type -> protocol.GetFeedbackResponse
-	33, // 61: protocol.DataStore.GetUserItemFeedback:output_type -> protocol.GetFeedbackResponse
-	29, // 62: protocol.DataStore.DeleteUserItemFeedback:output_type -> protocol.DeleteUserItemFeedbackResponse
-	31, // 63: protocol.DataStore.BatchInsertFeedback:output_type -> protocol.BatchInsertFeedbackResponse
-	33, // 64: protocol.DataStore.GetFeedback:output_type -> protocol.GetFeedbackResponse
-	35, // 65: protocol.DataStore.GetUserStream:output_type -> protocol.GetUserStreamResponse
-	37, // 66: protocol.DataStore.GetItemStream:output_type -> protocol.GetItemStreamResponse
-	39, // 67: protocol.DataStore.GetFeedbackStream:output_type -> protocol.GetFeedbackStreamResponse
-	41, // 68: protocol.DataStore.CountUsers:output_type -> protocol.CountUsersResponse
-	43, // 69: protocol.DataStore.CountItems:output_type -> protocol.CountItemsResponse
-	45, // 70: protocol.DataStore.CountFeedback:output_type -> protocol.CountFeedbackResponse
-	47, // [47:71] is the sub-list for method output_type
-	23, // [23:47] is the sub-list for method input_type
-	23, // [23:23] is the sub-list for extension type_name
-	23, // [23:23] is the sub-list for extension extendee
-	0,  // [0:23] is the sub-list for field type_name
+	49, // 15: protocol.BatchInsertFeedbackResponse.feedback:type_name -> protocol.Feedback
+	46, // 16: protocol.GetFeedbackRequest.begin_time:type_name -> google.protobuf.Timestamp
+	46, // 17: protocol.GetFeedbackRequest.end_time:type_name -> google.protobuf.Timestamp
+	49, // 18: protocol.GetFeedbackResponse.feedback:type_name -> protocol.Feedback
+	48, // 19: protocol.GetUserStreamResponse.users:type_name -> protocol.User
+	46, // 20: protocol.GetItemStreamRequest.time_limit:type_name -> google.protobuf.Timestamp
+	47, // 21: protocol.GetItemStreamResponse.items:type_name -> protocol.Item
+	2,  // 22: protocol.GetFeedbackStreamRequest.scan_options:type_name -> protocol.ScanOptions
+	49, // 23: protocol.GetFeedbackStreamResponse.feedback:type_name -> protocol.Feedback
+	50, // 24: protocol.DataStore.Ping:input_type -> protocol.PingRequest
+	3,  // 25: protocol.DataStore.BatchInsertItems:input_type -> protocol.BatchInsertItemsRequest
+	5,  // 26: protocol.DataStore.BatchGetItems:input_type -> protocol.BatchGetItemsRequest
+	7,  // 27: protocol.DataStore.DeleteItem:input_type -> protocol.DeleteItemRequest
+	9,  // 28: protocol.DataStore.GetItem:input_type -> protocol.GetItemRequest
+	11, // 29: protocol.DataStore.ModifyItem:input_type -> protocol.ModifyItemRequest
+	13, // 30: protocol.DataStore.GetItems:input_type -> protocol.GetItemsRequest
+	15, // 31: protocol.DataStore.GetItemFeedback:input_type -> protocol.GetItemFeedbackRequest
+	16, // 32: protocol.DataStore.BatchInsertUsers:input_type -> protocol.BatchInsertUsersRequest
+	18, // 33: protocol.DataStore.DeleteUser:input_type -> protocol.DeleteUserRequest
+	20, // 34: protocol.DataStore.GetUser:input_type -> protocol.GetUserRequest
+	22, // 35: protocol.DataStore.ModifyUser:input_type -> protocol.ModifyUserRequest
+	24, // 36: protocol.DataStore.GetUsers:input_type -> protocol.GetUsersRequest
+	26, // 37: protocol.DataStore.GetUserFeedback:input_type -> protocol.GetUserFeedbackRequest
+	27, // 38: protocol.DataStore.GetUserItemFeedback:input_type -> protocol.GetUserItemFeedbackRequest
+	28, // 39: protocol.DataStore.DeleteUserItemFeedback:input_type -> protocol.DeleteUserItemFeedbackRequest
+	30, // 40: protocol.DataStore.BatchInsertFeedback:input_type -> protocol.BatchInsertFeedbackRequest
+	32, // 41: protocol.DataStore.GetFeedback:input_type -> protocol.GetFeedbackRequest
+	34, // 42: protocol.DataStore.GetUserStream:input_type -> protocol.GetUserStreamRequest
+	36, // 43: protocol.DataStore.GetItemStream:input_type -> protocol.GetItemStreamRequest
+	38, // 44: protocol.DataStore.GetFeedbackStream:input_type -> protocol.GetFeedbackStreamRequest
+	40, // 45: protocol.DataStore.CountUsers:input_type -> protocol.CountUsersRequest
+	42, // 46: protocol.DataStore.CountItems:input_type -> protocol.CountItemsRequest
+	44, // 47: protocol.DataStore.CountFeedback:input_type -> protocol.CountFeedbackRequest
+	51, // 48: protocol.DataStore.Ping:output_type -> protocol.PingResponse
+	4,  // 49: protocol.DataStore.BatchInsertItems:output_type -> protocol.BatchInsertItemsResponse
+	6,  // 50: protocol.DataStore.BatchGetItems:output_type -> protocol.BatchGetItemsResponse
+	8,  // 51: protocol.DataStore.DeleteItem:output_type -> protocol.DeleteItemResponse
+	10, // 52: protocol.DataStore.GetItem:output_type -> protocol.GetItemResponse
+	12, // 53: protocol.DataStore.ModifyItem:output_type -> protocol.ModifyItemResponse
+	14, // 54: protocol.DataStore.GetItems:output_type -> protocol.GetItemsResponse
+	33, // 55: protocol.DataStore.GetItemFeedback:output_type -> protocol.GetFeedbackResponse
+	17, // 56: protocol.DataStore.BatchInsertUsers:output_type -> protocol.BatchInsertUsersResponse
+	19, // 57: protocol.DataStore.DeleteUser:output_type -> protocol.DeleteUserResponse
+	21, // 58: protocol.DataStore.GetUser:output_type -> protocol.GetUserResponse
+	23, // 59: protocol.DataStore.ModifyUser:output_type -> protocol.ModifyUserResponse
+	25, // 60: protocol.DataStore.GetUsers:output_type -> protocol.GetUsersResponse
+	33, // 61: protocol.DataStore.GetUserFeedback:output_type -> protocol.GetFeedbackResponse
+	33, // 62: protocol.DataStore.GetUserItemFeedback:output_type -> protocol.GetFeedbackResponse
+	29, // 63: protocol.DataStore.DeleteUserItemFeedback:output_type -> protocol.DeleteUserItemFeedbackResponse
+	31, // 64: protocol.DataStore.BatchInsertFeedback:output_type -> protocol.BatchInsertFeedbackResponse
+	33, // 65: protocol.DataStore.GetFeedback:output_type -> protocol.GetFeedbackResponse
+	35, // 66: protocol.DataStore.GetUserStream:output_type -> protocol.GetUserStreamResponse
+	37, // 67: protocol.DataStore.GetItemStream:output_type -> protocol.GetItemStreamResponse
+	39, // 68: protocol.DataStore.GetFeedbackStream:output_type -> protocol.GetFeedbackStreamResponse
+	41, // 69: protocol.DataStore.CountUsers:output_type -> protocol.CountUsersResponse
+	43, // 70: protocol.DataStore.CountItems:output_type -> protocol.CountItemsResponse
+	45, // 71: protocol.DataStore.CountFeedback:output_type -> protocol.CountFeedbackResponse
+	48, // [48:72] is the sub-list for method output_type
+	24, // [24:48] is the sub-list for method input_type
+	24, // [24:24] is the sub-list for extension type_name
+	24, // [24:24] is the sub-list for extension extendee
+	0,  // [0:24] is the sub-list for field type_name
 }
 
 func init() { file_data_store_proto_init() }
@@ -155,7 +155,7 @@ func newBenchServer(b *testing.B) *benchServer {
 			Timestamp: time.Now(),
 		})
 	}
-	err = s.DataClient.BatchInsertFeedback(ctx, feedbacks, true, true, true)
+	_, err = s.DataClient.BatchInsertFeedback(ctx, feedbacks, true, true, true)
 	require.NoError(b, err)
 
 	// start http server
@@ -854,7 +854,7 @@ func BenchmarkRecommendFromOfflineCache(b *testing.B) {
 			lo.Reverse(expects)
 			err := s.CacheClient.AddScores(ctx, cache.OfflineRecommend, "init_user_1", documents)
 			require.NoError(b, err)
-			err = s.DataClient.BatchInsertFeedback(ctx, feedbacks, true, true, true)
+			_, err = s.DataClient.BatchInsertFeedback(ctx, feedbacks, true, true, true)
 			require.NoError(b, err)
 			s.Config.Recommend.CacheSize = len(documents)
 
@@ -905,7 +905,7 @@ func BenchmarkRecommendFromLatest(b *testing.B) {
 			lo.Reverse(expects)
 			err := s.CacheClient.AddScores(ctx, cache.NonPersonalized, cache.Latest, documents)
 			require.NoError(b, err)
-			err = s.DataClient.BatchInsertFeedback(ctx, feedbacks, true, true, true)
+			_, err = s.DataClient.BatchInsertFeedback(ctx, feedbacks, true, true, true)
 			require.NoError(b, err)
 			s.Config.Recommend.CacheSize = len(documents)
 
@@ -944,7 +944,7 @@ func BenchmarkRecommendFromItemBased(b *testing.B) {
 				documents[i].Score = float64(i)
 				documents[i].Categories = []string{""}
 				if i < s.Config.Recommend.Online.NumFeedbackFallbackItemBased {
-					err := s.DataClient.BatchInsertFeedback(ctx, []data.Feedback{{
+					_, err := s.DataClient.BatchInsertFeedback(ctx, []data.Feedback{{
 						FeedbackKey: data.FeedbackKey{
 							FeedbackType: "feedback_type_positive",
 							UserId:       "init_user_1",
@@ -16,12 +16,18 @@ package server
 
 import (
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
 	"net/http"
 	"net/http/pprof"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/araddon/dateparse"
@@ -33,13 +39,19 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/samber/lo"
 	"github.com/thoas/go-funk"
+	"github.com/zhenghaoz/gorse/base"
 	"github.com/zhenghaoz/gorse/base/heap"
 	"github.com/zhenghaoz/gorse/base/log"
+	"github.com/zhenghaoz/gorse/common/util"
 	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/logics"
+	"github.com/zhenghaoz/gorse/protocol"
 	"github.com/zhenghaoz/gorse/storage/cache"
 	"github.com/zhenghaoz/gorse/storage/data"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/emicklei/go-restful/otelrestful"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/protobuf/proto"
 	"modernc.org/mathutil"
 )
@@ -54,6 +66,147 @@ const (
 	DetractedAPITag      = "deprecated"
 )
 
+// RouteTimeout is the metadata key of routes bounded by a timeout other than the request timeout. The value is
+// RecommendTimeout or SearchTimeout.
+const RouteTimeout = "timeout"
+
+// RouteMutating is the metadata key of routes modifying data. Read-only users of the dashboard are not allowed to
+// call these routes.
+const RouteMutating = "mutating"
+
+const (
+	RecommendTimeout = "recommend"
+	SearchTimeout    = "search"
+)
+
+// MIME_CSV is the MIME type for comma-separated values.
+const MIME_CSV = "text/csv"
+
+// MaxExcludeSize is the maximal number of items excluded explicitly by a recommendation request.
+const MaxExcludeSize = 1000
+
+// RecommendationsServed is the time series of the number of recommendations served per minute.
+const RecommendationsServed = "RecommendationsServed"
+
+// ItemFeedbackPerMinute is the prefix of time series of the number of feedback per item per minute. Only feedback
+// within ItemFeedbackWindow is counted.
+const ItemFeedbackPerMinute = "ItemFeedbackPerMinute"
+
+// ItemFeedbackWindow is the window of feedback counted in ItemFeedbackPerMinute.
+const ItemFeedbackWindow = 2 * time.Hour
+
+// ItemFeedbackActive is the collection of items with time series in ItemFeedbackPerMinute. The timestamp of an item
+// is the last time its time series was updated, so time series of items older than ItemFeedbackWindow are empty.
+const ItemFeedbackActive = "ItemFeedbackActive"
+
+// FeedbackTimeline is the time series of the number of feedback inserted per hour. Feedback is counted by the time
+// it is inserted rather than its timestamp.
+const FeedbackTimeline = "FeedbackTimeline"
+
+// RecommendHistory is the collection of recommendations served to each user. Each document is an event of serving
+// recommendations scored by the serving time, and items of the event are stored in a value expiring after
+// RecommendHistoryWindow. The format of key:
+//
+//	Events of a user - RecommendHistory/{user_id}
+//	Items of an event - RecommendHistory/{user_id}/{event_id}
+const RecommendHistory = "RecommendHistory"
+
+// RecommendHistoryWindow is the window of served recommendations kept in RecommendHistory.
+const RecommendHistoryWindow = 7 * 24 * time.Hour
+
+// maxRecommendHistory is the maximum number of events of served recommendations kept for a user.
+const maxRecommendHistory = 1000
+
+// Exposure is the number of times an item was recommended to each user, stored in the collection with item id as
+// subset and user id as document id. Users not exposed to the item within ExposureWindow are pruned by the master.
+const Exposure = "Exposure"
+
+// ExposureWindow is the window of exposure kept in Exposure.
+const ExposureWindow = 7 * 24 * time.Hour
+
+// exposureFlushInterval is the interval to write buffered exposure to the cache.
+const exposureFlushInterval = 10 * time.Second
+
+// ActiveABTest is the A/B experiment applied to online recommendation, stored in JSON.
+const ActiveABTest = "ActiveABTest"
+
+// ABTestGroup is a group of users receiving recommendations by a strategy. The strategy is the first recommender,
+// which is one of offline, blend, collaborative, item_based, user_based, latest and popular. Fallback recommenders
+// are appended.
+type ABTestGroup struct {
+	Id       string  `json:"id"`
+	Fraction float64 `json:"fraction"`
+	Strategy string  `json:"strategy"`
+}
+
+// ABTest is an A/B experiment splitting users into groups by fractions.
+type ABTest struct {
+	Name   string        `json:"name"`
+	Groups []ABTestGroup `json:"groups"`
+}
+
+// Validate checks that group ids are unique, strategies are known and fractions sum to one.
+func (t *ABTest) Validate() error {
+	if t.Name == "" {
+		return errors.NotValidf("empty experiment name")
+	}
+	if len(t.Groups) == 0 {
+		return errors.NotValidf("experiment without groups")
+	}
+	ids := mapset.NewSet[string]()
+	var sum float64
+	for _, group := range t.Groups {
+		if group.Id == "" || ids.Contains(group.Id) {
+			return errors.NotValidf("group id `%s`", group.Id)
+		}
+		ids.Add(group.Id)
+		if group.Fraction <= 0 {
+			return errors.NotValidf("fraction %v of group `%s`", group.Fraction, group.Id)
+		}
+		if !lo.Contains(recommendStrategies, group.Strategy) {
+			return errors.NotValidf("strategy `%s` of group `%s`", group.Strategy, group.Id)
+		}
+		sum += group.Fraction
+	}
+	if math.Abs(sum-1) > 1e-6 {
+		return errors.NotValidf("sum of fractions %v", sum)
+	}
+	return nil
+}
+
+// Assign returns the group of a user. Users are assigned by the hash of the experiment name and the user id, so a
+// user always falls in the same group of an experiment.
+func (t *ABTest) Assign(userId string) ABTestGroup {
+	hash := fnv.New64a()
+	hash.Write([]byte(t.Name))
+	hash.Write([]byte{0})
+	hash.Write([]byte(userId))
+	position := float64(hash.Sum64()) / math.Pow(2, 64)
+	var cumulative float64
+	for _, group := range t.Groups {
+		cumulative += group.Fraction
+		if position < cumulative {
+			return group
+		}
+	}
+	return t.Groups[len(t.Groups)-1]
+}
+
+// GetABTest returns the active A/B experiment, or nil if there is no active experiment.
+func GetABTest(ctx context.Context, cacheClient cache.Database) (*ABTest, error) {
+	text, err := cacheClient.Get(ctx, ActiveABTest).String()
+	if errors.Is(err, errors.NotFound) || (err == nil && text == "") {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var experiment ABTest
+	if err = json.Unmarshal([]byte(text), &experiment); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &experiment, nil
+}
+
 // RestServer implements a REST-ful API server.
 type RestServer struct {
 	*config.Settings
@@ -64,6 +217,28 @@ type RestServer struct {
 	DisableLog bool
 	WebService *restful.WebService
 	HttpServer *http.Server
+	TLSConfig  *util.TLSConfig // TLS config of connections to custom scorers
+
+	// ItemsInserted is called with items inserted or modified via the REST API if not nil.
+	ItemsInserted func(items []data.Item)
+
+	scorersMutex sync.Mutex
+	scorerConns  map[string]*grpc.ClientConn
+
+	scoreExprMutex          sync.Mutex
+	compiledScoreExpr       *logics.ScoreExpr
+	compiledScoreExprSource string
+
+	exposureMutex  sync.Mutex
+	exposureBuffer map[string]map[string]int
+
+	blocklistMutex sync.Mutex
+	blocklist      mapset.Set[string]
+	blocklistTime  time.Time
+
+	abTestMutex sync.Mutex
+	abTest      *ABTest
+	abTestTime  time.Time
 }
 
 // StartHttpServer starts the REST-ful API server.
@@ -112,6 +287,7 @@ func (s *RestServer) StartHttpServer(container *restful.Container) {
 		Addr:    fmt.Sprintf("%s:%d", s.HttpHost, s.HttpPort),
 		Handler: container,
 	}
+	go s.flushExposureLoop()
 	if err := s.HttpServer.ListenAndServe(); err != http.ErrServerClosed {
 		log.Logger().Fatal("failed to start http server", zap.Error(err))
 	}
@@ -156,6 +332,39 @@ func (s *RestServer) AuthFilter(req *restful.Request, resp *restful.Response, ch
 	}
 }
 
+// TimeoutFilter sets the deadline of the request context to the timeout of the route. Storage queries are canceled
+// after the deadline and the request is replied with 504.
+func (s *RestServer) TimeoutFilter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	timeout := s.routeTimeout(req)
+	if timeout <= 0 {
+		chain.ProcessFilter(req, resp)
+		return
+	}
+	ctx, cancel := context.WithTimeout(req.Request.Context(), timeout)
+	defer cancel()
+	req.Request = req.Request.WithContext(ctx)
+	chain.ProcessFilter(req, resp)
+}
+
+// routeTimeout returns the timeout of the route of a request. Recommendation and search routes are tagged by
+// RouteTimeout, dashboard routes are matched by path and other routes are bounded by the request timeout.
+func (s *RestServer) routeTimeout(req *restful.Request) time.Duration {
+	route := req.SelectedRoute()
+	if route == nil {
+		return s.Config.Server.RequestTimeout
+	}
+	switch route.Metadata()[RouteTimeout] {
+	case RecommendTimeout:
+		return s.Config.Server.RecommendTimeout
+	case SearchTimeout:
+		return s.Config.Server.SearchTimeout
+	}
+	if strings.HasPrefix(route.Path(), "/api/dashboard") {
+		return s.Config.Server.DashboardTimeout
+	}
+	return s.Config.Server.RequestTimeout
+}
+
 func (s *RestServer) MetricsFilter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
 	startTime := time.Now()
 	chain.ProcessFilter(req, resp)
@@ -176,6 +385,7 @@ func (s *RestServer) CreateWebService() {
 		Produces(restful.MIME_JSON).
 		Filter(s.LogFilter).
 		Filter(s.AuthFilter).
+		Filter(s.TimeoutFilter).
 		Filter(s.MetricsFilter).
 		Filter(otelrestful.OTelFilter("gorse"))
 
@@ -195,6 +405,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.POST("/user").To(s.insertUser).
 		Doc("Insert a user.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{UsersAPITag}).
+		Metadata(RouteMutating, true).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Reads(data.User{}).
 		Returns(http.StatusOK, "OK", Success{}).
@@ -203,6 +414,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.PATCH("/user/{user-id}").To(s.modifyUser).
 		Doc("Modify a user.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{UsersAPITag}).
+		Metadata(RouteMutating, true).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.PathParameter("user-id", "ID of the user to modify").DataType("string")).
 		Reads(data.UserPatch{}).
@@ -220,6 +432,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.POST("/users").To(s.insertUsers).
 		Doc("Insert users.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{UsersAPITag}).
+		Metadata(RouteMutating, true).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Reads([]data.User{}).
 		Returns(http.StatusOK, "OK", Success{}).
@@ -237,6 +450,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.DELETE("/user/{user-id}").To(s.deleteUser).
 		Doc("Delete a user and his or her feedback.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{UsersAPITag}).
+		Metadata(RouteMutating, true).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.PathParameter("user-id", "ID of the user to delete").DataType("string")).
 		Returns(http.StatusOK, "OK", Success{}).
@@ -246,6 +460,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.POST("/item").To(s.insertItem).
 		Doc("Insert an item. Overwrite if the item exists.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{ItemsAPITag}).
+		Metadata(RouteMutating, true).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Reads(data.Item{}).
 		Returns(http.StatusOK, "OK", Success{}).
@@ -254,6 +469,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.PATCH("/item/{item-id}").To(s.modifyItem).
 		Doc("Modify an item.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{ItemsAPITag}).
+		Metadata(RouteMutating, true).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.PathParameter("item-id", "ID of the item to modify").DataType("string")).
 		Reads(data.ItemPatch{}).
@@ -263,9 +479,11 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.GET("/items").To(s.getItems).
 		Doc("Get items.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{ItemsAPITag}).
+		Metadata(RouteTimeout, SearchTimeout).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.QueryParameter("n", "Number of returned items").DataType("integer")).
 		Param(ws.QueryParameter("cursor", "Cursor for the next page").DataType("string")).
+		Param(ws.QueryParameter("label", "Label filter in the form of name:value, nested labels are named by dotted paths such as spec.color and literal dots are escaped by backslashes").DataType("string")).
 		Returns(http.StatusOK, "OK", ItemIterator{}).
 		Writes(ItemIterator{}))
 	// Get item
@@ -280,6 +498,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.POST("/items").To(s.insertItems).
 		Doc("Insert items. Overwrite if items exist").
 		Metadata(restfulspec.KeyOpenAPITags, []string{ItemsAPITag}).
+		Metadata(RouteMutating, true).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Reads([]data.Item{}).
 		Returns(http.StatusOK, "OK", Success{}).
@@ -288,6 +507,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.DELETE("/item/{item-id}").To(s.deleteItem).
 		Doc("Delete an item and its feedback.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{ItemsAPITag}).
+		Metadata(RouteMutating, true).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.PathParameter("item-id", "ID of the item to delete").DataType("string")).
 		Returns(http.StatusOK, "OK", Success{}).
@@ -296,6 +516,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.PUT("/item/{item-id}/category/{category}").To(s.insertItemCategory).
 		Doc("Insert a category for a item.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{ItemsAPITag}).
+		Metadata(RouteMutating, true).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.PathParameter("item-id", "ID of the item to insert category").DataType("string")).
 		Param(ws.PathParameter("category", "Category to insert").DataType("string")).
@@ -305,6 +526,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.DELETE("/item/{item-id}/category/{category}").To(s.deleteItemCategory).
 		Doc("Delete a category from a item.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{ItemsAPITag}).
+		Metadata(RouteMutating, true).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.PathParameter("item-id", "ID of the item to delete categoryßßß").DataType("string")).
 		Param(ws.PathParameter("category", "Category to delete").DataType("string")).
@@ -314,14 +536,22 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.POST("/feedback").To(s.insertFeedback(false)).
 		Doc("Insert feedbacks. Ignore insertion if feedback exists.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{FeedbackAPITag}).
+		Metadata(RouteMutating, true).
+		Consumes(restful.MIME_JSON, MIME_CSV).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
+		Param(ws.HeaderParameter("Idempotency-Key", "Key to deduplicate retried requests").DataType("string")).
+		Param(ws.QueryParameter("partial", "insert valid feedback and report invalid feedback").DataType("boolean").DefaultValue("false")).
 		Reads([]data.Feedback{}).
 		Returns(http.StatusOK, "OK", Success{}).
 		Writes(Success{}))
 	ws.Route(ws.PUT("/feedback").To(s.insertFeedback(true)).
 		Doc("Insert feedbacks. Existed feedback will be overwritten.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{FeedbackAPITag}).
+		Metadata(RouteMutating, true).
+		Consumes(restful.MIME_JSON, MIME_CSV).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
+		Param(ws.HeaderParameter("Idempotency-Key", "Key to deduplicate retried requests").DataType("string")).
+		Param(ws.QueryParameter("partial", "insert valid feedback and report invalid feedback").DataType("boolean").DefaultValue("false")).
 		Reads([]data.Feedback{}).
 		Returns(http.StatusOK, "OK", Success{}).
 		Writes(Success{}))
@@ -345,6 +575,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.DELETE("/feedback/{user-id}/{item-id}").To(s.deleteUserItemFeedback).
 		Doc("Delete feedbacks between a user and a item.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{FeedbackAPITag}).
+		Metadata(RouteMutating, true).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.PathParameter("user-id", "User ID of returned feedbacks").DataType("string")).
 		Param(ws.PathParameter("item-id", "Item ID of returned feedbacks").DataType("string")).
@@ -371,6 +602,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.DELETE("/feedback/{feedback-type}/{user-id}/{item-id}").To(s.deleteTypedUserItemFeedback).
 		Doc("Delete feedbacks between a user and a item with feedback type.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{FeedbackAPITag}).
+		Metadata(RouteMutating, true).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.PathParameter("feedback-type", "Type of returned feedbacks").DataType("string")).
 		Param(ws.PathParameter("user-id", "User ID of returned feedbacks").DataType("string")).
@@ -414,6 +646,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.GET("/intermediate/recommend/{user-id}").To(s.getCollaborative).
 		Doc("Get the collaborative filtering recommendation for a user").
 		Metadata(restfulspec.KeyOpenAPITags, []string{DetractedAPITag}).
+		Metadata(RouteTimeout, RecommendTimeout).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.PathParameter("user-id", "ID of the user to get recommendation").DataType("string")).
 		Param(ws.QueryParameter("n", "Number of returned items").DataType("integer")).
@@ -423,6 +656,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.GET("/intermediate/recommend/{user-id}/{category}").To(s.getCollaborative).
 		Doc("Get the collaborative filtering recommendation for a user").
 		Metadata(restfulspec.KeyOpenAPITags, []string{DetractedAPITag}).
+		Metadata(RouteTimeout, RecommendTimeout).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.PathParameter("user-id", "ID of the user to get recommendation").DataType("string")).
 		Param(ws.PathParameter("category", "Category of returned items.").DataType("string")).
@@ -435,6 +669,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.GET("/popular").To(s.getPopular).
 		Doc("Get popular items.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{RecommendationAPITag}).
+		Metadata(RouteTimeout, RecommendTimeout).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.QueryParameter("category", "Category of returned items").DataType("string")).
 		Param(ws.QueryParameter("n", "Number of returned recommendations").DataType("integer")).
@@ -445,6 +680,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.GET("/popular/{category}").To(s.getPopular).
 		Doc("Get popular items in category.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{RecommendationAPITag}).
+		Metadata(RouteTimeout, RecommendTimeout).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.PathParameter("category", "Category of returned items.").DataType("string")).
 		Param(ws.QueryParameter("n", "Number of returned items").DataType("integer")).
@@ -456,6 +692,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.GET("/latest").To(s.getLatest).
 		Doc("Get the latest items.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{RecommendationAPITag}).
+		Metadata(RouteTimeout, RecommendTimeout).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.QueryParameter("category", "Category of returned items").DataType("string")).
 		Param(ws.QueryParameter("n", "Number of returned items").DataType("integer")).
@@ -466,6 +703,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.GET("/latest/{category}").To(s.getLatest).
 		Doc("Get the latest items in category.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{RecommendationAPITag}).
+		Metadata(RouteTimeout, RecommendTimeout).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.PathParameter("category", "Category of returned items.").DataType("string")).
 		Param(ws.QueryParameter("n", "Number of returned items").DataType("integer")).
@@ -477,6 +715,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.GET("/non-personalized/{name}").To(s.getNonPersonalized).
 		Doc("Get non-personalized recommendations.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{RecommendationAPITag}).
+		Metadata(RouteTimeout, RecommendTimeout).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.QueryParameter("category", "Category of returned items.").DataType("string")).
 		Param(ws.QueryParameter("n", "Number of returned users").DataType("integer")).
@@ -488,6 +727,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.GET("/item-to-item/{name}/{item-id}").To(s.getItemToItem).
 		Doc("Get item-to-item recommendation.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{RecommendationAPITag}).
+		Metadata(RouteTimeout, RecommendTimeout).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.PathParameter("name", "Name of the item-to-item recommendation").DataType("string")).
 		Param(ws.PathParameter("item-id", "ID of the item to get neighbors").DataType("string")).
@@ -499,6 +739,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.GET("/user-to-user/neighbors/{user-id}").To(s.getUserNeighbors).
 		Doc("Get user-to-user recommendation.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{RecommendationAPITag}).
+		Metadata(RouteTimeout, RecommendTimeout).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.PathParameter("name", "Name of the user-to-user recommendation").DataType("string")).
 		Param(ws.PathParameter("user-id", "ID of the user to get neighbors").DataType("string")).
@@ -510,6 +751,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.GET("/item/{item-id}/neighbors/").To(s.getItemNeighbors).
 		Doc("Get neighbors of a item").
 		Metadata(restfulspec.KeyOpenAPITags, []string{RecommendationAPITag}).
+		Metadata(RouteTimeout, RecommendTimeout).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.PathParameter("item-id", "ID of the item to get neighbors").DataType("string")).
 		Param(ws.QueryParameter("n", "Number of returned items").DataType("integer")).
@@ -519,6 +761,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.GET("/item/{item-id}/neighbors/{category}").To(s.getItemNeighbors).
 		Doc("Get neighbors of a item in category.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{RecommendationAPITag}).
+		Metadata(RouteTimeout, RecommendTimeout).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.PathParameter("item-id", "ID of the item to get neighbors").DataType("string")).
 		Param(ws.PathParameter("category", "Category of returned items").DataType("string")).
@@ -529,6 +772,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.GET("/user/{user-id}/neighbors/").To(s.getUserNeighbors).
 		Doc("Get neighbors of a user.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{RecommendationAPITag}).
+		Metadata(RouteTimeout, RecommendTimeout).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.PathParameter("user-id", "ID of the user to get neighbors").DataType("string")).
 		Param(ws.QueryParameter("n", "Number of returned users").DataType("integer")).
@@ -538,30 +782,35 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.GET("/recommend/{user-id}").To(s.getRecommend).
 		Doc("Get recommendation for user.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{RecommendationAPITag}).
+		Metadata(RouteTimeout, RecommendTimeout).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.PathParameter("user-id", "ID of the user to get recommendation").DataType("string")).
-		Param(ws.QueryParameter("category", "Category of the returned items (support multi-categories filtering)").DataType("string")).
+		Param(ws.QueryParameter("category", "Category of the returned items (support multi-categories filtering, or comma-separated categories for items in any of them)").DataType("string")).
 		Param(ws.QueryParameter("write-back-type", "Type of write back feedback").DataType("string")).
 		Param(ws.QueryParameter("write-back-delay", "Timestamp delay of write back feedback (format 0h0m0s)").DataType("string")).
 		Param(ws.QueryParameter("n", "Number of returned items").DataType("integer")).
 		Param(ws.QueryParameter("offset", "Offset of returned items").DataType("integer")).
+		Param(ws.QueryParameter("exclude", "Comma-separated IDs of items excluded from recommendation (at most 1000 items)").DataType("string")).
 		Returns(http.StatusOK, "OK", []string{}).
 		Writes([]string{}))
 	ws.Route(ws.GET("/recommend/{user-id}/{category}").To(s.getRecommend).
 		Doc("Get recommendation for user.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{RecommendationAPITag}).
+		Metadata(RouteTimeout, RecommendTimeout).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.PathParameter("user-id", "ID of the user to get recommendation").DataType("string")).
-		Param(ws.PathParameter("category", "Category of the returned items").DataType("string")).
+		Param(ws.PathParameter("category", "Category of the returned items (comma-separated categories for items in any of them)").DataType("string")).
 		Param(ws.QueryParameter("write-back-type", "Type of write back feedback").DataType("string")).
 		Param(ws.QueryParameter("write-back-delay", "Timestamp delay of write back feedback (format 0h0m0s)").DataType("string")).
 		Param(ws.QueryParameter("n", "Number of returned items").DataType("integer")).
 		Param(ws.QueryParameter("offset", "Offset of returned items").DataType("integer")).
+		Param(ws.QueryParameter("exclude", "Comma-separated IDs of items excluded from recommendation (at most 1000 items)").DataType("string")).
 		Returns(http.StatusOK, "OK", []string{}).
 		Writes([]string{}))
 	ws.Route(ws.POST("/session/recommend").To(s.sessionRecommend).
 		Doc("Get recommendation for session.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{RecommendationAPITag}).
+		Metadata(RouteTimeout, RecommendTimeout).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.QueryParameter("n", "Number of returned items").DataType("integer")).
 		Param(ws.QueryParameter("offset", "Offset of returned items").DataType("integer")).
@@ -571,6 +820,7 @@ func (s *RestServer) CreateWebService() {
 	ws.Route(ws.POST("/session/recommend/{category}").To(s.sessionRecommend).
 		Doc("Get recommendation for session.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{RecommendationAPITag}).
+		Metadata(RouteTimeout, RecommendTimeout).
 		Param(ws.HeaderParameter("X-API-Key", "API key").DataType("string")).
 		Param(ws.PathParameter("category", "Category of the returned items").DataType("string")).
 		Param(ws.QueryParameter("n", "Number of returned items").DataType("integer")).
@@ -600,6 +850,15 @@ func ParseInt(request *restful.Request, name string, fallback int) (value int, e
 	return
 }
 
+// ParseBool parses a boolean from the query parameter.
+func ParseBool(request *restful.Request, name string, fallback bool) (bool, error) {
+	valueString := request.QueryParameter(name)
+	if valueString == "" {
+		return fallback, nil
+	}
+	return strconv.ParseBool(valueString)
+}
+
 // ParseDuration parses duration from the query parameter.
 func ParseDuration(request *restful.Request, name string) (time.Duration, error) {
 	valueString := request.QueryParameter(name)
@@ -643,6 +902,12 @@ func (s *RestServer) SearchDocuments(collection, subset string, categories []str
 			readItems.Add(f.ItemId)
 		}
 	}
+	blocklist, err := s.itemBlocklist(ctx)
+	if err != nil {
+		InternalServerError(response, err)
+		return
+	}
+	readItems.Append(blocklist.ToSlice()...)
 
 	end := offset + n
 	if end > 0 && readItems.Cardinality() > 0 {
@@ -656,8 +921,8 @@ func (s *RestServer) SearchDocuments(collection, subset string, categories []str
 		return
 	}
 
-	// Remove read items
-	if userId != "" {
+	// Remove read and blocklisted items
+	if readItems.Cardinality() > 0 {
 		prunedItems := make([]cache.Score, 0, len(items))
 		for _, item := range items {
 			if !readItems.Contains(item.Id) {
@@ -772,25 +1037,39 @@ func (s *RestServer) getCollaborative(request *restful.Request, response *restfu
 // 2. If there are historical interactions of the users, return similar items.
 // 3. Otherwise, return fallback recommendation (popular/latest).
 func (s *RestServer) Recommend(ctx context.Context, response *restful.Response, userId string, categories []string, n int, recommenders ...Recommender) ([]string, error) {
-	initStart := time.Now()
-
-	// create context
-	recommendCtx, err := s.createRecommendContext(ctx, userId, categories, n)
+	scores, err := s.RecommendWithScores(ctx, response, userId, categories, false, nil, n, recommenders...)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	return cache.ConvertDocumentsToValues(scores), nil
+}
 
-	// execute recommenders
-	for _, recommender := range recommenders {
-		err = recommender(recommendCtx)
-		if err != nil {
+// RecommendWithScores recommends items to users like Recommend, but returns items with scores. Scores are given by
+// recommenders, or the score expression if it is set. Items in exclude are never recommended.
+func (s *RestServer) RecommendWithScores(ctx context.Context, response *restful.Response, userId string, categories []string, anyCategory bool, exclude []string, n int, recommenders ...Recommender) ([]cache.Score, error) {
+	initStart := time.Now()
+
+	// expire recommendations written back
+	writeBack := s.Config.Recommend.Online.WriteBackRecommend
+	if writeBack {
+		if frozen, err := s.isFrozenUser(ctx, userId); err != nil {
+			return nil, errors.Trace(err)
+		} else if frozen {
+			writeBack = false
+		} else if err = s.expireWriteBackRecommend(ctx, userId); err != nil {
 			return nil, errors.Trace(err)
 		}
 	}
 
-	// return recommendations
-	if len(recommendCtx.results) > n {
-		recommendCtx.results = recommendCtx.results[:n]
+	// create context
+	recommendCtx, err := s.createRecommendContext(ctx, userId, categories, anyCategory, exclude, n)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	// execute pipeline
+	if err = s.runPipeline(recommendCtx, recommenders, writeBack, log.ResponseLogger(response)); err != nil {
+		return nil, errors.Trace(err)
 	}
 	totalTime := time.Since(initStart)
 	log.ResponseLogger(response).Info("complete recommendation",
@@ -800,6 +1079,7 @@ func (s *RestServer) Recommend(ctx context.Context, response *restful.Response,
 		zap.Int("num_from_user_based", recommendCtx.numFromUserBased),
 		zap.Int("num_from_latest", recommendCtx.numFromLatest),
 		zap.Int("num_from_poplar", recommendCtx.numFromPopular),
+		zap.Int("num_from_blend", recommendCtx.numFromBlend),
 		zap.Duration("total_time", totalTime),
 		zap.Duration("load_final_recommend_time", recommendCtx.loadOfflineRecTime),
 		zap.Duration("load_col_recommend_time", recommendCtx.loadColRecTime),
@@ -807,8 +1087,349 @@ func (s *RestServer) Recommend(ctx context.Context, response *restful.Response,
 		zap.Duration("item_based_recommend_time", recommendCtx.itemBasedTime),
 		zap.Duration("user_based_recommend_time", recommendCtx.userBasedTime),
 		zap.Duration("load_latest_time", recommendCtx.loadLatestTime),
-		zap.Duration("load_popular_time", recommendCtx.loadPopularTime))
-	return recommendCtx.results, nil
+		zap.Duration("load_popular_time", recommendCtx.loadPopularTime),
+		zap.Duration("blend_time", recommendCtx.blendTime))
+	return lo.Map(recommendCtx.results, func(itemId string, _ int) cache.Score {
+		return cache.Score{Id: itemId, Score: recommendCtx.scores[itemId]}
+	}), nil
+}
+
+// runPipeline generates candidates, re-ranks them and truncates recommendations to n. Recommendations are written
+// back before re-ranking if there are no offline recommendations, since re-ranking is applied again when they are
+// read from the cache. The number of items after each stage is recorded in debug mode.
+func (s *RestServer) runPipeline(ctx *recommendContext, recommenders []Recommender, writeBack bool, logger *zap.Logger) error {
+	ctx.stage("history_exclusion", "", ctx.excludeSet.Cardinality())
+
+	// execute recommenders
+	if err := s.generateCandidates(ctx, recommenders); err != nil {
+		return errors.Trace(err)
+	}
+
+	// write back recommendations
+	if writeBack && len(ctx.results) > 0 && !ctx.anyCategory && ctx.numFromOffline == 0 && ctx.numFromBlend == 0 {
+		if err := s.writeBackRecommend(ctx.context, ctx.userId, ctx.categories, ctx.results, ctx.scores); err != nil {
+			logger.Warn("failed to write back recommendation", zap.Error(err))
+		}
+	}
+
+	// re-rank by score expression
+	if err := s.rerankItems(ctx); err != nil {
+		return errors.Trace(err)
+	}
+	if s.Config.Recommend.Online.ScoreExpr != "" {
+		ctx.stage("rerank", "", len(ctx.results))
+	}
+
+	// re-rank by custom scorers
+	ctx.results = s.applyCustomScorers(ctx.context, ctx.userId, ctx.results, ctx.scores)
+	if len(s.Config.Recommend.CustomScorers) > 0 {
+		ctx.stage("custom_scorers", "", len(ctx.results))
+	}
+
+	// truncate recommendations
+	if len(ctx.results) > ctx.n {
+		ctx.results = ctx.results[:ctx.n]
+	}
+	ctx.stage("truncation", "", len(ctx.results))
+	return nil
+}
+
+// generateCandidates executes recommenders and removes items out of their availability windows. Removed items stay
+// in the exclude set, so recommenders are executed again to refill recommendations up to n until no item is removed
+// or recommenders run out of items.
+func (s *RestServer) generateCandidates(ctx *recommendContext, recommenders []Recommender) error {
+	for {
+		numResults := len(ctx.results)
+		for i, recommender := range recommenders {
+			numTraces, numCandidates := len(ctx.traces), len(ctx.results)
+			if err := recommender(ctx); err != nil {
+				return errors.Trace(err)
+			}
+			for _, itemId := range ctx.results[numCandidates:] {
+				ctx.sources[itemId] = i
+			}
+			if ctx.debug && len(ctx.results) > numCandidates {
+				// candidates from multiple recommenders are blended
+				sources := lo.Uniq(lo.FilterMap(ctx.traces[numTraces:], func(trace RecommendTrace, _ int) (string, bool) {
+					return trace.Recommender, trace.Survived
+				}))
+				ctx.stage("candidate_generation", lo.Ternary(len(sources) == 1, sources[0], "blend"), len(ctx.results)-numCandidates)
+			}
+		}
+		if len(ctx.results) == numResults {
+			return nil
+		}
+		available, err := s.filterAvailableItems(ctx, ctx.results[numResults:])
+		if err != nil {
+			return errors.Trace(err)
+		}
+		numRemoved := len(ctx.results) - numResults - len(available)
+		ctx.results = append(ctx.results[:numResults], available...)
+		ctx.numPrevStage = len(ctx.results)
+		ctx.stage("availability_filter", "", len(ctx.results))
+		if numRemoved == 0 || len(ctx.results) >= ctx.n {
+			return nil
+		}
+	}
+}
+
+// filterAvailableItems removes items out of their availability windows. Fetched items are kept in the context for
+// re-ranking.
+func (s *RestServer) filterAvailableItems(ctx *recommendContext, itemIds []string) ([]string, error) {
+	items, err := s.DataClient.BatchGetItems(ctx.context, itemIds)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	now := time.Now()
+	unavailable := mapset.NewSet[string]()
+	for _, item := range items {
+		ctx.items[item.ItemId] = item
+		if !item.IsAvailable(now) {
+			unavailable.Add(item.ItemId)
+		}
+	}
+	if unavailable.Cardinality() == 0 {
+		return itemIds, nil
+	}
+	return lo.Filter(itemIds, func(itemId string, _ int) bool {
+		return !unavailable.Contains(itemId)
+	}), nil
+}
+
+// rerankItems evaluates scores of results by the score expression and sorts results by evaluated scores. Scores given
+// by different recommenders are not comparable, so results are only sorted among consecutive results from the same
+// recommender. Items failing to be evaluated keep scores given by recommenders.
+func (s *RestServer) rerankItems(ctx *recommendContext) error {
+	if s.Config.Recommend.Online.ScoreExpr == "" || len(ctx.results) == 0 {
+		return nil
+	}
+	scoreExpr, err := s.scoreExpr()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	now := time.Now()
+	for _, itemId := range ctx.results {
+		item, exist := ctx.items[itemId]
+		if !exist {
+			item = data.Item{ItemId: itemId}
+		}
+		score, err := scoreExpr.Eval(ctx.scores[itemId], item, now)
+		if err != nil {
+			log.Logger().Warn("failed to evaluate score expression", zap.String("item_id", itemId), zap.Error(err))
+			continue
+		}
+		ctx.scores[itemId] = score
+	}
+	for start := 0; start < len(ctx.results); {
+		end := start + 1
+		for end < len(ctx.results) && ctx.sources[ctx.results[end]] == ctx.sources[ctx.results[start]] {
+			end++
+		}
+		results := ctx.results[start:end]
+		sort.SliceStable(results, func(i, j int) bool {
+			return ctx.scores[results[i]] > ctx.scores[results[j]]
+		})
+		start = end
+	}
+	return nil
+}
+
+// scoreExpr returns the compiled score expression. The expression is compiled again only if it is changed in the
+// config.
+func (s *RestServer) scoreExpr() (*logics.ScoreExpr, error) {
+	s.scoreExprMutex.Lock()
+	defer s.scoreExprMutex.Unlock()
+	expression := s.Config.Recommend.Online.ScoreExpr
+	if s.compiledScoreExpr == nil || s.compiledScoreExprSource != expression {
+		scoreExpr, err := logics.NewScoreExpr(expression)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		s.compiledScoreExpr, s.compiledScoreExprSource = scoreExpr, expression
+	}
+	return s.compiledScoreExpr, nil
+}
+
+// applyCustomScorers sends items with scores to custom scorers in order and sorts items by returned scores. Items
+// dropped by a scorer are removed, and items unknown to the request are ignored. Failed scorers and scorers not
+// responding within the timeout are skipped.
+func (s *RestServer) applyCustomScorers(ctx context.Context, userId string, itemIds []string, scores map[string]float64) []string {
+	for _, address := range s.Config.Recommend.CustomScorers {
+		if len(itemIds) == 0 {
+			break
+		}
+		scorer, err := s.customScorer(address)
+		if err != nil {
+			log.Logger().Warn("failed to connect custom scorer", zap.String("address", address), zap.Error(err))
+			continue
+		}
+		request := &protocol.ScoredItems{UserId: userId, Items: make([]*protocol.ScoredItem, len(itemIds))}
+		for i, itemId := range itemIds {
+			request.Items[i] = &protocol.ScoredItem{ItemId: itemId, Score: scores[itemId]}
+		}
+		scoreCtx, cancel := context.WithTimeout(ctx, s.Config.Recommend.CustomScorerTimeout)
+		response, err := scorer.Score(scoreCtx, request)
+		cancel()
+		if err != nil {
+			log.Logger().Warn("failed to call custom scorer", zap.String("address", address), zap.Error(err))
+			continue
+		}
+		candidates := mapset.NewSet(itemIds...)
+		rescored := make([]cache.Score, 0, len(response.GetItems()))
+		for _, item := range response.GetItems() {
+			if candidates.Contains(item.GetItemId()) {
+				candidates.Remove(item.GetItemId())
+				rescored = append(rescored, cache.Score{Id: item.GetItemId(), Score: item.GetScore()})
+				scores[item.GetItemId()] = item.GetScore()
+			}
+		}
+		sort.SliceStable(rescored, func(i, j int) bool {
+			return rescored[i].Score > rescored[j].Score
+		})
+		itemIds = cache.ConvertDocumentsToValues(rescored)
+	}
+	return itemIds
+}
+
+// customScorer returns the client of a custom scorer. Connections are reused between requests and secured by the
+// TLS config of the cluster if set.
+func (s *RestServer) customScorer(address string) (protocol.CustomScorerClient, error) {
+	s.scorersMutex.Lock()
+	defer s.scorersMutex.Unlock()
+	if conn, exist := s.scorerConns[address]; exist {
+		return protocol.NewCustomScorerClient(conn), nil
+	}
+	var opts []grpc.DialOption
+	if s.TLSConfig != nil {
+		c, err := util.NewClientCreds(s.TLSConfig)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		opts = append(opts, grpc.WithTransportCredentials(c))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	conn, err := grpc.Dial(address, opts...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if s.scorerConns == nil {
+		s.scorerConns = make(map[string]*grpc.ClientConn)
+	}
+	s.scorerConns[address] = conn
+	return protocol.NewCustomScorerClient(conn), nil
+}
+
+// CloseCustomScorers closes connections to custom scorers.
+func (s *RestServer) CloseCustomScorers() {
+	s.scorersMutex.Lock()
+	defer s.scorersMutex.Unlock()
+	for address, conn := range s.scorerConns {
+		if err := conn.Close(); err != nil {
+			log.Logger().Error("failed to close custom scorer", zap.String("address", address), zap.Error(err))
+		}
+	}
+	s.scorerConns = nil
+}
+
+// writeBackRecommend writes recommendations computed online to the offline recommendation cache with scores given
+// by recommenders. The caller must skip frozen users.
+func (s *RestServer) writeBackRecommend(ctx context.Context, userId string, categories []string, results []string, itemScores map[string]float64) error {
+	if len(results) > s.Config.Recommend.CacheSize {
+		results = results[:s.Config.Recommend.CacheSize]
+	}
+	// timestamps are truncated to seconds to be consistent with the write back time in cache.
+	timestamp := time.Now().Truncate(time.Second)
+	scores := make([]cache.Score, len(results))
+	for i, itemId := range results {
+		scores[i] = cache.Score{
+			Id:         itemId,
+			Score:      itemScores[itemId],
+			Categories: categories,
+			Timestamp:  timestamp,
+		}
+	}
+	if err := s.CacheClient.AddScores(ctx, cache.OfflineRecommend, userId, scores); err != nil {
+		return errors.Trace(err)
+	}
+	return s.CacheClient.Set(ctx, cache.Time(cache.Key(cache.WriteBackRecommendTime, userId), timestamp))
+}
+
+// expireWriteBackRecommend removes recommendations written back if they are older than TTL. Offline
+// recommendations generated after writing back are kept. The caller must skip frozen users.
+func (s *RestServer) expireWriteBackRecommend(ctx context.Context, userId string) error {
+	writeBackTime, err := s.CacheClient.Get(ctx, cache.Key(cache.WriteBackRecommendTime, userId)).Time()
+	if errors.Is(err, errors.NotFound) {
+		return nil
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+	if time.Since(writeBackTime) < s.Config.Recommend.Online.WriteBackRecommendTTL {
+		return nil
+	}
+	if err = s.CacheClient.DeleteScores(ctx, []string{cache.OfflineRecommend}, cache.ScoreCondition{
+		Subset: proto.String(userId),
+		Before: lo.ToPtr(writeBackTime.Add(time.Nanosecond)),
+	}); err != nil {
+		return errors.Trace(err)
+	}
+	return s.CacheClient.Delete(ctx, cache.Key(cache.WriteBackRecommendTime, userId))
+}
+
+// isFrozenUser checks whether offline recommendations of a user are frozen.
+func (s *RestServer) isFrozenUser(ctx context.Context, userId string) (bool, error) {
+	frozenUsers, err := s.CacheClient.GetSet(ctx, cache.FrozenUsers)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return lo.Contains(frozenUsers, userId), nil
+}
+
+// RecommendTrace records how an item passed through the recommendation pipeline.
+type RecommendTrace struct {
+	ItemId      string
+	Recommender string
+	Score       float64
+	Survived    bool
+}
+
+// RecommendDebug runs the recommendation pipeline like Recommend but returns traces of
+// every candidate item, including items dropped by exclusion filters.
+func (s *RestServer) RecommendDebug(ctx context.Context, userId string, categories []string, n int, recommenders ...Recommender) ([]RecommendTrace, error) {
+	recommendCtx, err := s.createRecommendContext(ctx, userId, categories, false, nil, n)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	recommendCtx.debug = true
+	for _, recommender := range recommenders {
+		err = recommender(recommendCtx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return recommendCtx.traces, nil
+}
+
+// PipelineStage is the number of items after a stage of the recommendation pipeline. The source is the
+// recommender generating candidates in the candidate generation stage.
+type PipelineStage struct {
+	Stage  string `json:"stage"`
+	Source string `json:"source,omitempty"`
+	Count  int    `json:"count"`
+}
+
+// RecommendPipeline runs the recommendation pipeline like Recommend and returns the number of items after each
+// stage, as well as traces of every candidate item. Recommendations are neither written back nor recorded.
+func (s *RestServer) RecommendPipeline(ctx context.Context, userId string, categories []string, n int, recommenders ...Recommender) ([]string, []PipelineStage, []RecommendTrace, error) {
+	recommendCtx, err := s.createRecommendContext(ctx, userId, categories, false, nil, n)
+	if err != nil {
+		return nil, nil, nil, errors.Trace(err)
+	}
+	recommendCtx.debug = true
+	if err = s.runPipeline(recommendCtx, recommenders, false, log.Logger()); err != nil {
+		return nil, nil, nil, errors.Trace(err)
+	}
+	return recommendCtx.results, recommendCtx.stages, recommendCtx.traces, nil
 }
 
 type recommendContext struct {
@@ -818,7 +1439,16 @@ type recommendContext struct {
 	userFeedback []data.Feedback
 	n            int
 	results      []string
+	scores       map[string]float64   // scores of results given by recommenders
+	sources      map[string]int       // indices of recommenders giving results
+	items        map[string]data.Item // items of results fetched by the availability filter
 	excludeSet   mapset.Set[string]
+	debug        bool
+	traces       []RecommendTrace
+	stages       []PipelineStage
+	// anyCategory means items in any of categories are recommended. Otherwise, items in all categories are
+	// recommended.
+	anyCategory bool
 
 	numPrevStage         int
 	numFromLatest        int
@@ -827,6 +1457,7 @@ type recommendContext struct {
 	numFromItemBased     int
 	numFromCollaborative int
 	numFromOffline       int
+	numFromBlend         int
 
 	loadOfflineRecTime time.Duration
 	loadColRecTime     time.Duration
@@ -835,9 +1466,10 @@ type recommendContext struct {
 	userBasedTime      time.Duration
 	loadLatestTime     time.Duration
 	loadPopularTime    time.Duration
+	blendTime          time.Duration
 }
 
-func (s *RestServer) createRecommendContext(ctx context.Context, userId string, categories []string, n int) (*recommendContext, error) {
+func (s *RestServer) createRecommendContext(ctx context.Context, userId string, categories []string, anyCategory bool, exclude []string, n int) (*recommendContext, error) {
 	// pull historical feedback
 	userFeedback, err := s.DataClient.GetUserFeedback(ctx, userId, s.Config.Now())
 	if err != nil {
@@ -849,22 +1481,98 @@ func (s *RestServer) createRecommendContext(ctx context.Context, userId string,
 			excludeSet.Add(item.ItemId)
 		}
 	}
+	// exclude blocklisted items
+	blocklist, err := s.itemBlocklist(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	excludeSet.Append(blocklist.ToSlice()...)
+	// exclude items given by the request
+	excludeSet.Append(exclude...)
 	return &recommendContext{
 		userId:       userId,
 		categories:   categories,
+		anyCategory:  anyCategory && len(categories) > 1,
 		n:            n,
+		scores:       make(map[string]float64),
+		sources:      make(map[string]int),
+		items:        make(map[string]data.Item),
 		excludeSet:   excludeSet,
 		userFeedback: userFeedback,
 		context:      ctx,
 	}, nil
 }
 
+// matchCategories checks whether an item in categories could be recommended.
+func (ctx *recommendContext) matchCategories(categories []string) bool {
+	if funk.Equal(ctx.categories, []string{""}) {
+		return true
+	}
+	if ctx.anyCategory {
+		return lo.Some(categories, ctx.categories)
+	}
+	return funk.Subset(ctx.categories, categories)
+}
+
+// searchScores searches scores of items in categories of the recommendation. If items in any of categories are
+// recommended, scores in each category are merged and the best score of each item is kept.
+func (s *RestServer) searchScores(ctx *recommendContext, collection, subset string) ([]cache.Score, error) {
+	if !ctx.anyCategory {
+		return s.CacheClient.SearchScores(ctx.context, collection, subset, ctx.categories, 0, s.Config.Recommend.CacheSize)
+	}
+	best := make(map[string]cache.Score)
+	for _, category := range ctx.categories {
+		scores, err := s.CacheClient.SearchScores(ctx.context, collection, subset, []string{category}, 0, s.Config.Recommend.CacheSize)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, score := range scores {
+			if prev, exist := best[score.Id]; !exist || score.Score > prev.Score {
+				best[score.Id] = score
+			}
+		}
+	}
+	merged := lo.Values(best)
+	sort.Slice(merged, func(i, j int) bool {
+		if merged[i].Score != merged[j].Score {
+			return merged[i].Score > merged[j].Score
+		}
+		return merged[i].Id < merged[j].Id
+	})
+	if s.Config.Recommend.CacheSize >= 0 && len(merged) > s.Config.Recommend.CacheSize {
+		merged = merged[:s.Config.Recommend.CacheSize]
+	}
+	return merged, nil
+}
+
+// stage records the number of items after a stage of the pipeline in debug mode.
+func (ctx *recommendContext) stage(stage, source string, count int) {
+	if ctx.debug {
+		ctx.stages = append(ctx.stages, PipelineStage{Stage: stage, Source: source, Count: count})
+	}
+}
+
+// trace records a candidate item from a recommender. Scores of survived items are kept for re-ranking.
+func (ctx *recommendContext) trace(recommender, itemId string, score float64, survived bool) {
+	if survived {
+		ctx.scores[itemId] = score
+	}
+	if ctx.debug {
+		ctx.traces = append(ctx.traces, RecommendTrace{
+			ItemId:      itemId,
+			Recommender: recommender,
+			Score:       score,
+			Survived:    survived,
+		})
+	}
+}
+
 type Recommender func(ctx *recommendContext) error
 
 func (s *RestServer) RecommendOffline(ctx *recommendContext) error {
 	if len(ctx.results) < ctx.n {
 		start := time.Now()
-		recommendation, err := s.CacheClient.SearchScores(ctx.context, cache.OfflineRecommend, ctx.userId, ctx.categories, 0, s.Config.Recommend.CacheSize)
+		recommendation, err := s.searchScores(ctx, cache.OfflineRecommend, ctx.userId)
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -872,10 +1580,13 @@ func (s *RestServer) RecommendOffline(ctx *recommendContext) error {
 			if !ctx.excludeSet.Contains(item.Id) {
 				ctx.results = append(ctx.results, item.Id)
 				ctx.excludeSet.Add(item.Id)
+				ctx.trace("offline", item.Id, item.Score, true)
+			} else {
+				ctx.trace("offline", item.Id, item.Score, false)
 			}
 		}
-		ctx.loadOfflineRecTime = time.Since(start)
-		ctx.numFromOffline = len(ctx.results) - ctx.numPrevStage
+		ctx.loadOfflineRecTime += time.Since(start)
+		ctx.numFromOffline += len(ctx.results) - ctx.numPrevStage
 		ctx.numPrevStage = len(ctx.results)
 	}
 	return nil
@@ -884,7 +1595,7 @@ func (s *RestServer) RecommendOffline(ctx *recommendContext) error {
 func (s *RestServer) RecommendCollaborative(ctx *recommendContext) error {
 	if len(ctx.results) < ctx.n {
 		start := time.Now()
-		collaborativeRecommendation, err := s.CacheClient.SearchScores(ctx.context, cache.CollaborativeRecommend, ctx.userId, ctx.categories, 0, s.Config.Recommend.CacheSize)
+		collaborativeRecommendation, err := s.searchScores(ctx, cache.CollaborativeRecommend, ctx.userId)
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -892,10 +1603,13 @@ func (s *RestServer) RecommendCollaborative(ctx *recommendContext) error {
 			if !ctx.excludeSet.Contains(item.Id) {
 				ctx.results = append(ctx.results, item.Id)
 				ctx.excludeSet.Add(item.Id)
+				ctx.trace("collaborative", item.Id, item.Score, true)
+			} else {
+				ctx.trace("collaborative", item.Id, item.Score, false)
 			}
 		}
-		ctx.loadColRecTime = time.Since(start)
-		ctx.numFromCollaborative = len(ctx.results) - ctx.numPrevStage
+		ctx.loadColRecTime += time.Since(start)
+		ctx.numFromCollaborative += len(ctx.results) - ctx.numPrevStage
 		ctx.numPrevStage = len(ctx.results)
 	}
 	return nil
@@ -912,7 +1626,7 @@ func (s *RestServer) RecommendUserBased(ctx *recommendContext) error {
 		}
 		for _, user := range similarUsers {
 			// load historical feedback
-			feedbacks, err := s.DataClient.GetUserFeedback(ctx.context, user.Id, s.Config.Now(), s.Config.Recommend.DataSource.PositiveFeedbackTypes...)
+			feedbacks, err := s.DataClient.GetUserFeedback(ctx.context, user.Id, s.Config.Now(), s.FeedbackTypes().Positive...)
 			if err != nil {
 				return errors.Trace(err)
 			}
@@ -923,9 +1637,11 @@ func (s *RestServer) RecommendUserBased(ctx *recommendContext) error {
 					if err != nil {
 						return errors.Trace(err)
 					}
-					if funk.Equal(ctx.categories, []string{""}) || funk.Subset(ctx.categories, item.Categories) {
+					if ctx.matchCategories(item.Categories) {
 						candidates[feedback.ItemId] += user.Score
 					}
+				} else {
+					ctx.trace("user_based", feedback.ItemId, user.Score, false)
 				}
 			}
 		}
@@ -935,11 +1651,14 @@ func (s *RestServer) RecommendUserBased(ctx *recommendContext) error {
 		for id, score := range candidates {
 			filter.Push(id, score)
 		}
-		ids, _ := filter.PopAll()
+		ids, scores := filter.PopAll()
+		for i := range ids {
+			ctx.trace("user_based", ids[i], scores[i], true)
+		}
 		ctx.results = append(ctx.results, ids...)
 		ctx.excludeSet.Append(ids...)
-		ctx.userBasedTime = time.Since(start)
-		ctx.numFromUserBased = len(ctx.results) - ctx.numPrevStage
+		ctx.userBasedTime += time.Since(start)
+		ctx.numFromUserBased += len(ctx.results) - ctx.numPrevStage
 		ctx.numPrevStage = len(ctx.results)
 	}
 	return nil
@@ -955,7 +1674,7 @@ func (s *RestServer) RecommendItemBased(ctx *recommendContext) error {
 			if s.Config.Recommend.Online.NumFeedbackFallbackItemBased <= len(userFeedback) {
 				break
 			}
-			if funk.ContainsString(s.Config.Recommend.DataSource.PositiveFeedbackTypes, feedback.FeedbackType) {
+			if funk.ContainsString(s.FeedbackTypes().Positive, feedback.FeedbackType) {
 				userFeedback = append(userFeedback, feedback)
 			}
 		}
@@ -963,7 +1682,7 @@ func (s *RestServer) RecommendItemBased(ctx *recommendContext) error {
 		candidates := make(map[string]float64)
 		for _, feedback := range userFeedback {
 			// load similar items
-			similarItems, err := s.CacheClient.SearchScores(ctx.context, cache.ItemToItem, cache.Key(cache.Neighbors, feedback.ItemId), ctx.categories, 0, s.Config.Recommend.CacheSize)
+			similarItems, err := s.searchScores(ctx, cache.ItemToItem, cache.Key(cache.Neighbors, feedback.ItemId))
 			if err != nil {
 				return errors.Trace(err)
 			}
@@ -971,6 +1690,8 @@ func (s *RestServer) RecommendItemBased(ctx *recommendContext) error {
 			for _, item := range similarItems {
 				if !ctx.excludeSet.Contains(item.Id) {
 					candidates[item.Id] += item.Score
+				} else {
+					ctx.trace("item_based", item.Id, item.Score, false)
 				}
 			}
 		}
@@ -980,11 +1701,14 @@ func (s *RestServer) RecommendItemBased(ctx *recommendContext) error {
 		for id, score := range candidates {
 			filter.Push(id, score)
 		}
-		ids, _ := filter.PopAll()
+		ids, scores := filter.PopAll()
+		for i := range ids {
+			ctx.trace("item_based", ids[i], scores[i], true)
+		}
 		ctx.results = append(ctx.results, ids...)
 		ctx.excludeSet.Append(ids...)
-		ctx.itemBasedTime = time.Since(start)
-		ctx.numFromItemBased = len(ctx.results) - ctx.numPrevStage
+		ctx.itemBasedTime += time.Since(start)
+		ctx.numFromItemBased += len(ctx.results) - ctx.numPrevStage
 		ctx.numPrevStage = len(ctx.results)
 	}
 	return nil
@@ -993,41 +1717,258 @@ func (s *RestServer) RecommendItemBased(ctx *recommendContext) error {
 func (s *RestServer) RecommendLatest(ctx *recommendContext) error {
 	if len(ctx.results) < ctx.n {
 		start := time.Now()
-		items, err := s.CacheClient.SearchScores(ctx.context, cache.NonPersonalized, cache.Latest, ctx.categories, 0, s.Config.Recommend.CacheSize)
+		items, err := s.searchScores(ctx, cache.NonPersonalized, cache.Latest)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for _, item := range items {
+			if !ctx.excludeSet.Contains(item.Id) {
+				ctx.results = append(ctx.results, item.Id)
+				ctx.excludeSet.Add(item.Id)
+				ctx.trace("latest", item.Id, item.Score, true)
+			} else {
+				ctx.trace("latest", item.Id, item.Score, false)
+			}
+		}
+		ctx.loadLatestTime += time.Since(start)
+		ctx.numFromLatest += len(ctx.results) - ctx.numPrevStage
+		ctx.numPrevStage = len(ctx.results)
+	}
+	return nil
+}
+
+func (s *RestServer) RecommendPopular(ctx *recommendContext) error {
+	if len(ctx.results) < ctx.n {
+		start := time.Now()
+		items, err := s.searchScores(ctx, cache.NonPersonalized, cache.Popular)
 		if err != nil {
 			return errors.Trace(err)
 		}
 		for _, item := range items {
-			if !ctx.excludeSet.Contains(item.Id) {
-				ctx.results = append(ctx.results, item.Id)
-				ctx.excludeSet.Add(item.Id)
+			if !ctx.excludeSet.Contains(item.Id) {
+				ctx.results = append(ctx.results, item.Id)
+				ctx.excludeSet.Add(item.Id)
+				ctx.trace("popular", item.Id, item.Score, true)
+			} else {
+				ctx.trace("popular", item.Id, item.Score, false)
+			}
+		}
+		ctx.loadPopularTime += time.Since(start)
+		ctx.numFromPopular += len(ctx.results) - ctx.numPrevStage
+		ctx.numPrevStage = len(ctx.results)
+	}
+	return nil
+}
+
+// RecommendBlend merges scores from recommenders in blend weights. Scores from each recommender are
+// normalized, weighted and summed before ranking.
+func (s *RestServer) RecommendBlend(ctx *recommendContext) error {
+	if len(ctx.results) < ctx.n {
+		start := time.Now()
+		candidates := make(map[string]float64)
+		sources := lo.Keys(s.Config.Recommend.Online.BlendWeights)
+		sort.Strings(sources)
+		for _, source := range sources {
+			weight := s.Config.Recommend.Online.BlendWeights[source]
+			var collection, subset string
+			switch source {
+			case "offline":
+				collection, subset = cache.OfflineRecommend, ctx.userId
+			case "collaborative":
+				collection, subset = cache.CollaborativeRecommend, ctx.userId
+			case "latest":
+				collection, subset = cache.NonPersonalized, cache.Latest
+			case "popular":
+				collection, subset = cache.NonPersonalized, cache.Popular
+			default:
+				return fmt.Errorf("unknown blend recommendation method `%s`", source)
+			}
+			items, err := s.searchScores(ctx, collection, subset)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			normalized, err := NormalizeScores(items, s.Config.Recommend.Online.Normalization)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			for i, item := range items {
+				if ctx.excludeSet.Contains(item.Id) {
+					ctx.trace(source, item.Id, item.Score, false)
+					continue
+				}
+				candidates[item.Id] += weight * normalized[i]
+				ctx.trace(source, item.Id, item.Score, true)
+			}
+		}
+		// collect top k
+		k := ctx.n - len(ctx.results)
+		filter := heap.NewTopKFilter[string, float64](k)
+		for id, score := range candidates {
+			filter.Push(id, score)
+		}
+		ids, scores := filter.PopAll()
+		for i := range ids {
+			ctx.scores[ids[i]] = scores[i]
+		}
+		ctx.results = append(ctx.results, ids...)
+		ctx.excludeSet.Append(ids...)
+		ctx.blendTime += time.Since(start)
+		ctx.numFromBlend += len(ctx.results) - ctx.numPrevStage
+		ctx.numPrevStage = len(ctx.results)
+	}
+	return nil
+}
+
+// NormalizeScores normalizes scores from a recommender so that scores from different recommenders are
+// comparable. Supported methods are "min_max" and "z_score".
+func NormalizeScores(items []cache.Score, method string) ([]float64, error) {
+	normalized := make([]float64, len(items))
+	if len(items) == 0 {
+		return normalized, nil
+	}
+	switch method {
+	case "min_max", "":
+		minScore, maxScore := items[0].Score, items[0].Score
+		for _, item := range items {
+			minScore = math.Min(minScore, item.Score)
+			maxScore = math.Max(maxScore, item.Score)
+		}
+		for i, item := range items {
+			if maxScore > minScore {
+				normalized[i] = (item.Score - minScore) / (maxScore - minScore)
+			} else {
+				normalized[i] = 1
+			}
+		}
+	case "z_score":
+		var mean, variance float64
+		for _, item := range items {
+			mean += item.Score
+		}
+		mean /= float64(len(items))
+		for _, item := range items {
+			variance += (item.Score - mean) * (item.Score - mean)
+		}
+		std := math.Sqrt(variance / float64(len(items)))
+		for i, item := range items {
+			if std > 0 {
+				normalized[i] = (item.Score - mean) / std
 			}
 		}
-		ctx.loadLatestTime = time.Since(start)
-		ctx.numFromLatest = len(ctx.results) - ctx.numPrevStage
-		ctx.numPrevStage = len(ctx.results)
+	default:
+		return nil, fmt.Errorf("unknown normalization method `%s`", method)
 	}
-	return nil
+	return normalized, nil
 }
 
-func (s *RestServer) RecommendPopular(ctx *recommendContext) error {
-	if len(ctx.results) < ctx.n {
-		start := time.Now()
-		items, err := s.CacheClient.SearchScores(ctx.context, cache.NonPersonalized, cache.Popular, ctx.categories, 0, s.Config.Recommend.CacheSize)
+// OnlineRecommenders returns recommenders used by online recommendation. Offline recommendation (or blended
+// recommendation in blend mode) comes first, followed by fallback recommenders.
+func (s *RestServer) OnlineRecommenders() ([]Recommender, error) {
+	if s.Config.Recommend.Online.Mode == "blend" {
+		return s.StrategyRecommenders("blend")
+	}
+	return s.StrategyRecommenders("offline")
+}
+
+// recommendStrategies are names of recommenders used by A/B experiments.
+var recommendStrategies = []string{"offline", "blend", "collaborative", "item_based", "user_based", "latest", "popular"}
+
+// StrategyRecommenders returns the recommender of a strategy followed by fallback recommenders.
+func (s *RestServer) StrategyRecommenders(strategy string) ([]Recommender, error) {
+	recommenders := make([]Recommender, 0, len(s.Config.Recommend.Online.FallbackRecommend)+1)
+	switch strategy {
+	case "offline":
+		recommenders = append(recommenders, s.RecommendOffline)
+	case "blend":
+		recommenders = append(recommenders, s.RecommendBlend)
+	default:
+		recommender, err := s.fallbackRecommender(strategy)
 		if err != nil {
-			return errors.Trace(err)
+			return nil, errors.Trace(err)
 		}
-		for _, item := range items {
-			if !ctx.excludeSet.Contains(item.Id) {
-				ctx.results = append(ctx.results, item.Id)
-				ctx.excludeSet.Add(item.Id)
-			}
+		recommenders = append(recommenders, recommender)
+	}
+	for _, name := range s.Config.Recommend.Online.FallbackRecommend {
+		recommender, err := s.fallbackRecommender(name)
+		if err != nil {
+			return nil, errors.Trace(err)
 		}
-		ctx.loadPopularTime = time.Since(start)
-		ctx.numFromPopular = len(ctx.results) - ctx.numPrevStage
-		ctx.numPrevStage = len(ctx.results)
+		recommenders = append(recommenders, recommender)
+	}
+	return recommenders, nil
+}
+
+func (s *RestServer) fallbackRecommender(name string) (Recommender, error) {
+	switch name {
+	case "collaborative":
+		return s.RecommendCollaborative, nil
+	case "item_based":
+		return s.RecommendItemBased, nil
+	case "user_based":
+		return s.RecommendUserBased, nil
+	case "latest":
+		return s.RecommendLatest, nil
+	case "popular":
+		return s.RecommendPopular, nil
+	default:
+		return nil, fmt.Errorf("unknown fallback recommendation method `%s`", name)
+	}
+}
+
+// activeABTest returns the active A/B experiment, or nil if there is no active experiment. The experiment is cached
+// for Config.Server.CacheExpire, so changes of the experiment take effect after the cache expires.
+func (s *RestServer) activeABTest(ctx context.Context) (*ABTest, error) {
+	s.abTestMutex.Lock()
+	defer s.abTestMutex.Unlock()
+	if time.Since(s.abTestTime) < s.Config.Server.CacheExpire {
+		return s.abTest, nil
+	}
+	experiment, err := GetABTest(ctx, s.CacheClient)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	s.abTest = experiment
+	s.abTestTime = time.Now()
+	return s.abTest, nil
+}
+
+// AssignABTestGroup returns the group a user is assigned to in the active A/B experiment, or nil if there is no
+// active experiment.
+func (s *RestServer) AssignABTestGroup(ctx context.Context, userId string) (*ABTestGroup, error) {
+	experiment, err := s.activeABTest(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if experiment == nil || len(experiment.Groups) == 0 {
+		return nil, nil
+	}
+	group := experiment.Assign(userId)
+	return &group, nil
+}
+
+// UserRecommenders returns recommenders of the group a user is assigned to in the active A/B experiment and the
+// group. Online recommenders are used and the group is nil if there is no active experiment.
+func (s *RestServer) UserRecommenders(ctx context.Context, userId string) ([]Recommender, *ABTestGroup, error) {
+	group, err := s.AssignABTestGroup(ctx, userId)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	if group == nil {
+		recommenders, err := s.OnlineRecommenders()
+		return recommenders, nil, err
+	}
+	recommenders, err := s.StrategyRecommenders(group.Strategy)
+	return recommenders, group, err
+}
+
+// countRecommendation increases the number of recommendations served in the current minute. The counter is shared
+// by all servers, so it is increased atomically in the cache store.
+func (s *RestServer) countRecommendation(ctx context.Context) {
+	if err := s.CacheClient.IncrTimeSeriesPoints(ctx, []cache.TimeSeriesPoint{
+		{Name: RecommendationsServed, Timestamp: time.Now().Truncate(time.Minute), Value: 1},
+	}); err != nil {
+		log.Logger().Warn("failed to count served recommendations", zap.Error(err))
 	}
-	return nil
 }
 
 func (s *RestServer) getRecommend(request *restful.Request, response *restful.Response) {
@@ -1042,7 +1983,14 @@ func (s *RestServer) getRecommend(request *restful.Request, response *restful.Re
 		BadRequest(response, err)
 		return
 	}
+	if maxN := s.Config.Recommend.Online.MaxRecommendSize; maxN > 0 && n > maxN {
+		n = maxN
+	}
 	categories := ReadCategories(request)
+	anyCategories := ReadAnyCategories(request)
+	if len(anyCategories) > 0 {
+		categories = anyCategories
+	}
 	offset, err := ParseInt(request, "offset", 0)
 	if err != nil {
 		BadRequest(response, err)
@@ -1054,31 +2002,32 @@ func (s *RestServer) getRecommend(request *restful.Request, response *restful.Re
 		BadRequest(response, err)
 		return
 	}
-	// online recommendation
-	recommenders := []Recommender{s.RecommendOffline}
-	for _, recommender := range s.Config.Recommend.Online.FallbackRecommend {
-		switch recommender {
-		case "collaborative":
-			recommenders = append(recommenders, s.RecommendCollaborative)
-		case "item_based":
-			recommenders = append(recommenders, s.RecommendItemBased)
-		case "user_based":
-			recommenders = append(recommenders, s.RecommendUserBased)
-		case "latest":
-			recommenders = append(recommenders, s.RecommendLatest)
-		case "popular":
-			recommenders = append(recommenders, s.RecommendPopular)
-		default:
-			InternalServerError(response, fmt.Errorf("unknown fallback recommendation method `%s`", recommender))
-			return
+	var exclude []string
+	for _, itemId := range strings.Split(request.QueryParameter("exclude"), ",") {
+		if itemId = strings.TrimSpace(itemId); itemId != "" {
+			exclude = append(exclude, itemId)
 		}
 	}
-	results, err := s.Recommend(ctx, response, userId, categories, offset+n, recommenders...)
+	if len(exclude) > MaxExcludeSize {
+		BadRequest(response, fmt.Errorf("the number of excluded items exceeds %d", MaxExcludeSize))
+		return
+	}
+	// online recommendation
+	recommenders, group, err := s.UserRecommenders(ctx, userId)
+	if err != nil {
+		InternalServerError(response, err)
+		return
+	}
+	if group != nil {
+		response.Header().Set("X-AB-Group", group.Id)
+	}
+	scores, err := s.RecommendWithScores(ctx, response, userId, categories, len(anyCategories) > 0, exclude, offset+n, recommenders...)
 	if err != nil {
 		InternalServerError(response, err)
 		return
 	}
-	results = results[mathutil.Min(offset, len(results)):]
+	scores = scores[mathutil.Min(offset, len(scores)):]
+	results := cache.ConvertDocumentsToValues(scores)
 	// write back
 	if writeBackFeedback != "" {
 		startTime := time.Now()
@@ -1092,17 +2041,162 @@ func (s *RestServer) getRecommend(request *restful.Request, response *restful.Re
 				},
 				Timestamp: startTime.Add(writeBackDelay),
 			}
-			err = s.DataClient.BatchInsertFeedback(ctx, []data.Feedback{feedback}, false, false, false)
+			inserted, err := s.DataClient.BatchInsertFeedback(ctx, []data.Feedback{feedback}, false, false, false)
 			if err != nil {
 				InternalServerError(response, err)
 				return
 			}
+			UpdateFeedbackStats(ctx, s.CacheClient, inserted)
+		}
+	}
+	s.countRecommendation(ctx)
+	if len(results) > 0 {
+		if err = AddRecommendHistory(ctx, s.CacheClient, userId, RecommendEvent{Items: scores, Timestamp: time.Now()}); err != nil {
+			log.ResponseLogger(response).Warn("failed to record recommendation history", zap.Error(err))
 		}
+		s.recordExposure(userId, results)
 	}
 	// Send result
 	Ok(response, results)
 }
 
+// RecommendEvent is a list of recommended items served to a user.
+type RecommendEvent struct {
+	Items     []cache.Score
+	Timestamp time.Time
+}
+
+// GetRecommendHistory returns the latest n events of recommendations served to a user, the latest first. Events out
+// of RecommendHistoryWindow are skipped.
+func GetRecommendHistory(ctx context.Context, cacheClient cache.Database, userId string, n int) ([]RecommendEvent, error) {
+	documents, err := cacheClient.SearchScores(ctx, RecommendHistory, userId, []string{""}, 0, n)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	events := make([]RecommendEvent, 0, len(documents))
+	for _, document := range documents {
+		text, err := cacheClient.Get(ctx, cache.Key(RecommendHistory, userId, document.Id)).String()
+		if errors.Is(err, errors.NotFound) {
+			continue
+		} else if err != nil {
+			return nil, errors.Trace(err)
+		}
+		var event RecommendEvent
+		if err = json.Unmarshal([]byte(text), &event); err != nil {
+			return nil, errors.Trace(err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// AddRecommendHistory appends an event of served recommendations to the history of a user. Existing events are not
+// read except those beyond maxRecommendHistory, which are removed. Events out of RecommendHistoryWindow expire, and
+// their documents are pruned by the master.
+func AddRecommendHistory(ctx context.Context, cacheClient cache.Database, userId string, event RecommendEvent) error {
+	eventId := strconv.FormatInt(event.Timestamp.UnixNano(), 10)
+	buf, err := json.Marshal(event)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = cacheClient.Set(ctx, cache.String(cache.Key(RecommendHistory, userId, eventId), string(buf)).
+		WithTTL(RecommendHistoryWindow)); err != nil {
+		return errors.Trace(err)
+	}
+	if err = cacheClient.AddScores(ctx, RecommendHistory, userId, []cache.Score{{
+		Id:         eventId,
+		Score:      float64(event.Timestamp.UnixMicro()),
+		Categories: []string{""},
+		Timestamp:  event.Timestamp,
+	}}); err != nil {
+		return errors.Trace(err)
+	}
+	// trim events beyond the limit
+	overflow, err := cacheClient.SearchScores(ctx, RecommendHistory, userId, []string{""}, maxRecommendHistory, -1)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, document := range overflow {
+		if err = cacheClient.DeleteScores(ctx, []string{RecommendHistory},
+			cache.ScoreCondition{Subset: proto.String(userId), Id: proto.String(document.Id)}); err != nil {
+			return errors.Trace(err)
+		}
+		if err = cacheClient.Delete(ctx, cache.Key(RecommendHistory, userId, document.Id)); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// itemBlocklist returns the set of items never recommended. The set is cached for Config.Server.CacheExpire, so
+// changes of the blocklist take effect after the cache expires. The returned set must not be modified.
+func (s *RestServer) itemBlocklist(ctx context.Context) (mapset.Set[string], error) {
+	s.blocklistMutex.Lock()
+	defer s.blocklistMutex.Unlock()
+	if s.blocklist != nil && time.Since(s.blocklistTime) < s.Config.Server.CacheExpire {
+		return s.blocklist, nil
+	}
+	members, err := s.CacheClient.GetSet(ctx, cache.ItemBlocklist)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	s.blocklist = mapset.NewSet(members...)
+	s.blocklistTime = time.Now()
+	return s.blocklist, nil
+}
+
+// recordExposure buffers the number of times each item was recommended to a user. Buffered exposure is written to
+// the cache by FlushExposure.
+func (s *RestServer) recordExposure(userId string, items []string) {
+	s.exposureMutex.Lock()
+	defer s.exposureMutex.Unlock()
+	if s.exposureBuffer == nil {
+		s.exposureBuffer = make(map[string]map[string]int)
+	}
+	for _, itemId := range lo.Uniq(items) {
+		if _, exist := s.exposureBuffer[itemId]; !exist {
+			s.exposureBuffer[itemId] = make(map[string]int)
+		}
+		s.exposureBuffer[itemId][userId]++
+	}
+}
+
+// FlushExposure adds buffered exposure to the ranking of users by exposure to each item.
+func (s *RestServer) FlushExposure(ctx context.Context) error {
+	s.exposureMutex.Lock()
+	buffer := s.exposureBuffer
+	s.exposureBuffer = nil
+	s.exposureMutex.Unlock()
+	timestamp := time.Now()
+	for itemId, users := range buffer {
+		documents := make([]cache.Score, 0, len(users))
+		for userId, count := range users {
+			documents = append(documents, cache.Score{
+				Id:         userId,
+				Score:      float64(count),
+				Categories: []string{""},
+				Timestamp:  timestamp,
+			})
+		}
+		if err := s.CacheClient.IncrScores(ctx, Exposure, itemId, documents); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// flushExposureLoop flushes buffered exposure periodically.
+func (s *RestServer) flushExposureLoop() {
+	defer base.CheckPanic()
+	ticker := time.NewTicker(exposureFlushInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := s.FlushExposure(context.Background()); err != nil {
+			log.Logger().Warn("failed to flush exposure", zap.Error(err))
+		}
+	}
+}
+
 func (s *RestServer) sessionRecommend(request *restful.Request, response *restful.Response) {
 	ctx := context.Background()
 	if request != nil && request.Request != nil {
@@ -1138,12 +2232,33 @@ func (s *RestServer) sessionRecommend(request *restful.Request, response *restfu
 	}
 	data.SortFeedbacks(dataFeedback)
 
+	// recommend by the strategy of the A/B group of the session user
+	if userId := sessionUserId(dataFeedback); userId != "" {
+		group, err := s.AssignABTestGroup(ctx, userId)
+		if err != nil {
+			InternalServerError(response, err)
+			return
+		}
+		if group != nil {
+			response.Header().Set("X-AB-Group", group.Id)
+			if group.Strategy != "item_based" {
+				s.sessionRecommendByStrategy(ctx, response, userId, dataFeedback, category, n, offset, group.Strategy)
+				return
+			}
+		}
+	}
+
 	// item-based recommendation
-	var excludeSet = mapset.NewSet[string]()
+	blocklist, err := s.itemBlocklist(ctx)
+	if err != nil {
+		InternalServerError(response, err)
+		return
+	}
+	var excludeSet = mapset.NewSet(blocklist.ToSlice()...)
 	var userFeedback []data.Feedback
 	for _, feedback := range dataFeedback {
 		excludeSet.Add(feedback.ItemId)
-		if funk.ContainsString(s.Config.Recommend.DataSource.PositiveFeedbackTypes, feedback.FeedbackType) {
+		if funk.ContainsString(s.FeedbackTypes().Positive, feedback.FeedbackType) {
 			userFeedback = append(userFeedback, feedback)
 		}
 	}
@@ -1190,15 +2305,60 @@ func (s *RestServer) sessionRecommend(request *restful.Request, response *restfu
 		result = nil
 	}
 	result = result[:lo.Min([]int{len(result), n})]
+	s.countRecommendation(ctx)
 	// Send result
 	Ok(response, result)
 }
 
+// sessionUserId returns the user of session feedback, or an empty string if the session is anonymous.
+func sessionUserId(feedback []data.Feedback) string {
+	for _, f := range feedback {
+		if f.UserId != "" {
+			return f.UserId
+		}
+	}
+	return ""
+}
+
+// sessionRecommendByStrategy recommends items to the user of a session by the strategy of the A/B group of the user.
+// Items in the session are excluded.
+func (s *RestServer) sessionRecommendByStrategy(ctx context.Context, response *restful.Response, userId string,
+	feedback []data.Feedback, category string, n, offset int, strategy string) {
+	recommenders, err := s.StrategyRecommenders(strategy)
+	if err != nil {
+		InternalServerError(response, err)
+		return
+	}
+	sessionItems := lo.Map(feedback, func(f data.Feedback, _ int) string { return f.ItemId })
+	scores, err := s.RecommendWithScores(ctx, response, userId, []string{category}, false, sessionItems, offset+n, recommenders...)
+	if err != nil {
+		InternalServerError(response, err)
+		return
+	}
+	scores = scores[min(offset, len(scores)):]
+	scores = scores[:min(n, len(scores))]
+	s.countRecommendation(ctx)
+	Ok(response, scores)
+}
+
 // Success is the returned data structure for data insert operations.
 type Success struct {
 	RowAffected int
 }
 
+// PartialSuccess is the result of a batch in which invalid rows are rejected while valid rows are committed.
+type PartialSuccess struct {
+	RowAffected int
+	Rejected    int
+	Errors      []RowError `json:",omitempty"`
+}
+
+// RowError is the error of a rejected row in a batch. The index starts from zero.
+type RowError struct {
+	Index int
+	Error string
+}
+
 func (s *RestServer) insertUser(request *restful.Request, response *restful.Response) {
 	ctx := context.Background()
 	if request != nil && request.Request != nil {
@@ -1215,7 +2375,10 @@ func (s *RestServer) insertUser(request *restful.Request, response *restful.Resp
 		BadRequest(response, err)
 		return
 	}
-	if err := s.DataClient.BatchInsertUsers(ctx, []data.User{temp}); err != nil {
+	if err := s.DataClient.BatchInsertUsers(ctx, []data.User{temp}); errors.Is(err, errors.NotValid) {
+		BadRequest(response, err)
+		return
+	} else if err != nil {
 		InternalServerError(response, err)
 		return
 	}
@@ -1245,7 +2408,10 @@ func (s *RestServer) modifyUser(request *restful.Request, response *restful.Resp
 		BadRequest(response, err)
 		return
 	}
-	if err := s.DataClient.ModifyUser(ctx, userId, patch); err != nil {
+	if err := s.DataClient.ModifyUser(ctx, userId, patch); errors.Is(err, errors.NotValid) {
+		BadRequest(response, err)
+		return
+	} else if err != nil {
 		InternalServerError(response, err)
 		return
 	}
@@ -1294,8 +2460,18 @@ func (s *RestServer) insertUsers(request *restful.Request, response *restful.Res
 			return
 		}
 	}
+	// validate unique user ids
+	if s.Config.Server.RejectDuplicateUsers {
+		if duplicates := data.DuplicateUserIds(temp); len(duplicates) > 0 {
+			BadRequest(response, fmt.Errorf("duplicate user ids: %s", strings.Join(duplicates, ", ")))
+			return
+		}
+	}
 	// range temp and achieve user
-	if err := s.DataClient.BatchInsertUsers(ctx, temp); err != nil {
+	if err := s.DataClient.BatchInsertUsers(ctx, temp); errors.Is(err, errors.NotValid) {
+		BadRequest(response, err)
+		return
+	} else if err != nil {
 		InternalServerError(response, err)
 		return
 	}
@@ -1390,6 +2566,24 @@ type Item struct {
 	Timestamp  string
 	Labels     any
 	Comment    string
+
+	AvailableFrom  string
+	AvailableUntil string
+}
+
+// AvailabilityWindow parses the availability window of the item. Empty bounds are parsed as nil.
+func (item Item) AvailabilityWindow() (*time.Time, *time.Time, error) {
+	var window [2]*time.Time
+	for i, s := range []string{item.AvailableFrom, item.AvailableUntil} {
+		if s != "" {
+			t, err := dateparse.ParseAny(s)
+			if err != nil {
+				return nil, nil, err
+			}
+			window[i] = &t
+		}
+	}
+	return window[0], window[1], nil
 }
 
 func (s *RestServer) batchInsertItems(ctx context.Context, response *restful.Response, temp []Item) {
@@ -1431,13 +2625,20 @@ func (s *RestServer) batchInsertItems(ctx context.Context, response *restful.Res
 				return
 			}
 		}
+		availableFrom, availableUntil, err := item.AvailabilityWindow()
+		if err != nil {
+			BadRequest(response, err)
+			return
+		}
 		items = append(items, data.Item{
-			ItemId:     item.ItemId,
-			IsHidden:   item.IsHidden,
-			Categories: item.Categories,
-			Timestamp:  timestamp,
-			Labels:     item.Labels,
-			Comment:    item.Comment,
+			ItemId:         item.ItemId,
+			IsHidden:       item.IsHidden,
+			Categories:     item.Categories,
+			Timestamp:      timestamp,
+			Labels:         item.Labels,
+			Comment:        item.Comment,
+			AvailableFrom:  availableFrom,
+			AvailableUntil: availableUntil,
 		})
 		// insert to latest items cache
 		if err = s.CacheClient.AddScores(ctx, cache.NonPersonalized, cache.Latest, []cache.Score{{
@@ -1468,6 +2669,9 @@ func (s *RestServer) batchInsertItems(ctx context.Context, response *restful.Res
 		return
 	}
 	insertItemsTime = time.Since(start)
+	if s.ItemsInserted != nil {
+		s.ItemsInserted(items)
+	}
 
 	// insert modify timestamp
 	start = time.Now()
@@ -1578,6 +2782,14 @@ func (s *RestServer) modifyItem(request *restful.Request, response *restful.Resp
 		InternalServerError(response, err)
 		return
 	}
+	if s.ItemsInserted != nil {
+		item, err := s.DataClient.GetItem(ctx, itemId)
+		if err != nil {
+			InternalServerError(response, err)
+			return
+		}
+		s.ItemsInserted([]data.Item{item})
+	}
 	// insert modify timestamp
 	if err := s.CacheClient.Set(ctx, cache.Time(cache.Key(cache.LastModifyItemTime, itemId), time.Now())); err != nil {
 		return
@@ -1602,7 +2814,17 @@ func (s *RestServer) getItems(request *restful.Request, response *restful.Respon
 		BadRequest(response, err)
 		return
 	}
-	cursor, items, err := s.DataClient.GetItems(ctx, cursor, n, nil)
+	var items []data.Item
+	if label := request.QueryParameter("label"); label != "" {
+		name, value, found := strings.Cut(label, ":")
+		if !found {
+			BadRequest(response, fmt.Errorf("invalid label filter: %s", label))
+			return
+		}
+		cursor, items, err = data.SearchItemsByLabel(ctx, s.DataClient, name, value, cursor, n)
+	} else {
+		cursor, items, err = s.DataClient.GetItems(ctx, cursor, n, nil)
+	}
 	if err != nil {
 		InternalServerError(response, err)
 		return
@@ -1734,34 +2956,117 @@ func (f Feedback) ToDataFeedback() (data.Feedback, error) {
 	return feedback, nil
 }
 
+// readFeedbackCSV reads feedback from CSV with columns type, user_id, item_id and an optional timestamp.
+// The header row is skipped if exists.
+func readFeedbackCSV(r io.Reader) ([]Feedback, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	var feedback []Feedback
+	for lineNumber := 1; ; lineNumber++ {
+		record, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return feedback, nil
+			}
+			return nil, err
+		}
+		if len(record) < 3 || len(record) > 4 {
+			return nil, fmt.Errorf("expect 3 or 4 columns at line %d but got %d", lineNumber, len(record))
+		}
+		if lineNumber == 1 && record[0] == "type" && record[1] == "user_id" && record[2] == "item_id" {
+			continue
+		}
+		f := Feedback{FeedbackKey: data.FeedbackKey{
+			FeedbackType: record[0],
+			UserId:       record[1],
+			ItemId:       record[2],
+		}}
+		if len(record) > 3 {
+			f.Timestamp = record[3]
+		}
+		feedback = append(feedback, f)
+	}
+}
+
+// checkFeedbackType rejects feedback types out of positive and read feedback types in strict mode.
+func (s *RestServer) checkFeedbackType(feedbackType string) error {
+	if !s.Config.Server.StrictFeedbackType {
+		return nil
+	}
+	allowedTypes := append(append([]string{}, s.FeedbackTypes().Positive...),
+		s.FeedbackTypes().Read...)
+	if !lo.Contains(allowedTypes, feedbackType) {
+		return fmt.Errorf("unknown feedback type `%s`, allowed types: %s", feedbackType, strings.Join(allowedTypes, ", "))
+	}
+	return nil
+}
+
 func (s *RestServer) insertFeedback(overwrite bool) func(request *restful.Request, response *restful.Response) {
 	return func(request *restful.Request, response *restful.Response) {
 		ctx := context.Background()
 		if request != nil && request.Request != nil {
 			ctx = request.Request.Context()
 		}
+		// return the original result if the request has been processed
+		idempotencyKey := request.HeaderParameter("Idempotency-Key")
+		if idempotencyKey != "" && s.Config.Server.IdempotencyTTL > 0 {
+			token := uuid.NewString()
+			result, found, err := s.claimIdempotencyKey(ctx, idempotencyKey, token)
+			if errors.Is(err, errors.AlreadyExists) {
+				Error(response, http.StatusConflict, err)
+				return
+			} else if err != nil {
+				InternalServerError(response, err)
+				return
+			} else if found {
+				Ok(response, result)
+				return
+			}
+			defer s.releaseIdempotencyKey(ctx, idempotencyKey, token)
+		}
 		// add ratings
+		var err error
 		var feedbackLiterTime []Feedback
-		if err := request.ReadEntity(&feedbackLiterTime); err != nil {
+		if strings.HasPrefix(request.HeaderParameter("Content-Type"), MIME_CSV) {
+			if feedbackLiterTime, err = readFeedbackCSV(request.Request.Body); err != nil {
+				BadRequest(response, err)
+				return
+			}
+		} else if err = request.ReadEntity(&feedbackLiterTime); err != nil {
+			BadRequest(response, err)
+			return
+		}
+		// in partial mode, invalid feedback is rejected while the others are inserted
+		partial, err := ParseBool(request, "partial", false)
+		if err != nil {
 			BadRequest(response, err)
 			return
 		}
 		// parse datetime
-		var err error
-		feedback := make([]data.Feedback, len(feedbackLiterTime))
+		feedback := make([]data.Feedback, 0, len(feedbackLiterTime))
 		users := mapset.NewSet[string]()
 		items := mapset.NewSet[string]()
-		for i := range feedback {
-			users.Add(feedbackLiterTime[i].UserId)
-			items.Add(feedbackLiterTime[i].ItemId)
-			feedback[i], err = feedbackLiterTime[i].ToDataFeedback()
+		var rowErrors []RowError
+		for i := range feedbackLiterTime {
+			var f data.Feedback
+			if err = s.checkFeedbackType(feedbackLiterTime[i].FeedbackType); err == nil {
+				f, err = feedbackLiterTime[i].ToDataFeedback()
+			}
 			if err != nil {
-				BadRequest(response, err)
-				return
+				if !partial {
+					BadRequest(response, err)
+					return
+				}
+				rowErrors = append(rowErrors, RowError{Index: i, Error: err.Error()})
+				continue
 			}
+			users.Add(feedbackLiterTime[i].UserId)
+			items.Add(feedbackLiterTime[i].ItemId)
+			feedback = append(feedback, f)
 		}
 		// insert feedback to data store
-		err = s.DataClient.BatchInsertFeedback(ctx, feedback,
+		inserted, err := s.DataClient.BatchInsertFeedback(ctx, feedback,
 			s.Config.Server.AutoInsertUser,
 			s.Config.Server.AutoInsertItem, overwrite)
 		if err != nil {
@@ -1779,9 +3084,134 @@ func (s *RestServer) insertFeedback(overwrite bool) func(request *restful.Reques
 			InternalServerError(response, err)
 			return
 		}
+		UpdateFeedbackStats(ctx, s.CacheClient, inserted)
+		if err = s.CacheClient.RemSet(ctx, cache.NoFeedbackItems, items.ToSlice()...); err != nil {
+			log.ResponseLogger(response).Warn("failed to remove items from items without feedback", zap.Error(err))
+		}
+		var result any = Success{RowAffected: len(feedback)}
+		if partial {
+			result = PartialSuccess{RowAffected: len(feedback), Rejected: len(rowErrors), Errors: rowErrors}
+		}
+		if idempotencyKey != "" && s.Config.Server.IdempotencyTTL > 0 {
+			if err = s.setIdempotentResult(ctx, idempotencyKey, result); err != nil {
+				InternalServerError(response, err)
+				return
+			}
+		}
 		log.ResponseLogger(response).Info("Insert feedback successfully", zap.Int("num_feedback", len(feedback)))
-		Ok(response, Success{RowAffected: len(feedback)})
+		Ok(response, result)
+	}
+}
+
+// claimIdempotencyKey returns the result of a processed request with the idempotency key. Otherwise, the key is
+// claimed by the current request with the token until it is released, and concurrent requests with the same key are
+// rejected.
+func (s *RestServer) claimIdempotencyKey(ctx context.Context, key, token string) (json.RawMessage, bool, error) {
+	result, found, err := s.getIdempotentResult(ctx, key)
+	if err != nil || found {
+		return result, found, err
+	}
+	locked, err := s.CacheClient.TryLock(ctx, cache.Key(cache.IdempotencyLock, key), token, time.Minute)
+	if err != nil {
+		return nil, false, errors.Trace(err)
+	}
+	if !locked {
+		return nil, false, errors.AlreadyExistsf("request with idempotency key %s in progress", key)
+	}
+	// the request might be completed before the lock is acquired
+	return s.getIdempotentResult(ctx, key)
+}
+
+// releaseIdempotencyKey releases the idempotency key claimed by the current request with the token.
+func (s *RestServer) releaseIdempotencyKey(ctx context.Context, key, token string) {
+	if err := s.CacheClient.Unlock(context.WithoutCancel(ctx), cache.Key(cache.IdempotencyLock, key), token); err != nil {
+		log.Logger().Warn("failed to release idempotency key", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// getIdempotentResult returns the result of a processed request with the idempotency key.
+func (s *RestServer) getIdempotentResult(ctx context.Context, key string) (json.RawMessage, bool, error) {
+	text, err := s.CacheClient.Get(ctx, cache.Key(cache.IdempotencyKey, key)).String()
+	if errors.Is(err, errors.NotFound) || (err == nil && text == "") {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, errors.Trace(err)
 	}
+	return json.RawMessage(text), true, nil
+}
+
+// setIdempotentResult records the result of a processed request with the idempotency key. The result expires
+// after the idempotency TTL.
+func (s *RestServer) setIdempotentResult(ctx context.Context, key string, result any) error {
+	text, err := json.Marshal(result)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return s.CacheClient.Set(ctx, cache.String(cache.Key(cache.IdempotencyKey, key), string(text)).
+		WithTTL(s.Config.Server.IdempotencyTTL))
+}
+
+// UpdateFeedbackStats updates statistics of feedback inserted into the data store, including the number of feedback
+// of each item in FeedbackCount, the number of recent feedback of each item per minute in ItemFeedbackPerMinute, the
+// number of feedback inserted per hour in FeedbackTimeline. Counters are increased atomically. Since the feedback has been committed, statistics are updated
+// on a best-effort basis and failures are logged.
+func UpdateFeedbackStats(ctx context.Context, cacheClient cache.Database, feedback []data.Feedback) {
+	if len(feedback) == 0 {
+		return
+	}
+	now := time.Now()
+	itemCounts := make(map[string]map[string]int)
+	minuteCounts := make(map[string]map[time.Time]int)
+	for _, f := range feedback {
+		if _, exist := itemCounts[f.FeedbackType]; !exist {
+			itemCounts[f.FeedbackType] = make(map[string]int)
+		}
+		itemCounts[f.FeedbackType][f.ItemId]++
+		if !f.Timestamp.After(now) && now.Sub(f.Timestamp) <= ItemFeedbackWindow {
+			if _, exist := minuteCounts[f.ItemId]; !exist {
+				minuteCounts[f.ItemId] = make(map[time.Time]int)
+			}
+			minuteCounts[f.ItemId][f.Timestamp.Truncate(time.Minute)]++
+		}
+	}
+	// update the number of feedback of items
+	for feedbackType, counts := range itemCounts {
+		if err := cacheClient.IncrScores(ctx, cache.FeedbackCount, feedbackType, countScores(counts, now)); err != nil {
+			log.Logger().Warn("failed to update number of feedback of items", zap.Error(err))
+		}
+	}
+	// update the number of recent feedback of items per minute and mark items as active
+	points := []cache.TimeSeriesPoint{{Name: FeedbackTimeline, Timestamp: now.Truncate(time.Hour), Value: float64(len(feedback))}}
+	activeItems := make([]cache.Score, 0, len(minuteCounts))
+	for itemId, counts := range minuteCounts {
+		name := cache.Key(ItemFeedbackPerMinute, itemId)
+		for minute, count := range counts {
+			points = append(points, cache.TimeSeriesPoint{Name: name, Timestamp: minute, Value: float64(count)})
+		}
+		activeItems = append(activeItems, cache.Score{Id: itemId, Score: float64(now.Unix()), Categories: []string{""}, Timestamp: now})
+	}
+	if err := cacheClient.IncrTimeSeriesPoints(ctx, points); err != nil {
+		log.Logger().Warn("failed to update time series of feedback", zap.Error(err))
+	}
+	if len(activeItems) > 0 {
+		if err := cacheClient.AddScores(ctx, ItemFeedbackActive, "", activeItems); err != nil {
+			log.Logger().Warn("failed to mark items with recent feedback as active", zap.Error(err))
+		}
+	}
+}
+
+// countScores converts counts to documents to be added by IncrScores.
+func countScores(counts map[string]int, timestamp time.Time) []cache.Score {
+	documents := make([]cache.Score, 0, len(counts))
+	for id, count := range counts {
+		documents = append(documents, cache.Score{
+			Id:         id,
+			Score:      float64(count),
+			Categories: []string{""},
+			Timestamp:  timestamp,
+		})
+	}
+	return documents
 }
 
 // FeedbackIterator is the iterator for feedback.
@@ -1964,6 +3394,13 @@ func BadRequest(response *restful.Response, err error) {
 // InternalServerError returns a internal server error.
 func InternalServerError(response *restful.Response, err error) {
 	response.Header().Set("Access-Control-Allow-Origin", "*")
+	if errors.Is(err, context.DeadlineExceeded) {
+		log.ResponseLogger(response).Error("gateway timeout", zap.Error(err))
+		if err = response.WriteError(http.StatusGatewayTimeout, err); err != nil {
+			log.ResponseLogger(response).Error("failed to write error", zap.Error(err))
+		}
+		return
+	}
 	log.ResponseLogger(response).Error("internal server error", zap.Error(err))
 	if err = response.WriteError(http.StatusInternalServerError, err); err != nil {
 		log.ResponseLogger(response).Error("failed to write error", zap.Error(err))
@@ -2008,6 +3445,20 @@ func withWildCard(categories []string) []string {
 	return result
 }
 
+// ReadAnyCategories reads comma-separated categories from the category parameter. Items in any of these categories
+// are recommended. It returns nil if the category parameter is not a single comma-separated value.
+func ReadAnyCategories(request *restful.Request) []string {
+	categories := ReadCategories(request)
+	if len(categories) != 1 || !strings.Contains(categories[0], ",") {
+		return nil
+	}
+	return lo.Uniq(lo.Filter(lo.Map(strings.Split(categories[0], ","), func(category string, _ int) string {
+		return strings.TrimSpace(category)
+	}), func(category string, _ int) bool {
+		return category != ""
+	}))
+}
+
 // ReadCategories tries to read categories from the request. If the category is not found, it returns an empty string.
 func ReadCategories(request *restful.Request) []string {
 	if pathValue := request.PathParameter("category"); pathValue != "" {
@@ -17,8 +17,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -28,8 +31,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"github.com/zhenghaoz/gorse/config"
+	"github.com/zhenghaoz/gorse/protocol"
+	"github.com/zhenghaoz/gorse/storage"
 	"github.com/zhenghaoz/gorse/storage/cache"
 	"github.com/zhenghaoz/gorse/storage/data"
+	"google.golang.org/grpc"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -78,6 +84,8 @@ func (suite *ServerTestSuite) SetupTest() {
 	// configuration
 	suite.Config = config.GetDefaultConfig()
 	suite.Config.Server.APIKey = apiKey
+	suite.blocklistTime = time.Time{}
+	suite.abTestTime = time.Time{}
 }
 
 func (suite *ServerTestSuite) marshal(v interface{}) string {
@@ -656,6 +664,215 @@ func (suite *ServerTestSuite) TestItems() {
 		End()
 }
 
+func (suite *ServerTestSuite) TestSearchItemsByLabel() {
+	ctx := context.Background()
+	t := suite.T()
+	items := []data.Item{
+		{ItemId: "0", Timestamp: time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), Labels: map[string]any{"genre": []any{"comedy", "sci-fi"}}},
+		{ItemId: "1", Timestamp: time.Date(1997, 3, 15, 0, 0, 0, 0, time.UTC), Labels: map[string]any{"genre": []any{"comedy"}}},
+		{ItemId: "2", Timestamp: time.Date(1998, 3, 15, 0, 0, 0, 0, time.UTC), Labels: map[string]any{"genre": "sci-fi"}},
+		{ItemId: "3", Timestamp: time.Date(1999, 3, 15, 0, 0, 0, 0, time.UTC), Labels: map[string]any{"tag": []any{"sci-fi"}}},
+		{ItemId: "4", Timestamp: time.Date(2000, 3, 15, 0, 0, 0, 0, time.UTC), Labels: map[string]any{"spec": map[string]any{"color": "red", "size": "L"}}},
+		{ItemId: "5", Timestamp: time.Date(2001, 3, 15, 0, 0, 0, 0, time.UTC), Labels: map[string]any{"spec": map[string]any{"color": "blue", "size": "L"}}},
+	}
+	err := suite.DataClient.BatchInsertItems(ctx, items)
+	assert.NoError(t, err)
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/items").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"label": "genre:sci-fi",
+			"n":     "100",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal(ItemIterator{
+			Cursor: "",
+			Items:  []data.Item{items[0], items[2]},
+		})).
+		End()
+	// search items by a nested label
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/items").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"label": "spec.color:red",
+			"n":     "100",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal(ItemIterator{
+			Cursor: "",
+			Items:  []data.Item{items[4]},
+		})).
+		End()
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/items").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"label": "genre",
+		}).
+		Expect(t).
+		Status(http.StatusBadRequest).
+		End()
+}
+
+// slowCache blocks reading sets until the context is canceled.
+type slowCache struct {
+	cache.Database
+}
+
+func (c slowCache) GetSet(ctx context.Context, _ string) ([]string, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (suite *ServerTestSuite) TestRequestTimeout() {
+	t := suite.T()
+	cacheClient := suite.CacheClient
+	suite.CacheClient = slowCache{Database: cacheClient}
+	defer func() { suite.CacheClient = cacheClient }()
+	// recommendation is bounded by the recommendation timeout
+	suite.Config.Server.RecommendTimeout = 100 * time.Millisecond
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		Expect(t).
+		Status(http.StatusGatewayTimeout).
+		End()
+}
+
+func (suite *ServerTestSuite) TestABTest() {
+	ctx := context.Background()
+	t := suite.T()
+	experiment := ABTest{
+		Name: "exp1",
+		Groups: []ABTestGroup{
+			{Id: "control", Fraction: 0.5, Strategy: "offline"},
+			{Id: "treatment", Fraction: 0.5, Strategy: "popular"},
+		},
+	}
+	assert.NoError(t, experiment.Validate())
+	// the same user always maps to the same group
+	groups := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		userId := strconv.Itoa(i)
+		group := experiment.Assign(userId)
+		assert.Equal(t, group, experiment.Assign(userId))
+		groups[group.Id]++
+	}
+	assert.Len(t, groups, 2)
+	// store the active experiment
+	buf, err := json.Marshal(experiment)
+	assert.NoError(t, err)
+	err = suite.CacheClient.Set(ctx, cache.String(ActiveABTest, string(buf)))
+	assert.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		userId := strconv.Itoa(i)
+		for j := 0; j < 2; j++ {
+			apitest.New().
+				Handler(suite.handler).
+				Get("/api/recommend/"+userId).
+				Header("X-API-Key", apiKey).
+				Expect(t).
+				Status(http.StatusOK).
+				Header("X-AB-Group", experiment.Assign(userId).Id).
+				End()
+		}
+	}
+	// invalid experiments
+	assert.Error(t, (&ABTest{Name: "exp2", Groups: []ABTestGroup{{Id: "a", Fraction: 0.6, Strategy: "offline"}}}).Validate())
+	assert.Error(t, (&ABTest{Name: "exp3", Groups: []ABTestGroup{{Id: "a", Fraction: 1, Strategy: "unknown"}}}).Validate())
+}
+
+func (suite *ServerTestSuite) TestSessionRecommendABTest() {
+	ctx := context.Background()
+	t := suite.T()
+	experiment := ABTest{
+		Name:   "exp1",
+		Groups: []ABTestGroup{{Id: "treatment", Fraction: 1, Strategy: "popular"}},
+	}
+	buf, err := json.Marshal(experiment)
+	assert.NoError(t, err)
+	err = suite.CacheClient.Set(ctx, cache.String(ActiveABTest, string(buf)))
+	assert.NoError(t, err)
+	err = suite.CacheClient.AddScores(ctx, cache.NonPersonalized, cache.Popular, []cache.Score{
+		{Id: "c", Score: 100, Categories: []string{""}},
+		{Id: "b", Score: 60, Categories: []string{""}},
+		{Id: "a", Score: 0, Categories: []string{""}},
+	})
+	assert.NoError(t, err)
+	// the session user receives recommendations by the strategy of its group, excluding session items
+	feedback := []data.Feedback{
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "0", ItemId: "b"}, Timestamp: time.Date(2010, 1, 1, 1, 1, 1, 1, time.UTC)},
+	}
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/session/recommend").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n": "3",
+		}).
+		JSON(feedback).
+		Expect(t).
+		Status(http.StatusOK).
+		Header("X-AB-Group", "treatment").
+		Body(suite.marshal([]cache.Score{{Id: "c"}, {Id: "a"}})).
+		End()
+}
+
+func (suite *ServerTestSuite) TestInsertFeedbackPartially() {
+	ctx := context.Background()
+	t := suite.T()
+	feedback := []Feedback{
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "0", ItemId: "0"}, Timestamp: "2020-01-01"},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "1", ItemId: "1"}, Timestamp: "not a timestamp"},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "2", ItemId: "2"}, Timestamp: "2020-01-02"},
+	}
+	// the whole batch fails by default
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/feedback").
+		Header("X-API-Key", apiKey).
+		JSON(feedback).
+		Expect(t).
+		Status(http.StatusBadRequest).
+		End()
+	// valid feedback is inserted in partial mode
+	var result PartialSuccess
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/feedback").
+		Header("X-API-Key", apiKey).
+		Query("partial", "true").
+		JSON(feedback).
+		Expect(t).
+		Assert(func(response *http.Response, _ *http.Request) error {
+			return json.NewDecoder(response.Body).Decode(&result)
+		}).
+		Status(http.StatusOK).
+		End()
+	assert.Equal(t, 2, result.RowAffected)
+	assert.Equal(t, 1, result.Rejected)
+	if assert.Len(t, result.Errors, 1) {
+		assert.Equal(t, 1, result.Errors[0].Index)
+		assert.NotEmpty(t, result.Errors[0].Error)
+		// fields of row errors are named like other fields of the result
+		buf, err := json.Marshal(result.Errors[0])
+		assert.NoError(t, err)
+		var fields map[string]any
+		assert.NoError(t, json.Unmarshal(buf, &fields))
+		assert.ElementsMatch(t, []string{"Index", "Error"}, lo.Keys(fields))
+	}
+	_, inserted, err := suite.DataClient.GetFeedback(ctx, "", 10, nil, lo.ToPtr(time.Now()))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"0", "2"}, lo.Map(inserted, func(f data.Feedback, _ int) string { return f.UserId }))
+}
+
 func (suite *ServerTestSuite) TestFeedback() {
 	ctx := context.Background()
 	t := suite.T()
@@ -856,7 +1073,7 @@ func (suite *ServerTestSuite) TestNonPersonalizedRecommend() {
 				Status(http.StatusOK).
 				End()
 			// insert read feedback
-			err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{{
+			_, err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{{
 				FeedbackKey: data.FeedbackKey{
 					FeedbackType: "read",
 					UserId:       "0",
@@ -887,6 +1104,19 @@ func (suite *ServerTestSuite) TestNonPersonalizedRecommend() {
 				Status(http.StatusOK).
 				Body(suite.marshal([]cache.Score{documents[0], documents[1], documents[2]})).
 				End()
+			// requesting more than stored returns all stored documents
+			apitest.New().
+				Handler(suite.handler).
+				Get(operator.URL).
+				Query("category", operator.Category).
+				Header("X-API-Key", apiKey).
+				QueryParams(map[string]string{
+					"offset": "0",
+					"n":      "100"}).
+				Expect(t).
+				Status(http.StatusOK).
+				Body(suite.marshal([]cache.Score{documents[0], documents[1], documents[2], documents[4]})).
+				End()
 			apitest.New().
 				Handler(suite.handler).
 				Get(operator.URL).
@@ -928,15 +1158,15 @@ func (suite *ServerTestSuite) TestNonPersonalizedRecommend() {
 	}
 }
 
-func (suite *ServerTestSuite) TestDeleteFeedback() {
+func (suite *ServerTestSuite) TestFeedbackCount() {
+	ctx := context.Background()
 	t := suite.T()
-	// Insert feedback
-	feedback := []data.Feedback{
-		{FeedbackKey: data.FeedbackKey{FeedbackType: "type1", UserId: "2", ItemId: "3"}},
-		{FeedbackKey: data.FeedbackKey{FeedbackType: "type2", UserId: "2", ItemId: "3"}},
-		{FeedbackKey: data.FeedbackKey{FeedbackType: "type3", UserId: "2", ItemId: "3"}},
-		{FeedbackKey: data.FeedbackKey{FeedbackType: "type1", UserId: "1", ItemId: "6"}},
-		{FeedbackKey: data.FeedbackKey{FeedbackType: "type1", UserId: "4", ItemId: "8"}},
+	// insert feedback
+	feedback := []Feedback{
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "0", ItemId: "1"}},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "0", ItemId: "2"}},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "1", ItemId: "2"}},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "like", UserId: "0", ItemId: "1"}},
 	}
 	apitest.New().
 		Handler(suite.handler).
@@ -945,196 +1175,1231 @@ func (suite *ServerTestSuite) TestDeleteFeedback() {
 		JSON(feedback).
 		Expect(t).
 		Status(http.StatusOK).
-		Body(`{"RowAffected": 5}`).
-		End()
-	// Get Feedback
-	apitest.New().
-		Handler(suite.handler).
-		Get("/api/feedback/2/3").
-		Header("X-API-Key", apiKey).
-		Expect(t).
-		Body(suite.marshal([]data.Feedback{feedback[0], feedback[1], feedback[2]})).
-		Status(http.StatusOK).
+		Body(`{"RowAffected": 4}`).
 		End()
-	// Get typed feedback
+	feedback = []Feedback{
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "2", ItemId: "3"}},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "3", ItemId: "3"}},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "4", ItemId: "3"}},
+	}
 	apitest.New().
 		Handler(suite.handler).
-		Get("/api/feedback/type2/2/3").
+		Post("/api/feedback").
 		Header("X-API-Key", apiKey).
+		JSON(feedback).
 		Expect(t).
 		Status(http.StatusOK).
-		Body(suite.marshal(feedback[1])).
+		Body(`{"RowAffected": 3}`).
 		End()
-	// delete feedback
+	// check counts
+	scores, err := suite.CacheClient.SearchScores(ctx, cache.FeedbackCount, "click", []string{""}, 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"3", "2", "1"}, cache.ConvertDocumentsToValues(scores))
+	assert.Equal(t, []float64{3, 2, 1}, lo.Map(scores, func(score cache.Score, _ int) float64 { return score.Score }))
+	scores, err = suite.CacheClient.SearchScores(ctx, cache.FeedbackCount, "like", []string{""}, 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1"}, cache.ConvertDocumentsToValues(scores))
+	// ignored and overwritten feedback is not counted
 	apitest.New().
 		Handler(suite.handler).
-		Delete("/api/feedback/2/3").
+		Post("/api/feedback").
 		Header("X-API-Key", apiKey).
+		JSON(feedback).
 		Expect(t).
-		Body(`{"RowAffected": 3}`).
 		Status(http.StatusOK).
 		End()
-	// delete typed feedback
 	apitest.New().
 		Handler(suite.handler).
-		Delete("/api/feedback/type1/4/8").
+		Put("/api/feedback").
 		Header("X-API-Key", apiKey).
+		JSON(feedback).
 		Expect(t).
 		Status(http.StatusOK).
-		Body(`{"RowAffected": 1}`).
 		End()
+	scores, err = suite.CacheClient.SearchScores(ctx, cache.FeedbackCount, "click", []string{""}, 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{3, 2, 1}, lo.Map(scores, func(score cache.Score, _ int) float64 { return score.Score }))
 }
 
-func (suite *ServerTestSuite) TestTimeSeries() {
+func (suite *ServerTestSuite) TestItemFeedbackSeries() {
 	ctx := context.Background()
 	t := suite.T()
-	timestamp := time.Now().Add(24 * time.Hour * -2)
-	points := []cache.TimeSeriesPoint{
-		{"Test_NDCG", timestamp.Add(24 * time.Hour * 0), 0},
-		{"Test_NDCG", timestamp.Add(24 * time.Hour * 1), 1},
-		{"Test_NDCG", timestamp.Add(24 * time.Hour * 2), 2},
-		{"Test_NDCG", timestamp.Add(24 * time.Hour * 3), 3},
-		{"Test_NDCG", timestamp.Add(24 * time.Hour * 4), 4},
-		{"Test_Recall", timestamp, 1},
+	minute := time.Now().Add(-5 * time.Minute).Truncate(time.Minute)
+	feedback := []Feedback{
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "0", ItemId: "1"}, Timestamp: minute.Format(time.RFC3339)},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "1", ItemId: "1"}, Timestamp: minute.Format(time.RFC3339)},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "2", ItemId: "1"}, Timestamp: minute.Add(-time.Hour).Format(time.RFC3339)},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "3", ItemId: "1"}, Timestamp: minute.Add(-3 * time.Hour).Format(time.RFC3339)},
 	}
-	err := suite.CacheClient.AddTimeSeriesPoints(ctx, points)
-	assert.NoError(t, err)
 	apitest.New().
 		Handler(suite.handler).
-		Get("/api/measurements/Test_NDCG").
-		Query("n", "3").
+		Post("/api/feedback").
 		Header("X-API-Key", apiKey).
+		JSON(feedback).
 		Expect(t).
 		Status(http.StatusOK).
-		Body(suite.marshal([]cache.TimeSeriesPoint{
-			points[0],
-			points[1],
-			points[2],
-		})).
+		Body(`{"RowAffected": 4}`).
 		End()
-	// test auth fail
+	feedback = []Feedback{
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "like", UserId: "0", ItemId: "1"}, Timestamp: minute.Format(time.RFC3339)},
+	}
 	apitest.New().
 		Handler(suite.handler).
-		Get("/api/measurements/Test_NDCG").
-		Query("n", "3").
+		Post("/api/feedback").
+		Header("X-API-Key", apiKey).
+		JSON(feedback).
 		Expect(t).
-		Status(http.StatusUnauthorized).
+		Status(http.StatusOK).
+		Body(`{"RowAffected": 1}`).
 		End()
+	// feedback out of the window is not counted
+	points, err := suite.CacheClient.GetTimeSeriesPoints(ctx, cache.Key(ItemFeedbackPerMinute, "1"),
+		time.Now().Add(-4*time.Hour), time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 3}, lo.Map(points, func(point cache.TimeSeriesPoint, _ int) float64 { return point.Value }))
+	assert.Equal(t, minute.Unix(), points[1].Timestamp.Unix())
+	// the item is marked as active
+	activeItems, err := suite.CacheClient.SearchScores(ctx, ItemFeedbackActive, "", []string{""}, 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1"}, lo.Map(activeItems, func(item cache.Score, _ int) string { return item.Id }))
 }
 
-func (suite *ServerTestSuite) TestGetRecommends() {
+func (suite *ServerTestSuite) TestFeedbackIdempotencyKey() {
 	ctx := context.Background()
 	t := suite.T()
-	// insert hidden items
-	err := suite.CacheClient.AddScores(ctx, cache.OfflineRecommend, "0", []cache.Score{{Id: "0", Score: 100, Categories: []string{""}}})
-	assert.NoError(t, err)
-	// hide item
+	suite.Config.Server.IdempotencyTTL = time.Second
+	feedback := []Feedback{{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "0", ItemId: "1"}, Timestamp: "2001-01-01"}}
 	apitest.New().
 		Handler(suite.handler).
-		Patch("/api/item/0").
+		Put("/api/feedback").
 		Header("X-API-Key", apiKey).
-		JSON(data.ItemPatch{IsHidden: proto.Bool(true)}).
+		Header("Idempotency-Key", "retry").
+		JSON(feedback).
 		Expect(t).
 		Status(http.StatusOK).
+		Body(`{"RowAffected": 1}`).
 		End()
-	// insert items
-	err = suite.DataClient.BatchInsertItems(ctx, []data.Item{
-		{ItemId: "1"},
-		{ItemId: "2"},
-		{ItemId: "3"},
-		{ItemId: "4"},
-		{ItemId: "5"},
-		{ItemId: "6"},
-		{ItemId: "7"},
-		{ItemId: "8"},
-	})
-	assert.NoError(t, err)
-	// insert recommendation
-	err = suite.CacheClient.AddScores(ctx, cache.OfflineRecommend, "0", []cache.Score{
-		{Id: "1", Score: 99, Categories: []string{""}},
-		{Id: "2", Score: 98, Categories: []string{""}},
-		{Id: "3", Score: 97, Categories: []string{""}},
-		{Id: "4", Score: 96, Categories: []string{""}},
-		{Id: "5", Score: 95, Categories: []string{""}},
-		{Id: "6", Score: 94, Categories: []string{""}},
-		{Id: "7", Score: 93, Categories: []string{""}},
-		{Id: "8", Score: 92, Categories: []string{""}},
-	})
-	assert.NoError(t, err)
-	// insert feedback
-	feedback := []data.Feedback{
-		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "0", ItemId: "2"}},
-		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "0", ItemId: "4"}},
-		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "0", ItemId: "1"}, Timestamp: time.Now().Add(time.Hour)},
-	}
+	// retry with the same key
+	feedback[0].Timestamp = "2002-02-02"
 	apitest.New().
 		Handler(suite.handler).
-		Post("/api/feedback").
+		Put("/api/feedback").
 		Header("X-API-Key", apiKey).
+		Header("Idempotency-Key", "retry").
 		JSON(feedback).
 		Expect(t).
 		Status(http.StatusOK).
-		Body(`{"RowAffected": 3}`).
+		Body(`{"RowAffected": 1}`).
 		End()
+	// only the first request is processed
+	stored, err := suite.DataClient.GetUserFeedback(ctx, "0", nil)
+	assert.NoError(t, err)
+	if assert.Len(t, stored, 1) {
+		assert.Equal(t, 2001, stored[0].Timestamp.Year())
+	}
+	scores, err := suite.CacheClient.SearchScores(ctx, cache.FeedbackCount, "click", []string{""}, 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1}, lo.Map(scores, func(score cache.Score, _ int) float64 { return score.Score }))
+	// concurrent requests with the same key are rejected
+	locked, err := suite.CacheClient.TryLock(ctx, cache.Key(cache.IdempotencyLock, "concurrent"), "token", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, locked)
 	apitest.New().
 		Handler(suite.handler).
-		Get("/api/recommend/0").
+		Put("/api/feedback").
+		Header("X-API-Key", apiKey).
+		Header("Idempotency-Key", "concurrent").
+		JSON(feedback).
+		Expect(t).
+		Status(http.StatusConflict).
+		End()
+	// the key expires
+	time.Sleep(1100 * time.Millisecond)
+	apitest.New().
+		Handler(suite.handler).
+		Put("/api/feedback").
+		Header("X-API-Key", apiKey).
+		Header("Idempotency-Key", "retry").
+		JSON(feedback).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`{"RowAffected": 1}`).
+		End()
+	stored, err = suite.DataClient.GetUserFeedback(ctx, "0", nil)
+	assert.NoError(t, err)
+	if assert.Len(t, stored, 1) {
+		assert.Equal(t, 2002, stored[0].Timestamp.Year())
+	}
+}
+
+func (suite *ServerTestSuite) TestStrictFeedbackType() {
+	ctx := context.Background()
+	t := suite.T()
+	feedback := []Feedback{{FeedbackKey: data.FeedbackKey{FeedbackType: "bogus", UserId: "0", ItemId: "1"}}}
+	// accept unknown feedback types by default
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/feedback").
+		Header("X-API-Key", apiKey).
+		JSON(feedback).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`{"RowAffected": 1}`).
+		End()
+	// reject unknown feedback types in strict mode
+	suite.Config.Server.StrictFeedbackType = true
+	suite.Config.Recommend.DataSource.PositiveFeedbackTypes = []string{"star"}
+	suite.Config.Recommend.DataSource.ReadFeedbackTypes = []string{"read"}
+	feedback[0].ItemId = "2"
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/feedback").
+		Header("X-API-Key", apiKey).
+		JSON(feedback).
+		Expect(t).
+		Status(http.StatusBadRequest).
+		Body("unknown feedback type `bogus`, allowed types: star, read").
+		End()
+	stored, err := suite.DataClient.GetUserFeedback(ctx, "0", nil)
+	assert.NoError(t, err)
+	assert.Len(t, stored, 1)
+	// accept known feedback types in strict mode
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/feedback").
+		Header("X-API-Key", apiKey).
+		JSON([]Feedback{
+			{FeedbackKey: data.FeedbackKey{FeedbackType: "star", UserId: "0", ItemId: "2"}},
+			{FeedbackKey: data.FeedbackKey{FeedbackType: "read", UserId: "0", ItemId: "3"}},
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`{"RowAffected": 2}`).
+		End()
+}
+
+func (suite *ServerTestSuite) TestRejectDuplicateUsers() {
+	ctx := context.Background()
+	t := suite.T()
+	users := []data.User{{UserId: "1"}, {UserId: "2", Comment: "first"}, {UserId: "2", Comment: "second"}, {UserId: "1"}}
+	// reject duplicate users
+	suite.Config.Server.RejectDuplicateUsers = true
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/users").
+		Header("X-API-Key", apiKey).
+		JSON(users).
+		Expect(t).
+		Status(http.StatusBadRequest).
+		Body("duplicate user ids: 2, 1").
+		End()
+	_, stored, err := suite.DataClient.GetUsers(ctx, "", 100)
+	assert.NoError(t, err)
+	assert.Empty(t, stored)
+	// merge duplicate users
+	suite.Config.Server.RejectDuplicateUsers = false
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/users").
+		Header("X-API-Key", apiKey).
+		JSON(users).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`{"RowAffected": 4}`).
+		End()
+	_, stored, err = suite.DataClient.GetUsers(ctx, "", 100)
+	assert.NoError(t, err)
+	assert.Len(t, stored, 2)
+	user, err := suite.DataClient.GetUser(ctx, "2")
+	assert.NoError(t, err)
+	assert.Equal(t, "second", user.Comment)
+}
+
+func (suite *ServerTestSuite) TestMaxLabelsPerUser() {
+	ctx := context.Background()
+	t := suite.T()
+	users := []data.User{
+		{UserId: "1", Labels: map[string]any{"a": "1", "b": "2", "c": "3"}},
+		{UserId: "2", Labels: map[string]any{"a": "1"}},
+	}
+	// labels are limited by the data store
+	dataClient := suite.DataClient
+	defer func() {
+		suite.DataClient = dataClient
+	}()
+	openLimited := func(truncate bool) {
+		var err error
+		suite.DataClient, err = data.Open(fmt.Sprintf("sqlite://%s/data.db", t.TempDir()), "",
+			storage.WithUserLabelLimit(2, truncate))
+		assert.NoError(t, err)
+		assert.NoError(t, suite.DataClient.Init())
+	}
+	// reject users
+	openLimited(false)
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/users").
+		Header("X-API-Key", apiKey).
+		JSON(users).
+		Expect(t).
+		Status(http.StatusBadRequest).
+		Body("user `1` has 3 labels, exceeding the limit 2").
+		End()
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/user").
+		Header("X-API-Key", apiKey).
+		JSON(users[0]).
+		Expect(t).
+		Status(http.StatusBadRequest).
+		Body("user `1` has 3 labels, exceeding the limit 2").
+		End()
+	_, stored, err := suite.DataClient.GetUsers(ctx, "", 100)
+	assert.NoError(t, err)
+	assert.Empty(t, stored)
+	// reject patches
+	err = suite.DataClient.BatchInsertUsers(ctx, []data.User{users[1]})
+	assert.NoError(t, err)
+	apitest.New().
+		Handler(suite.handler).
+		Patch("/api/user/2").
+		Header("X-API-Key", apiKey).
+		JSON(data.UserPatch{Labels: users[0].Labels}).
+		Expect(t).
+		Status(http.StatusBadRequest).
+		Body("user `2` has 3 labels, exceeding the limit 2").
+		End()
+	user, err := suite.DataClient.GetUser(ctx, "2")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"a": "1"}, user.Labels)
+	assert.NoError(t, suite.DataClient.Close())
+	// truncate labels
+	openLimited(true)
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/users").
+		Header("X-API-Key", apiKey).
+		JSON(users).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`{"RowAffected": 2}`).
+		End()
+	user, err = suite.DataClient.GetUser(ctx, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"a": "1", "b": "2"}, user.Labels)
+	user, err = suite.DataClient.GetUser(ctx, "2")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"a": "1"}, user.Labels)
+	apitest.New().
+		Handler(suite.handler).
+		Patch("/api/user/2").
+		Header("X-API-Key", apiKey).
+		JSON(data.UserPatch{Labels: users[0].Labels}).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+	user, err = suite.DataClient.GetUser(ctx, "2")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"a": "1", "b": "2"}, user.Labels)
+	assert.NoError(t, suite.DataClient.Close())
+}
+
+func (suite *ServerTestSuite) TestFeedbackRemoveNoFeedbackItems() {
+	ctx := context.Background()
+	t := suite.T()
+	err := suite.CacheClient.SetSet(ctx, cache.NoFeedbackItems, "1", "2", "3")
+	assert.NoError(t, err)
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/feedback").
+		Header("X-API-Key", apiKey).
+		JSON([]Feedback{{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "0", ItemId: "2"}}}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`{"RowAffected": 1}`).
+		End()
+	items, err := suite.CacheClient.GetSet(ctx, cache.NoFeedbackItems)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"1", "3"}, items)
+}
+
+func (suite *ServerTestSuite) TestInsertFeedbackCSV() {
+	ctx := context.Background()
+	t := suite.T()
+	body := `type,user_id,item_id,timestamp
+click,0,0,2020-01-01T00:00:00Z
+"click","user,1","item ""2""",2021-01-01T00:00:00Z
+read,2,4
+`
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/feedback").
+		Header("X-API-Key", apiKey).
+		Header("Content-Type", MIME_CSV).
+		Body(body).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`{"RowAffected": 3}`).
+		End()
+	feedback, err := suite.DataClient.GetUserFeedback(ctx, "user,1", lo.ToPtr(time.Now()))
+	suite.NoError(err)
+	suite.Equal([]data.Feedback{{
+		FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "user,1", ItemId: `item "2"`},
+		Timestamp:   time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+	}}, feedback)
+	feedback, err = suite.DataClient.GetUserFeedback(ctx, "0", lo.ToPtr(time.Now()))
+	suite.NoError(err)
+	suite.Equal([]data.Feedback{{
+		FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "0", ItemId: "0"},
+		Timestamp:   time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}}, feedback)
+	feedback, err = suite.DataClient.GetUserFeedback(ctx, "2", lo.ToPtr(time.Now()))
+	suite.NoError(err)
+	suite.Equal([]data.Feedback{{
+		FeedbackKey: data.FeedbackKey{FeedbackType: "read", UserId: "2", ItemId: "4"},
+	}}, feedback)
+
+	// invalid number of columns
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/feedback").
+		Header("X-API-Key", apiKey).
+		Header("Content-Type", MIME_CSV).
+		Body("click,0\n").
+		Expect(t).
+		Status(http.StatusBadRequest).
+		End()
+}
+
+func (suite *ServerTestSuite) TestDeleteFeedback() {
+	t := suite.T()
+	// Insert feedback
+	feedback := []data.Feedback{
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "type1", UserId: "2", ItemId: "3"}},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "type2", UserId: "2", ItemId: "3"}},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "type3", UserId: "2", ItemId: "3"}},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "type1", UserId: "1", ItemId: "6"}},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "type1", UserId: "4", ItemId: "8"}},
+	}
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/feedback").
+		Header("X-API-Key", apiKey).
+		JSON(feedback).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`{"RowAffected": 5}`).
+		End()
+	// Get Feedback
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/feedback/2/3").
+		Header("X-API-Key", apiKey).
+		Expect(t).
+		Body(suite.marshal([]data.Feedback{feedback[0], feedback[1], feedback[2]})).
+		Status(http.StatusOK).
+		End()
+	// Get typed feedback
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/feedback/type2/2/3").
+		Header("X-API-Key", apiKey).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal(feedback[1])).
+		End()
+	// delete feedback
+	apitest.New().
+		Handler(suite.handler).
+		Delete("/api/feedback/2/3").
+		Header("X-API-Key", apiKey).
+		Expect(t).
+		Body(`{"RowAffected": 3}`).
+		Status(http.StatusOK).
+		End()
+	// delete typed feedback
+	apitest.New().
+		Handler(suite.handler).
+		Delete("/api/feedback/type1/4/8").
+		Header("X-API-Key", apiKey).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`{"RowAffected": 1}`).
+		End()
+}
+
+func (suite *ServerTestSuite) TestTimeSeries() {
+	ctx := context.Background()
+	t := suite.T()
+	timestamp := time.Now().Add(24 * time.Hour * -2)
+	points := []cache.TimeSeriesPoint{
+		{"Test_NDCG", timestamp.Add(24 * time.Hour * 0), 0},
+		{"Test_NDCG", timestamp.Add(24 * time.Hour * 1), 1},
+		{"Test_NDCG", timestamp.Add(24 * time.Hour * 2), 2},
+		{"Test_NDCG", timestamp.Add(24 * time.Hour * 3), 3},
+		{"Test_NDCG", timestamp.Add(24 * time.Hour * 4), 4},
+		{"Test_Recall", timestamp, 1},
+	}
+	err := suite.CacheClient.AddTimeSeriesPoints(ctx, points)
+	assert.NoError(t, err)
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/measurements/Test_NDCG").
+		Query("n", "3").
+		Header("X-API-Key", apiKey).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]cache.TimeSeriesPoint{
+			points[0],
+			points[1],
+			points[2],
+		})).
+		End()
+	// test auth fail
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/measurements/Test_NDCG").
+		Query("n", "3").
+		Expect(t).
+		Status(http.StatusUnauthorized).
+		End()
+}
+
+func (suite *ServerTestSuite) TestGetRecommends() {
+	ctx := context.Background()
+	t := suite.T()
+	// insert hidden items
+	err := suite.CacheClient.AddScores(ctx, cache.OfflineRecommend, "0", []cache.Score{{Id: "0", Score: 100, Categories: []string{""}}})
+	assert.NoError(t, err)
+	// hide item
+	apitest.New().
+		Handler(suite.handler).
+		Patch("/api/item/0").
+		Header("X-API-Key", apiKey).
+		JSON(data.ItemPatch{IsHidden: proto.Bool(true)}).
+		Expect(t).
+		Status(http.StatusOK).
+		End()
+	// insert items
+	err = suite.DataClient.BatchInsertItems(ctx, []data.Item{
+		{ItemId: "1"},
+		{ItemId: "2"},
+		{ItemId: "3"},
+		{ItemId: "4"},
+		{ItemId: "5"},
+		{ItemId: "6"},
+		{ItemId: "7"},
+		{ItemId: "8"},
+	})
+	assert.NoError(t, err)
+	// insert recommendation
+	err = suite.CacheClient.AddScores(ctx, cache.OfflineRecommend, "0", []cache.Score{
+		{Id: "1", Score: 99, Categories: []string{""}},
+		{Id: "2", Score: 98, Categories: []string{""}},
+		{Id: "3", Score: 97, Categories: []string{""}},
+		{Id: "4", Score: 96, Categories: []string{""}},
+		{Id: "5", Score: 95, Categories: []string{""}},
+		{Id: "6", Score: 94, Categories: []string{""}},
+		{Id: "7", Score: 93, Categories: []string{""}},
+		{Id: "8", Score: 92, Categories: []string{""}},
+	})
+	assert.NoError(t, err)
+	// insert feedback
+	feedback := []data.Feedback{
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "0", ItemId: "2"}},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "0", ItemId: "4"}},
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "0", ItemId: "1"}, Timestamp: time.Now().Add(time.Hour)},
+	}
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/feedback").
+		Header("X-API-Key", apiKey).
+		JSON(feedback).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`{"RowAffected": 3}`).
+		End()
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n": "3",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{"1", "3", "5"})).
+		End()
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n":      "3",
+			"offset": "3",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{"6", "7", "8"})).
+		End()
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n":      "3",
+			"offset": "10000",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{})).
+		End()
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n":               "3",
+			"write-back-type": "read",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{"1", "3", "5"})).
+		End()
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n":                "3",
+			"write-back-type":  "read",
+			"write-back-delay": "10m",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{"6", "7", "8"})).
+		End()
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n": "3",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{"6", "7", "8"})).
+		End()
+}
+
+func (suite *ServerTestSuite) TestGetRecommendsWithMaxSize() {
+	ctx := context.Background()
+	t := suite.T()
+	// insert recommendation
+	err := suite.CacheClient.AddScores(ctx, cache.OfflineRecommend, "0", []cache.Score{
+		{Id: "1", Score: 99, Categories: []string{""}},
+		{Id: "2", Score: 98, Categories: []string{""}},
+		{Id: "3", Score: 97, Categories: []string{""}},
+		{Id: "4", Score: 96, Categories: []string{""}},
+		{Id: "5", Score: 95, Categories: []string{""}},
+	})
+	assert.NoError(t, err)
+	// unlimited by default
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n": "5",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{"1", "2", "3", "4", "5"})).
+		End()
+	// clamp to the maximum size
+	suite.Config.Recommend.Online.MaxRecommendSize = 3
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n": "5",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{"1", "2", "3"})).
+		End()
+	// honor smaller n
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n": "2",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{"1", "2"})).
+		End()
+}
+
+func (suite *ServerTestSuite) TestRecommendHistory() {
+	ctx := context.Background()
+	t := suite.T()
+	// insert recommendation
+	err := suite.CacheClient.AddScores(ctx, cache.OfflineRecommend, "0", []cache.Score{
+		{Id: "1", Score: 99, Categories: []string{""}},
+		{Id: "2", Score: 98, Categories: []string{""}},
+		{Id: "3", Score: 97, Categories: []string{""}},
+	})
+	assert.NoError(t, err)
+	// insert an expired event whose items have been removed
+	err = suite.CacheClient.AddScores(ctx, RecommendHistory, "0", []cache.Score{{
+		Id:         "0",
+		Score:      float64(time.Now().Add(-RecommendHistoryWindow).UnixMicro()),
+		Categories: []string{""},
+		Timestamp:  time.Now().Add(-RecommendHistoryWindow),
+	}})
+	assert.NoError(t, err)
+	// serve recommendations
+	for _, n := range []string{"2", "3"} {
+		apitest.New().
+			Handler(suite.handler).
+			Get("/api/recommend/0").
+			Header("X-API-Key", apiKey).
+			QueryParams(map[string]string{
+				"n": n,
+			}).
+			Expect(t).
+			Status(http.StatusOK).
+			End()
+	}
+	// check history
+	events, err := GetRecommendHistory(ctx, suite.CacheClient, "0", 10)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, []cache.Score{{Id: "1", Score: 99}, {Id: "2", Score: 98}, {Id: "3", Score: 97}}, events[0].Items)
+		assert.Equal(t, []cache.Score{{Id: "1", Score: 99}, {Id: "2", Score: 98}}, events[1].Items)
+		assert.False(t, events[0].Timestamp.Before(events[1].Timestamp))
+	}
+	events, err = GetRecommendHistory(ctx, suite.CacheClient, "0", 1)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+}
+
+func (suite *ServerTestSuite) TestGetRecommendsExclude() {
+	ctx := context.Background()
+	t := suite.T()
+	// insert recommendation
+	err := suite.CacheClient.AddScores(ctx, cache.OfflineRecommend, "0", []cache.Score{
+		{Id: "1", Score: 99, Categories: []string{""}},
+		{Id: "2", Score: 98, Categories: []string{""}},
+		{Id: "3", Score: 97, Categories: []string{""}},
+		{Id: "4", Score: 96, Categories: []string{""}},
+		{Id: "5", Score: 95, Categories: []string{""}},
+	})
+	assert.NoError(t, err)
+	// insert feedback
+	_, err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "0", ItemId: "2"}},
+	}, true, true, true)
+	assert.NoError(t, err)
+	// exclude items
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n":       "2",
+			"exclude": "1, 4",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`["3", "5"]`).
+		End()
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n":       "2",
+			"offset":  "1",
+			"exclude": "3",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`["4", "5"]`).
+		End()
+	// exclude items with the maximum size
+	suite.Config.Recommend.Online.MaxRecommendSize = 2
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n":       "2",
+			"exclude": "1",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`["3", "4"]`).
+		End()
+	// too many excluded items
+	exclude := make([]string, MaxExcludeSize+1)
+	for i := range exclude {
+		exclude[i] = strconv.Itoa(i + 100)
+	}
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"exclude": strings.Join(exclude, ","),
+		}).
+		Expect(t).
+		Status(http.StatusBadRequest).
+		End()
+}
+
+func (suite *ServerTestSuite) TestGetRecommendsBlocklist() {
+	ctx := context.Background()
+	t := suite.T()
+	// insert recommendation
+	err := suite.CacheClient.AddScores(ctx, cache.OfflineRecommend, "0", []cache.Score{
+		{Id: "1", Score: 99, Categories: []string{""}},
+		{Id: "2", Score: 98, Categories: []string{""}},
+		{Id: "3", Score: 97, Categories: []string{""}},
+	})
+	assert.NoError(t, err)
+	// blocklist the top-scored item
+	err = suite.CacheClient.AddSet(ctx, cache.ItemBlocklist, "1")
+	assert.NoError(t, err)
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n": "2",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`["2", "3"]`).
+		End()
+	// blocklisted items are excluded from session recommendation
+	suite.Config.Recommend.DataSource.PositiveFeedbackTypes = []string{"a"}
+	err = suite.CacheClient.AddScores(ctx, cache.ItemToItem, cache.Key(cache.Neighbors, "4"), []cache.Score{
+		{Id: "1", Score: 99, Categories: []string{""}},
+		{Id: "2", Score: 98, Categories: []string{""}},
+	})
+	assert.NoError(t, err)
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/session/recommend").
+		Header("X-API-Key", apiKey).
+		JSON([]data.Feedback{{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "0", ItemId: "4"}}}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]cache.Score{{Id: "2", Score: 98}})).
+		End()
+	// the blocklist is cached until expired
+	err = suite.CacheClient.RemSet(ctx, cache.ItemBlocklist, "1")
+	assert.NoError(t, err)
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n": "2",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`["2", "3"]`).
+		End()
+	// remove from blocklist
+	suite.blocklistTime = time.Time{}
+	suite.abTestTime = time.Time{}
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n": "2",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`["1", "2"]`).
+		End()
+}
+
+func (suite *ServerTestSuite) TestRecommendScoreExpr() {
+	ctx := context.Background()
+	t := suite.T()
+	// insert items and recommendation
+	err := suite.DataClient.BatchInsertItems(ctx, []data.Item{
+		{ItemId: "1", Labels: map[string]any{"in_stock": false}},
+		{ItemId: "2", Labels: map[string]any{"in_stock": false}},
+		{ItemId: "3", Labels: map[string]any{"in_stock": true}},
+		{ItemId: "4", Labels: map[string]any{"in_stock": true}},
+		{ItemId: "5", Labels: map[string]any{"in_stock": false}},
+	})
+	assert.NoError(t, err)
+	err = suite.CacheClient.AddScores(ctx, cache.OfflineRecommend, "0", []cache.Score{
+		{Id: "1", Score: 99, Categories: []string{""}},
+		{Id: "2", Score: 98, Categories: []string{""}},
+		{Id: "3", Score: 97, Categories: []string{""}},
+	})
+	assert.NoError(t, err)
+	// scores are untouched without expression
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`["1", "2", "3"]`).
+		End()
+	// boost items in stock
+	suite.Config.Recommend.Online.ScoreExpr = "score * (item.Labels?.in_stock == true ? 2 : 1)"
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`["3", "1", "2"]`).
+		End()
+	// scores of fallback recommenders are not compared with offline scores
+	err = suite.CacheClient.AddScores(ctx, cache.NonPersonalized, cache.Latest, []cache.Score{
+		{Id: "5", Score: 1e9 + 1, Categories: []string{""}},
+		{Id: "4", Score: 1e9, Categories: []string{""}},
+	})
+	assert.NoError(t, err)
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		Query("n", "5").
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`["3", "1", "2", "4", "5"]`).
+		End()
+}
+
+type mockCustomScorer struct {
+	protocol.UnimplementedCustomScorerServer
+}
+
+// Score doubles scores of all items and boosts item "3".
+func (m *mockCustomScorer) Score(_ context.Context, in *protocol.ScoredItems) (*protocol.ScoredItems, error) {
+	out := &protocol.ScoredItems{UserId: in.UserId}
+	for _, item := range in.Items {
+		score := item.Score * 2
+		if item.ItemId == "3" {
+			score += 10
+		}
+		out.Items = append(out.Items, &protocol.ScoredItem{ItemId: item.ItemId, Score: score})
+	}
+	return out, nil
+}
+
+func (suite *ServerTestSuite) TestRecommendCustomScorers() {
+	ctx := context.Background()
+	t := suite.T()
+	// start custom scorer
+	lis, err := net.Listen("tcp", "localhost:0")
+	assert.NoError(t, err)
+	grpcServer := grpc.NewServer()
+	protocol.RegisterCustomScorerServer(grpcServer, &mockCustomScorer{})
+	go func() {
+		_ = grpcServer.Serve(lis)
+	}()
+	defer grpcServer.Stop()
+	// insert recommendation
+	err = suite.CacheClient.AddScores(ctx, cache.OfflineRecommend, "0", []cache.Score{
+		{Id: "1", Score: 99, Categories: []string{""}},
+		{Id: "2", Score: 98, Categories: []string{""}},
+		{Id: "3", Score: 97, Categories: []string{""}},
+	})
+	assert.NoError(t, err)
+	// custom scorers are called in order and failed scorers are skipped
+	suite.Config.Recommend.CustomScorers = []string{lis.Addr().String(), "localhost:1", lis.Addr().String()}
+	scores, err := suite.RecommendWithScores(ctx, restful.NewResponse(httptest.NewRecorder()), "0", []string{""}, false, nil, 3, suite.RecommendOffline)
+	assert.NoError(t, err)
+	assert.Equal(t, []cache.Score{
+		{Id: "3", Score: 418},
+		{Id: "1", Score: 396},
+		{Id: "2", Score: 392},
+	}, scores)
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
 		Header("X-API-Key", apiKey).
-		QueryParams(map[string]string{
-			"n": "3",
-		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`["3", "1", "2"]`).
+		End()
+	// connections are closed
+	suite.CloseCustomScorers()
+	assert.Empty(t, suite.scorerConns)
+}
+
+type hangingCustomScorer struct {
+	protocol.UnimplementedCustomScorerServer
+}
+
+// Score blocks until the call is canceled.
+func (m *hangingCustomScorer) Score(ctx context.Context, _ *protocol.ScoredItems) (*protocol.ScoredItems, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (suite *ServerTestSuite) TestRecommendCustomScorerTimeout() {
+	ctx := context.Background()
+	t := suite.T()
+	// start custom scorers
+	hangingLis, err := net.Listen("tcp", "localhost:0")
+	assert.NoError(t, err)
+	hangingServer := grpc.NewServer()
+	protocol.RegisterCustomScorerServer(hangingServer, &hangingCustomScorer{})
+	go func() {
+		_ = hangingServer.Serve(hangingLis)
+	}()
+	defer hangingServer.Stop()
+	lis, err := net.Listen("tcp", "localhost:0")
+	assert.NoError(t, err)
+	grpcServer := grpc.NewServer()
+	protocol.RegisterCustomScorerServer(grpcServer, &mockCustomScorer{})
+	go func() {
+		_ = grpcServer.Serve(lis)
+	}()
+	defer grpcServer.Stop()
+	defer suite.CloseCustomScorers()
+	// insert recommendation
+	err = suite.CacheClient.AddScores(ctx, cache.OfflineRecommend, "0", []cache.Score{
+		{Id: "1", Score: 99, Categories: []string{""}},
+		{Id: "2", Score: 98, Categories: []string{""}},
+		{Id: "3", Score: 97, Categories: []string{""}},
+	})
+	assert.NoError(t, err)
+	// scorers not responding in time are skipped
+	suite.Config.Recommend.CustomScorers = []string{hangingLis.Addr().String(), lis.Addr().String()}
+	suite.Config.Recommend.CustomScorerTimeout = 100 * time.Millisecond
+	scores, err := suite.RecommendWithScores(ctx, restful.NewResponse(httptest.NewRecorder()), "0", []string{""}, false, nil, 3, suite.RecommendOffline)
+	assert.NoError(t, err)
+	assert.Equal(t, []cache.Score{
+		{Id: "3", Score: 204},
+		{Id: "1", Score: 198},
+		{Id: "2", Score: 196},
+	}, scores)
+}
+
+func (suite *ServerTestSuite) TestRecordExposure() {
+	ctx := context.Background()
+	t := suite.T()
+	// insert recommendation
+	for _, userId := range []string{"0", "1"} {
+		err := suite.CacheClient.AddScores(ctx, cache.OfflineRecommend, userId, []cache.Score{
+			{Id: "1", Score: 99, Categories: []string{""}},
+			{Id: "2", Score: 98, Categories: []string{""}},
+		})
+		assert.NoError(t, err)
+	}
+	// serve recommendations
+	for _, userId := range []string{"0", "0", "1"} {
+		apitest.New().
+			Handler(suite.handler).
+			Get("/api/recommend/"+userId).
+			Header("X-API-Key", apiKey).
+			Expect(t).
+			Status(http.StatusOK).
+			End()
+	}
+	// exposure is buffered until flushed
+	scores, err := suite.CacheClient.SearchScores(ctx, Exposure, "2", []string{""}, 0, -1)
+	assert.NoError(t, err)
+	assert.Empty(t, scores)
+	err = suite.FlushExposure(ctx)
+	assert.NoError(t, err)
+	scores, err = suite.CacheClient.SearchScores(ctx, Exposure, "2", []string{""}, 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0", "1"}, cache.ConvertDocumentsToValues(scores))
+	assert.Equal(t, []float64{2, 1}, lo.Map(scores, func(score cache.Score, _ int) float64 { return score.Score }))
+	// flushed exposure is added to existing exposure
+	for i := 0; i < 2; i++ {
+		apitest.New().
+			Handler(suite.handler).
+			Get("/api/recommend/1").
+			Header("X-API-Key", apiKey).
+			Expect(t).
+			Status(http.StatusOK).
+			End()
+	}
+	err = suite.FlushExposure(ctx)
+	assert.NoError(t, err)
+	scores, err = suite.CacheClient.SearchScores(ctx, Exposure, "1", []string{""}, 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "0"}, cache.ConvertDocumentsToValues(scores))
+	assert.Equal(t, []float64{3, 2}, lo.Map(scores, func(score cache.Score, _ int) float64 { return score.Score }))
+}
+
+func (suite *ServerTestSuite) TestGetRecommendsMultipleCategories() {
+	ctx := context.Background()
+	t := suite.T()
+	// insert recommendation
+	err := suite.CacheClient.AddScores(ctx, cache.OfflineRecommend, "0", []cache.Score{
+		{Id: "1", Score: 99, Categories: []string{"", "x"}},
+		{Id: "2", Score: 98, Categories: []string{"", "y"}},
+		{Id: "3", Score: 97, Categories: []string{"", "x", "y"}},
+		{Id: "4", Score: 96, Categories: []string{"", "z"}},
+		{Id: "5", Score: 95, Categories: []string{"", "y"}},
+	})
+	assert.NoError(t, err)
+	// insert feedback
+	_, err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
+		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "0", ItemId: "2"}},
+	}, true, true, true)
+	assert.NoError(t, err)
+	// items in x or y
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"category": "x,y",
+			"n":        "10",
+		}).
 		Expect(t).
 		Status(http.StatusOK).
 		Body(suite.marshal([]string{"1", "3", "5"})).
 		End()
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0/x,y").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n": "2",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{"1", "3"})).
+		End()
+	// single category
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0/y").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n": "10",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{"3", "5"})).
+		End()
+}
+
+func (suite *ServerTestSuite) TestGetRecommendsWriteBack() {
+	ctx := context.Background()
+	t := suite.T()
+	suite.Config.Recommend.Online.FallbackRecommend = []string{"latest"}
+	// insert latest
+	err := suite.CacheClient.AddScores(ctx, cache.NonPersonalized, cache.Latest, []cache.Score{
+		{Id: "1", Score: 99, Categories: []string{""}},
+		{Id: "2", Score: 98, Categories: []string{""}},
+		{Id: "3", Score: 97, Categories: []string{""}}})
+	assert.NoError(t, err)
+	// compute every time if write back is disabled
 	apitest.New().
 		Handler(suite.handler).
 		Get("/api/recommend/0").
 		Header("X-API-Key", apiKey).
 		QueryParams(map[string]string{
-			"n":      "3",
-			"offset": "3",
+			"n": "3",
 		}).
 		Expect(t).
 		Status(http.StatusOK).
-		Body(suite.marshal([]string{"6", "7", "8"})).
+		Body(suite.marshal([]string{"1", "2", "3"})).
 		End()
+	scores, err := suite.CacheClient.SearchScores(ctx, cache.OfflineRecommend, "0", []string{""}, 0, -1)
+	assert.NoError(t, err)
+	assert.Empty(t, scores)
+
+	// write back online recommendation
+	suite.Config.Recommend.Online.WriteBackRecommend = true
 	apitest.New().
 		Handler(suite.handler).
 		Get("/api/recommend/0").
 		Header("X-API-Key", apiKey).
 		QueryParams(map[string]string{
-			"n":      "3",
-			"offset": "10000",
+			"n": "3",
 		}).
 		Expect(t).
 		Status(http.StatusOK).
-		Body(suite.marshal([]string{})).
+		Body(suite.marshal([]string{"1", "2", "3"})).
 		End()
+	scores, err = suite.CacheClient.SearchScores(ctx, cache.OfflineRecommend, "0", []string{""}, 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "3"}, lo.Map(scores, func(score cache.Score, _ int) string { return score.Id }))
+	assert.Equal(t, []float64{99, 98, 97}, lo.Map(scores, func(score cache.Score, _ int) float64 { return score.Score }))
+
+	// the second request hits the cache
+	err = suite.CacheClient.DeleteScores(ctx, []string{cache.NonPersonalized}, cache.ScoreCondition{Subset: proto.String(cache.Latest)})
+	assert.NoError(t, err)
+	err = suite.CacheClient.AddScores(ctx, cache.NonPersonalized, cache.Latest, []cache.Score{
+		{Id: "4", Score: 96, Categories: []string{""}},
+		{Id: "5", Score: 95, Categories: []string{""}},
+		{Id: "6", Score: 94, Categories: []string{""}}})
+	assert.NoError(t, err)
 	apitest.New().
 		Handler(suite.handler).
 		Get("/api/recommend/0").
 		Header("X-API-Key", apiKey).
 		QueryParams(map[string]string{
-			"n":               "3",
-			"write-back-type": "read",
+			"n": "3",
 		}).
 		Expect(t).
 		Status(http.StatusOK).
-		Body(suite.marshal([]string{"1", "3", "5"})).
+		Body(suite.marshal([]string{"1", "2", "3"})).
 		End()
+
+	// recompute after expiration
+	suite.Config.Recommend.Online.WriteBackRecommendTTL = time.Nanosecond
 	apitest.New().
 		Handler(suite.handler).
 		Get("/api/recommend/0").
 		Header("X-API-Key", apiKey).
 		QueryParams(map[string]string{
-			"n":                "3",
-			"write-back-type":  "read",
-			"write-back-delay": "10m",
+			"n": "3",
 		}).
 		Expect(t).
 		Status(http.StatusOK).
-		Body(suite.marshal([]string{"6", "7", "8"})).
+		Body(suite.marshal([]string{"4", "5", "6"})).
+		End()
+}
+
+func (suite *ServerTestSuite) TestGetRecommendsAvailabilityWindow() {
+	ctx := context.Background()
+	t := suite.T()
+	// insert recommendation
+	err := suite.CacheClient.AddScores(ctx, cache.OfflineRecommend, "0", []cache.Score{
+		{Id: "1", Score: 99, Categories: []string{""}},
+		{Id: "2", Score: 98, Categories: []string{""}},
+		{Id: "3", Score: 97, Categories: []string{""}},
+	})
+	assert.NoError(t, err)
+	// item 2 is not available before its start time and the latest item 4 is recommended instead
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/items").
+		Header("X-API-Key", apiKey).
+		JSON([]Item{
+			{ItemId: "1"},
+			{ItemId: "2", AvailableFrom: time.Now().Add(time.Hour).Format(time.RFC3339)},
+			{ItemId: "3", AvailableUntil: time.Now().Add(time.Hour).Format(time.RFC3339)},
+			{ItemId: "4", Timestamp: time.Now().Format(time.RFC3339)},
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`{"RowAffected": 4}`).
 		End()
 	apitest.New().
 		Handler(suite.handler).
@@ -1145,10 +2410,116 @@ func (suite *ServerTestSuite) TestGetRecommends() {
 		}).
 		Expect(t).
 		Status(http.StatusOK).
-		Body(suite.marshal([]string{"6", "7", "8"})).
+		Body(suite.marshal([]string{"1", "3", "4"})).
+		End()
+	// item 2 is available after its start time
+	apitest.New().
+		Handler(suite.handler).
+		Post("/api/items").
+		Header("X-API-Key", apiKey).
+		JSON([]Item{{ItemId: "2", AvailableFrom: time.Now().Add(-time.Hour).Format(time.RFC3339)}}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(`{"RowAffected": 1}`).
+		End()
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n": "3",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{"1", "2", "3"})).
+		End()
+}
+
+func (suite *ServerTestSuite) TestGetRecommendsBlend() {
+	ctx := context.Background()
+	t := suite.T()
+	// insert recommendation
+	err := suite.CacheClient.AddScores(ctx, cache.OfflineRecommend, "0", []cache.Score{
+		{Id: "a", Score: 3, Categories: []string{""}},
+		{Id: "b", Score: 2, Categories: []string{""}},
+		{Id: "c", Score: 1, Categories: []string{""}},
+	})
+	assert.NoError(t, err)
+	err = suite.CacheClient.AddScores(ctx, cache.CollaborativeRecommend, "0", []cache.Score{
+		{Id: "a", Score: 10, Categories: []string{""}},
+		{Id: "b", Score: 5, Categories: []string{""}},
+		{Id: "c", Score: 0, Categories: []string{""}},
+	})
+	assert.NoError(t, err)
+	err = suite.CacheClient.AddScores(ctx, cache.NonPersonalized, cache.Popular, []cache.Score{
+		{Id: "c", Score: 100, Categories: []string{""}},
+		{Id: "b", Score: 60, Categories: []string{""}},
+		{Id: "a", Score: 0, Categories: []string{""}},
+	})
+	assert.NoError(t, err)
+	// fallback mode
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n": "3",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{"a", "b", "c"})).
+		End()
+	// blend mode
+	suite.Config.Recommend.Online.Mode = "blend"
+	suite.Config.Recommend.Online.BlendWeights = map[string]float64{"collaborative": 0.4, "popular": 0.6}
+	apitest.New().
+		Handler(suite.handler).
+		Get("/api/recommend/0").
+		Header("X-API-Key", apiKey).
+		QueryParams(map[string]string{
+			"n": "3",
+		}).
+		Expect(t).
+		Status(http.StatusOK).
+		Body(suite.marshal([]string{"c", "b", "a"})).
 		End()
 }
 
+func (suite *ServerTestSuite) TestGetRecommendsBlendNormalization() {
+	ctx := context.Background()
+	t := suite.T()
+	// insert scores at different scales
+	err := suite.CacheClient.AddScores(ctx, cache.CollaborativeRecommend, "0", []cache.Score{
+		{Id: "a", Score: 0.9, Categories: []string{""}},
+		{Id: "b", Score: 0.5, Categories: []string{""}},
+		{Id: "c", Score: 0.1, Categories: []string{""}},
+	})
+	assert.NoError(t, err)
+	err = suite.CacheClient.AddScores(ctx, cache.NonPersonalized, cache.Popular, []cache.Score{
+		{Id: "b", Score: 1000, Categories: []string{""}},
+		{Id: "c", Score: 600, Categories: []string{""}},
+		{Id: "a", Score: 500, Categories: []string{""}},
+	})
+	assert.NoError(t, err)
+	// ordering by raw scores would be b, c, a
+	suite.Config.Recommend.Online.Mode = "blend"
+	suite.Config.Recommend.Online.BlendWeights = map[string]float64{"collaborative": 0.5, "popular": 0.5}
+	for _, method := range []string{"min_max", "z_score"} {
+		suite.Config.Recommend.Online.Normalization = method
+		apitest.New().
+			Handler(suite.handler).
+			Get("/api/recommend/0").
+			Header("X-API-Key", apiKey).
+			QueryParams(map[string]string{
+				"n": "3",
+			}).
+			Expect(t).
+			Status(http.StatusOK).
+			Body(suite.marshal([]string{"b", "a", "c"})).
+			End()
+	}
+}
+
 func (suite *ServerTestSuite) TestGetRecommendsWithMultiCategories() {
 	ctx := context.Background()
 	t := suite.T()
@@ -1356,16 +2727,16 @@ func (suite *ServerTestSuite) TestGetRecommendsFallbackUserBasedSimilar() {
 		{Id: "3", Score: 1, Categories: []string{""}},
 	})
 	assert.NoError(t, err)
-	err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
+	_, err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "1", ItemId: "11"}},
 	}, true, true, true)
 	assert.NoError(t, err)
-	err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
+	_, err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "2", ItemId: "12"}},
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "2", ItemId: "48"}},
 	}, true, true, true)
 	assert.NoError(t, err)
-	err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
+	_, err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "3", ItemId: "13"}},
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "3", ItemId: "48"}},
 	}, true, true, true)
@@ -80,6 +80,7 @@ func NewServer(
 			HttpHost:   serverHost,
 			HttpPort:   serverPort,
 			WebService: new(restful.WebService),
+			TLSConfig:  tlsConfig,
 		},
 	}
 	return s
@@ -140,6 +141,10 @@ func (s *Server) Shutdown() {
 	if err != nil {
 		log.Logger().Fatal("failed to shutdown http server", zap.Error(err))
 	}
+	if err = s.FlushExposure(context.TODO()); err != nil {
+		log.Logger().Error("failed to flush exposure", zap.Error(err))
+	}
+	s.CloseCustomScorers()
 }
 
 // Sync this server to the master.
@@ -175,7 +180,9 @@ func (s *Server) Sync() {
 			} else {
 				log.Logger().Info("connect data store",
 					zap.String("database", log.RedactDBURL(s.Config.Database.DataStore)))
-				if s.DataClient, err = data.Open(s.Config.Database.DataStore, s.Config.Database.DataTablePrefix); err != nil {
+				if s.DataClient, err = data.Open(s.Config.Database.DataStore, s.Config.Database.DataTablePrefix,
+					storage.WithFeedbackDedupWindow(s.Config.Database.FeedbackDedupWindow),
+					storage.WithUserLabelLimit(s.Config.Recommend.DataSource.MaxLabelsPerUser, s.Config.Recommend.DataSource.TruncateLabels)); err != nil {
 					log.Logger().Error("failed to connect data store", zap.Error(err))
 					goto sleep
 				}
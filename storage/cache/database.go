@@ -76,9 +76,37 @@ const (
 	//	Global item categories - item_categories
 	ItemCategories = "item_categories"
 
+	// FeedbackCount is the collection of the number of feedback of items with feedback type as subset.
+	FeedbackCount = "feedback_count"
+
+	// NoFeedbackItems is the set of items without any feedback. The format of key:
+	//	Items without feedback - no_feedback_items
+	NoFeedbackItems = "no_feedback_items"
+
+	// ItemBlocklist is the set of items never recommended. The format of key:
+	//	Blocklisted items - item_blocklist
+	ItemBlocklist = "item_blocklist"
+
+	// FrozenUsers is the set of users whose offline recommendations are not updated. The format of key:
+	//	Frozen users - frozen_users
+	FrozenUsers = "frozen_users"
+
+	// IdempotencyKey is the result of a processed request with an idempotency key. The format of key:
+	//	Result of processed request - idempotency_key/{key}
+	IdempotencyKey = "idempotency_key"
+
+	// IdempotencyLock is held while a request with an idempotency key is processed. The format of key:
+	//	Lock of request in progress - idempotency_lock/{key}
+	IdempotencyLock = "idempotency_lock"
+
+	// PurgeLock is the lock preventing concurrent purges. It is kept by Purge. The format of key:
+	//	Lock of purging data - purge_lock
+	PurgeLock = "purge_lock"
+
 	LastModifyItemTime          = "last_modify_item_time"           // the latest timestamp that a user related data was modified
 	LastModifyUserTime          = "last_modify_user_time"           // the latest timestamp that an item related data was modified
 	LastUpdateUserRecommendTime = "last_update_user_recommend_time" // the latest timestamp that a user's recommendation was updated
+	WriteBackRecommendTime      = "write_back_recommend_time"       // the latest timestamp that online recommendation was written back
 
 	// GlobalMeta is global meta information
 	GlobalMeta                 = "global_meta"
@@ -95,6 +123,7 @@ const (
 	LastUpdateLatestItemsTime  = "last_update_latest_items_time"  // the latest timestamp that latest items were updated
 	LastUpdatePopularItemsTime = "last_update_popular_items_time" // the latest timestamp that popular items were updated
 	MatchingIndexRecall        = "matching_index_recall"
+	RecommendationCoverage     = "recommendation_coverage" // fraction of users with offline recommendations
 )
 
 var ItemCache = []string{
@@ -127,6 +156,13 @@ func Key(keys ...string) string {
 type Value struct {
 	name  string
 	value string
+	ttl   time.Duration
+}
+
+// WithTTL returns the value which expires after ttl. The value never expires if ttl is zero.
+func (v Value) WithTTL(ttl time.Duration) Value {
+	v.ttl = ttl
+	return v
 }
 
 func String(name, value string) Value {
@@ -255,19 +291,31 @@ type TimeSeriesPoint struct {
 	Value     float64
 }
 
+// MemoryReporter is implemented by cache stores which report memory usage of collections.
+type MemoryReporter interface {
+	MemoryUsage(ctx context.Context, collection string) (int64, error)
+}
+
 // Database is the common interface for cache store.
 type Database interface {
 	Close() error
 	Ping() error
 	Init() error
 	Scan(work func(string) error) error
+	// Purge removes all data except the purge lock.
 	Purge() error
 
 	Set(ctx context.Context, values ...Value) error
 	Get(ctx context.Context, name string) *ReturnValue
 	Delete(ctx context.Context, name string) error
+	// TryLock acquires a lock owned by the token which expires after ttl. It returns false if the lock is held.
+	// The token should be unique, e.g. a UUID.
+	TryLock(ctx context.Context, name, token string, ttl time.Duration) (bool, error)
+	// Unlock releases a lock if it is still owned by the token.
+	Unlock(ctx context.Context, name, token string) error
 
 	GetSet(ctx context.Context, key string) ([]string, error)
+	GetSetPage(ctx context.Context, key, cursor string, n int) (string, []string, error)
 	SetSet(ctx context.Context, key string, members ...string) error
 	AddSet(ctx context.Context, key string, members ...string) error
 	RemSet(ctx context.Context, key string, members ...string) error
@@ -277,12 +325,24 @@ type Database interface {
 	Remain(ctx context.Context, name string) (int64, error)
 
 	AddScores(ctx context.Context, collection, subset string, documents []Score) error
+	// IncrScores adds scores of documents to existing documents atomically. Missing documents are created, and
+	// categories and timestamps of existing documents are overwritten.
+	IncrScores(ctx context.Context, collection, subset string, documents []Score) error
 	SearchScores(ctx context.Context, collection, subset string, query []string, begin, end int) ([]Score, error)
 	DeleteScores(ctx context.Context, collection []string, condition ScoreCondition) error
 	UpdateScores(ctx context.Context, collections []string, subset *string, id string, patch ScorePatch) error
+	CountScores(ctx context.Context, collection string) (int, error)
+	// CountSubsets counts subsets of a collection having visible documents.
+	CountSubsets(ctx context.Context, collection string) (int, error)
+	// AggregateScores counts documents in a subset and sums their scores.
+	AggregateScores(ctx context.Context, collection, subset string) (int, float64, error)
 
 	AddTimeSeriesPoints(ctx context.Context, points []TimeSeriesPoint) error
+	// IncrTimeSeriesPoints adds values of points to existing points atomically. Missing points are created.
+	IncrTimeSeriesPoints(ctx context.Context, points []TimeSeriesPoint) error
 	GetTimeSeriesPoints(ctx context.Context, name string, begin, end time.Time) ([]TimeSeriesPoint, error)
+	// DeleteTimeSeriesPoints removes points of a time series before a timestamp.
+	DeleteTimeSeriesPoints(ctx context.Context, name string, before time.Time) error
 }
 
 // Open a connection to a database.
@@ -141,6 +141,43 @@ func (suite *baseTestSuite) TestSet() {
 	suite.NoError(err)
 }
 
+func (suite *baseTestSuite) TestSetPage() {
+	ctx := context.Background()
+	var expected []string
+	for i := 0; i < 1000; i++ {
+		expected = append(expected, strconv.Itoa(i))
+	}
+	err := suite.Database.SetSet(ctx, "large_set", expected...)
+	suite.NoError(err)
+	// page through the set
+	var members []string
+	cursor := ""
+	for {
+		var page []string
+		cursor, page, err = suite.Database.GetSetPage(ctx, "large_set", cursor, 100)
+		suite.NoError(err)
+		members = append(members, page...)
+		if cursor == "" {
+			break
+		}
+	}
+	suite.Equal(len(expected), len(members))
+	suite.ElementsMatch(expected, members)
+	// pages are full until the last page
+	cursor, members, err = suite.Database.GetSetPage(ctx, "large_set", "", 300)
+	suite.NoError(err)
+	suite.NotEmpty(cursor)
+	suite.Len(members, 300)
+	// get page of empty set
+	cursor, members, err = suite.Database.GetSetPage(ctx, "unknown_set", "", 100)
+	suite.NoError(err)
+	suite.Empty(cursor)
+	suite.Empty(members)
+	// page size must be positive
+	_, _, err = suite.Database.GetSetPage(ctx, "large_set", "", 0)
+	suite.ErrorIs(err, errors.NotValid)
+}
+
 func (suite *baseTestSuite) TestScan() {
 	ctx := context.Background()
 	err := suite.Database.Set(ctx, String("1", "1"))
@@ -172,11 +209,18 @@ func (suite *baseTestSuite) TestPurge() {
 	suite.NoError(err)
 	suite.ElementsMatch([]string{"a", "b", "c"}, s)
 
+	locked, err := suite.Database.TryLock(ctx, PurgeLock, "token", time.Minute)
+	suite.NoError(err)
+	suite.True(locked)
+
 	// purge data
 	err = suite.Database.Purge()
 	suite.NoError(err)
 	ret = suite.Database.Get(ctx, "key")
 	suite.ErrorIs(ret.err, errors.NotFound)
+	locked, err = suite.Database.TryLock(ctx, PurgeLock, "token", time.Minute)
+	suite.NoError(err)
+	suite.False(locked)
 	s, err = suite.Database.GetSet(ctx, "set")
 	suite.NoError(err)
 	suite.Empty(s)
@@ -356,6 +400,136 @@ func (suite *baseTestSuite) TestDocument() {
 	suite.Equal("0", documents[0].Id)
 }
 
+func (suite *baseTestSuite) TestCountScores() {
+	ctx := context.Background()
+	err := suite.AddScores(ctx, "a", "0", []Score{{Id: "1", Score: 1}, {Id: "2", Score: 2, IsHidden: true}})
+	suite.NoError(err)
+	err = suite.AddScores(ctx, "a", "1", []Score{{Id: "1", Score: 1}})
+	suite.NoError(err)
+	err = suite.AddScores(ctx, "b", "0", []Score{{Id: "1", Score: 1}})
+	suite.NoError(err)
+	count, err := suite.CountScores(ctx, "a")
+	suite.NoError(err)
+	suite.Equal(3, count)
+	count, err = suite.CountScores(ctx, "b")
+	suite.NoError(err)
+	suite.Equal(1, count)
+	count, err = suite.CountScores(ctx, "c")
+	suite.NoError(err)
+	suite.Equal(0, count)
+}
+
+func (suite *baseTestSuite) TestCountSubsets() {
+	ctx := context.Background()
+	err := suite.AddScores(ctx, "a", "0", []Score{{Id: "1", Score: 1}, {Id: "2", Score: 2}})
+	suite.NoError(err)
+	err = suite.AddScores(ctx, "a", "1", []Score{{Id: "1", Score: 1, IsHidden: true}})
+	suite.NoError(err)
+	err = suite.AddScores(ctx, "a", "2", []Score{{Id: "1", Score: 1}})
+	suite.NoError(err)
+	count, err := suite.CountSubsets(ctx, "a")
+	suite.NoError(err)
+	suite.Equal(2, count)
+	count, err = suite.CountSubsets(ctx, "b")
+	suite.NoError(err)
+	suite.Equal(0, count)
+}
+
+func (suite *baseTestSuite) TestIncrScores() {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	err := suite.IncrScores(ctx, "a", "0", []Score{
+		{Id: "1", Score: 1, Categories: []string{""}, Timestamp: ts},
+		{Id: "2", Score: 2, Categories: []string{""}, Timestamp: ts},
+	})
+	suite.NoError(err)
+	err = suite.IncrScores(ctx, "a", "0", []Score{{Id: "1", Score: 3, Categories: []string{"", "x"}, Timestamp: ts.Add(time.Minute)}})
+	suite.NoError(err)
+	documents, err := suite.SearchScores(ctx, "a", "0", []string{""}, 0, -1)
+	suite.NoError(err)
+	suite.Equal([]Score{
+		{Id: "1", Score: 4, Categories: []string{"", "x"}, Timestamp: ts.Add(time.Minute)},
+		{Id: "2", Score: 2, Categories: []string{""}, Timestamp: ts},
+	}, documents)
+}
+
+func (suite *baseTestSuite) TestAggregateScores() {
+	ctx := context.Background()
+	err := suite.AddScores(ctx, "a", "0", []Score{{Id: "1", Score: 1}, {Id: "2", Score: 2}})
+	suite.NoError(err)
+	err = suite.AddScores(ctx, "a", "1", []Score{{Id: "1", Score: 4}})
+	suite.NoError(err)
+	count, sum, err := suite.AggregateScores(ctx, "a", "0")
+	suite.NoError(err)
+	suite.Equal(2, count)
+	suite.Equal(3.0, sum)
+	count, sum, err = suite.AggregateScores(ctx, "a", "2")
+	suite.NoError(err)
+	suite.Zero(count)
+	suite.Zero(sum)
+}
+
+func (suite *baseTestSuite) TestExpire() {
+	ctx := context.Background()
+	err := suite.Database.Set(ctx, String("expire", "1").WithTTL(time.Second), String("persist", "2"))
+	suite.NoError(err)
+	value, err := suite.Database.Get(ctx, "expire").String()
+	suite.NoError(err)
+	suite.Equal("1", value)
+	time.Sleep(1100 * time.Millisecond)
+	_, err = suite.Database.Get(ctx, "expire").String()
+	suite.True(errors.Is(err, errors.NotFound), err)
+	value, err = suite.Database.Get(ctx, "persist").String()
+	suite.NoError(err)
+	suite.Equal("2", value)
+	// overwrite without TTL
+	err = suite.Database.Set(ctx, String("expire", "3").WithTTL(time.Second))
+	suite.NoError(err)
+	err = suite.Database.Set(ctx, String("expire", "4"))
+	suite.NoError(err)
+	time.Sleep(1100 * time.Millisecond)
+	value, err = suite.Database.Get(ctx, "expire").String()
+	suite.NoError(err)
+	suite.Equal("4", value)
+}
+
+func (suite *baseTestSuite) TestTryLock() {
+	ctx := context.Background()
+	// acquire lock
+	locked, err := suite.TryLock(ctx, "lock", "a", time.Minute)
+	suite.NoError(err)
+	suite.True(locked)
+	locked, err = suite.TryLock(ctx, "lock", "b", time.Minute)
+	suite.NoError(err)
+	suite.False(locked)
+	// release lock owned by another token
+	err = suite.Unlock(ctx, "lock", "b")
+	suite.NoError(err)
+	locked, err = suite.TryLock(ctx, "lock", "b", time.Minute)
+	suite.NoError(err)
+	suite.False(locked)
+	// release lock
+	err = suite.Unlock(ctx, "lock", "a")
+	suite.NoError(err)
+	locked, err = suite.TryLock(ctx, "lock", "b", time.Minute)
+	suite.NoError(err)
+	suite.True(locked)
+	// acquire expired lock
+	locked, err = suite.TryLock(ctx, "expired", "a", time.Second)
+	suite.NoError(err)
+	suite.True(locked)
+	time.Sleep(1100 * time.Millisecond)
+	locked, err = suite.TryLock(ctx, "expired", "b", time.Second)
+	suite.NoError(err)
+	suite.True(locked)
+	// expired lock is not released by the previous owner
+	err = suite.Unlock(ctx, "expired", "a")
+	suite.NoError(err)
+	locked, err = suite.TryLock(ctx, "expired", "c", time.Second)
+	suite.NoError(err)
+	suite.False(locked)
+}
+
 func (suite *baseTestSuite) TestSubsetDocument() {
 	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
 	ctx := context.Background()
@@ -482,6 +656,44 @@ func (suite *baseTestSuite) TestTimeSeries() {
 	}, points)
 }
 
+func (suite *baseTestSuite) TestDeleteTimeSeries() {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	err := suite.AddTimeSeriesPoints(ctx, []TimeSeriesPoint{
+		{Name: "delete", Value: 1, Timestamp: ts.Add(1 * time.Second)},
+		{Name: "delete", Value: 2, Timestamp: ts.Add(2 * time.Second)},
+		{Name: "delete", Value: 3, Timestamp: ts.Add(3 * time.Second)},
+		{Name: "keep", Value: 1, Timestamp: ts.Add(1 * time.Second)}})
+	suite.NoError(err)
+	err = suite.DeleteTimeSeriesPoints(ctx, "delete", ts.Add(3*time.Second))
+	suite.NoError(err)
+
+	points, err := suite.GetTimeSeriesPoints(ctx, "delete", ts, ts.Add(time.Minute))
+	suite.NoError(err)
+	suite.Equal([]TimeSeriesPoint{{Name: "delete", Value: 3, Timestamp: ts.Add(3 * time.Second)}}, points)
+	points, err = suite.GetTimeSeriesPoints(ctx, "keep", ts, ts.Add(time.Minute))
+	suite.NoError(err)
+	suite.Len(points, 1)
+}
+
+func (suite *baseTestSuite) TestIncrTimeSeries() {
+	ts := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	err := suite.IncrTimeSeriesPoints(ctx, []TimeSeriesPoint{
+		{Name: "incr", Value: 1, Timestamp: ts},
+		{Name: "incr", Value: 2, Timestamp: ts.Add(time.Minute)}})
+	suite.NoError(err)
+	err = suite.IncrTimeSeriesPoints(ctx, []TimeSeriesPoint{{Name: "incr", Value: 3, Timestamp: ts}})
+	suite.NoError(err)
+
+	points, err := suite.GetTimeSeriesPoints(ctx, "incr", ts, ts.Add(2*time.Minute))
+	suite.NoError(err)
+	suite.Equal([]TimeSeriesPoint{
+		{Name: "incr", Value: 4, Timestamp: ts},
+		{Name: "incr", Value: 2, Timestamp: ts.Add(time.Minute)},
+	}, points)
+}
+
 func TestKey(t *testing.T) {
 	assert.Empty(t, Key())
 	assert.Equal(t, "a", Key("a"))
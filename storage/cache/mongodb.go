@@ -17,6 +17,7 @@ package cache
 import (
 	"context"
 	"io"
+	"strconv"
 	"time"
 
 	"github.com/juju/errors"
@@ -60,6 +61,14 @@ func (m MongoDB) Init() error {
 			return errors.Trace(err)
 		}
 	}
+	// remove expired values
+	_, err = d.Collection(m.ValuesTable()).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"expire_at": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
 	// create index
 	_, err = d.Collection(m.SetsTable()).Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys: bson.D{
@@ -179,7 +188,12 @@ func (m MongoDB) Scan(work func(string) error) error {
 }
 
 func (m MongoDB) Purge() error {
-	tables := []string{m.ValuesTable(), m.SetsTable(), m.DocumentTable()}
+	_, err := m.client.Database(m.dbName).Collection(m.ValuesTable()).
+		DeleteMany(context.Background(), bson.M{"_id": bson.M{"$ne": PurgeLock}})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	tables := []string{m.SetsTable(), m.DocumentTable()}
 	for _, tableName := range tables {
 		c := m.client.Database(m.dbName).Collection(tableName)
 		_, err := c.DeleteMany(context.Background(), bson.D{})
@@ -197,10 +211,14 @@ func (m MongoDB) Set(ctx context.Context, values ...Value) error {
 	c := m.client.Database(m.dbName).Collection(m.ValuesTable())
 	var models []mongo.WriteModel
 	for _, value := range values {
+		update := bson.M{"$set": bson.M{"_id": value.name, "value": value.value}, "$unset": bson.M{"expire_at": ""}}
+		if value.ttl > 0 {
+			update = bson.M{"$set": bson.M{"_id": value.name, "value": value.value, "expire_at": time.Now().Add(value.ttl)}}
+		}
 		models = append(models, mongo.NewUpdateOneModel().
 			SetUpsert(true).
 			SetFilter(bson.M{"_id": value.name}).
-			SetUpdate(bson.M{"$set": bson.M{"_id": value.name, "value": value.value}}))
+			SetUpdate(update))
 	}
 	_, err := c.BulkWrite(ctx, models)
 	return errors.Trace(err)
@@ -208,7 +226,13 @@ func (m MongoDB) Set(ctx context.Context, values ...Value) error {
 
 func (m MongoDB) Get(ctx context.Context, name string) *ReturnValue {
 	c := m.client.Database(m.dbName).Collection(m.ValuesTable())
-	r := c.FindOne(ctx, bson.M{"_id": bson.M{"$eq": name}})
+	r := c.FindOne(ctx, bson.M{
+		"_id": bson.M{"$eq": name},
+		"$or": bson.A{
+			bson.M{"expire_at": bson.M{"$exists": false}},
+			bson.M{"expire_at": bson.M{"$gt": time.Now()}},
+		},
+	})
 	if err := r.Err(); err == mongo.ErrNoDocuments {
 		return &ReturnValue{err: errors.Annotate(ErrObjectNotExist, name)}
 	} else if err != nil {
@@ -227,6 +251,31 @@ func (m MongoDB) Delete(ctx context.Context, name string) error {
 	return errors.Trace(err)
 }
 
+// TryLock acquires a lock in MongoDB. The token is stored as the value.
+func (m MongoDB) TryLock(ctx context.Context, name, token string, ttl time.Duration) (bool, error) {
+	c := m.client.Database(m.dbName).Collection(m.ValuesTable())
+	now := time.Now()
+	// remove expired lock
+	_, err := c.DeleteOne(ctx, bson.M{"_id": name, "expire_at": bson.M{"$lt": now}})
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	_, err = c.InsertOne(ctx, bson.M{"_id": name, "value": token, "expire_at": now.Add(ttl)})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	} else if err != nil {
+		return false, errors.Trace(err)
+	}
+	return true, nil
+}
+
+// Unlock releases a lock in MongoDB if the value equals the token.
+func (m MongoDB) Unlock(ctx context.Context, name, token string) error {
+	c := m.client.Database(m.dbName).Collection(m.ValuesTable())
+	_, err := c.DeleteOne(ctx, bson.M{"_id": name, "value": token})
+	return errors.Trace(err)
+}
+
 func (m MongoDB) GetSet(ctx context.Context, name string) ([]string, error) {
 	c := m.client.Database(m.dbName).Collection(m.SetsTable())
 	r, err := c.Find(ctx, bson.M{"name": name})
@@ -244,6 +293,34 @@ func (m MongoDB) GetSet(ctx context.Context, name string) ([]string, error) {
 	return members, nil
 }
 
+// GetSetPage returns a page of members of a set sorted by member. The cursor is the last member of the previous page
+// and the returned cursor is empty if there are no more members.
+func (m MongoDB) GetSetPage(ctx context.Context, name, cursor string, n int) (string, []string, error) {
+	if n <= 0 {
+		return "", nil, errors.NotValidf("page size %d", n)
+	}
+	c := m.client.Database(m.dbName).Collection(m.SetsTable())
+	opt := options.Find()
+	opt.SetSort(bson.M{"member": 1})
+	opt.SetLimit(int64(n))
+	r, err := c.Find(ctx, bson.M{"name": name, "member": bson.M{"$gt": cursor}}, opt)
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	var members []string
+	for r.Next(ctx) {
+		var doc bson.Raw
+		if err = r.Decode(&doc); err != nil {
+			return "", nil, err
+		}
+		members = append(members, doc.Lookup("member").StringValue())
+	}
+	if len(members) == 0 || len(members) < n {
+		return "", members, nil
+	}
+	return members[len(members)-1], members, nil
+}
+
 func (m MongoDB) SetSet(ctx context.Context, name string, members ...string) error {
 	c := m.client.Database(m.dbName).Collection(m.SetsTable())
 	var models []mongo.WriteModel
@@ -341,6 +418,33 @@ func (m MongoDB) AddScores(ctx context.Context, collection, subset string, docum
 	return errors.Trace(err)
 }
 
+// IncrScores adds scores of documents by $inc in a bulk write.
+func (m MongoDB) IncrScores(ctx context.Context, collection, subset string, documents []Score) error {
+	if len(documents) == 0 {
+		return nil
+	}
+	var models []mongo.WriteModel
+	for _, document := range documents {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetUpsert(true).
+			SetFilter(bson.M{
+				"collection": collection,
+				"subset":     subset,
+				"id":         document.Id,
+			}).
+			SetUpdate(bson.M{
+				"$inc": bson.M{"score": document.Score},
+				"$set": bson.M{
+					"categories": document.Categories,
+					"timestamp":  document.Timestamp,
+				},
+				"$setOnInsert": bson.M{"is_hidden": document.IsHidden},
+			}))
+	}
+	_, err := m.client.Database(m.dbName).Collection(m.DocumentTable()).BulkWrite(ctx, models)
+	return errors.Trace(err)
+}
+
 func (m MongoDB) SearchScores(ctx context.Context, collection, subset string, query []string, begin, end int) ([]Score, error) {
 	opt := options.Find().SetSkip(int64(begin)).SetSort(bson.M{"score": -1})
 	if end != -1 {
@@ -369,6 +473,59 @@ func (m MongoDB) SearchScores(ctx context.Context, collection, subset string, qu
 	return documents, nil
 }
 
+// CountScores counts documents in a collection, including hidden documents.
+func (m MongoDB) CountScores(ctx context.Context, collection string) (int, error) {
+	count, err := m.client.Database(m.dbName).Collection(m.DocumentTable()).CountDocuments(ctx, bson.M{"collection": collection})
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return int(count), nil
+}
+
+// CountSubsets counts subsets of a collection having visible documents.
+func (m MongoDB) CountSubsets(ctx context.Context, collection string) (int, error) {
+	cur, err := m.client.Database(m.dbName).Collection(m.DocumentTable()).Aggregate(ctx, mongo.Pipeline{
+		{{"$match", bson.M{"collection": collection, "is_hidden": false}}},
+		{{"$group", bson.M{"_id": "$subset"}}},
+		{{"$count", "count"}},
+	})
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	defer cur.Close(ctx)
+	var result struct {
+		Count int `bson:"count"`
+	}
+	if cur.Next(ctx) {
+		if err = cur.Decode(&result); err != nil {
+			return 0, errors.Trace(err)
+		}
+	}
+	return result.Count, errors.Trace(cur.Err())
+}
+
+// AggregateScores counts documents in a subset and sums their scores.
+func (m MongoDB) AggregateScores(ctx context.Context, collection, subset string) (int, float64, error) {
+	cur, err := m.client.Database(m.dbName).Collection(m.DocumentTable()).Aggregate(ctx, mongo.Pipeline{
+		{{"$match", bson.M{"collection": collection, "subset": subset}}},
+		{{"$group", bson.M{"_id": nil, "count": bson.M{"$sum": 1}, "sum": bson.M{"$sum": "$score"}}}},
+	})
+	if err != nil {
+		return 0, 0, errors.Trace(err)
+	}
+	defer cur.Close(ctx)
+	var result struct {
+		Count int     `bson:"count"`
+		Sum   float64 `bson:"sum"`
+	}
+	if cur.Next(ctx) {
+		if err = cur.Decode(&result); err != nil {
+			return 0, 0, errors.Trace(err)
+		}
+	}
+	return result.Count, result.Sum, errors.Trace(cur.Err())
+}
+
 func (m MongoDB) UpdateScores(ctx context.Context, collections []string, subset *string, id string, patch ScorePatch) error {
 	if len(collections) == 0 {
 		return nil
@@ -432,6 +589,26 @@ func (m MongoDB) AddTimeSeriesPoints(ctx context.Context, points []TimeSeriesPoi
 	return errors.Trace(err)
 }
 
+func (m MongoDB) IncrTimeSeriesPoints(ctx context.Context, points []TimeSeriesPoint) error {
+	if len(points) == 0 {
+		return nil
+	}
+	var models []mongo.WriteModel
+	for _, point := range points {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetUpsert(true).
+			SetFilter(bson.M{
+				"name":      point.Name,
+				"timestamp": point.Timestamp,
+			}).
+			SetUpdate(bson.M{"$inc": bson.M{
+				"value": point.Value,
+			}}))
+	}
+	_, err := m.client.Database(m.dbName).Collection(m.PointsTable()).BulkWrite(ctx, models)
+	return errors.Trace(err)
+}
+
 func (m MongoDB) GetTimeSeriesPoints(ctx context.Context, name string, begin, end time.Time) ([]TimeSeriesPoint, error) {
 	cur, err := m.client.Database(m.dbName).Collection(m.PointsTable()).Find(ctx, bson.M{
 		"name":      name,
@@ -450,3 +627,11 @@ func (m MongoDB) GetTimeSeriesPoints(ctx context.Context, name string, begin, en
 	}
 	return points, nil
 }
+
+func (m MongoDB) DeleteTimeSeriesPoints(ctx context.Context, name string, before time.Time) error {
+	_, err := m.client.Database(m.dbName).Collection(m.PointsTable()).DeleteMany(ctx, bson.M{
+		"name":      name,
+		"timestamp": bson.M{"$lt": before},
+	})
+	return errors.Trace(err)
+}
@@ -58,11 +58,26 @@ func (NoDatabase) Delete(_ context.Context, _ string) error {
 	return ErrNoDatabase
 }
 
+// TryLock method of NoDatabase returns ErrNoDatabase.
+func (NoDatabase) TryLock(_ context.Context, _, _ string, _ time.Duration) (bool, error) {
+	return false, ErrNoDatabase
+}
+
+// Unlock method of NoDatabase returns ErrNoDatabase.
+func (NoDatabase) Unlock(_ context.Context, _, _ string) error {
+	return ErrNoDatabase
+}
+
 // GetSet method of NoDatabase returns ErrNoDatabase.
 func (NoDatabase) GetSet(_ context.Context, _ string) ([]string, error) {
 	return nil, ErrNoDatabase
 }
 
+// GetSetPage method of NoDatabase returns ErrNoDatabase.
+func (NoDatabase) GetSetPage(_ context.Context, _, _ string, _ int) (string, []string, error) {
+	return "", nil, ErrNoDatabase
+}
+
 // SetSet method of NoDatabase returns ErrNoDatabase.
 func (NoDatabase) SetSet(_ context.Context, _ string, _ ...string) error {
 	return ErrNoDatabase
@@ -94,10 +109,26 @@ func (NoDatabase) AddScores(_ context.Context, _, _ string, _ []Score) error {
 	return ErrNoDatabase
 }
 
+func (NoDatabase) IncrScores(_ context.Context, _, _ string, _ []Score) error {
+	return ErrNoDatabase
+}
+
 func (NoDatabase) SearchScores(_ context.Context, _, _ string, _ []string, _, _ int) ([]Score, error) {
 	return nil, ErrNoDatabase
 }
 
+func (NoDatabase) CountScores(_ context.Context, _ string) (int, error) {
+	return 0, ErrNoDatabase
+}
+
+func (NoDatabase) CountSubsets(_ context.Context, _ string) (int, error) {
+	return 0, ErrNoDatabase
+}
+
+func (NoDatabase) AggregateScores(_ context.Context, _, _ string) (int, float64, error) {
+	return 0, 0, ErrNoDatabase
+}
+
 func (NoDatabase) UpdateScores(context.Context, []string, *string, string, ScorePatch) error {
 	return ErrNoDatabase
 }
@@ -110,6 +141,14 @@ func (NoDatabase) AddTimeSeriesPoints(_ context.Context, _ []TimeSeriesPoint) er
 	return ErrNoDatabase
 }
 
+func (NoDatabase) IncrTimeSeriesPoints(_ context.Context, _ []TimeSeriesPoint) error {
+	return ErrNoDatabase
+}
+
 func (NoDatabase) GetTimeSeriesPoints(_ context.Context, _ string, _, _ time.Time) ([]TimeSeriesPoint, error) {
 	return nil, ErrNoDatabase
 }
+
+func (NoDatabase) DeleteTimeSeriesPoints(_ context.Context, _ string, _ time.Time) error {
+	return ErrNoDatabase
+}
@@ -45,9 +45,15 @@ func TestNoDatabase(t *testing.T) {
 	assert.ErrorIs(t, err, ErrNoDatabase)
 	err = database.Delete(ctx, Key("", ""))
 	assert.ErrorIs(t, err, ErrNoDatabase)
+	_, err = database.TryLock(ctx, "", "", 0)
+	assert.ErrorIs(t, err, ErrNoDatabase)
+	err = database.Unlock(ctx, "", "")
+	assert.ErrorIs(t, err, ErrNoDatabase)
 
 	_, err = database.GetSet(ctx, "")
 	assert.ErrorIs(t, err, ErrNoDatabase)
+	_, _, err = database.GetSetPage(ctx, "", "", 0)
+	assert.ErrorIs(t, err, ErrNoDatabase)
 	err = database.SetSet(ctx, "")
 	assert.ErrorIs(t, err, ErrNoDatabase)
 	err = database.AddSet(ctx, "")
@@ -64,8 +70,16 @@ func TestNoDatabase(t *testing.T) {
 
 	err = database.AddScores(ctx, "", "", nil)
 	assert.ErrorIs(t, err, ErrNoDatabase)
+	err = database.IncrScores(ctx, "", "", nil)
+	assert.ErrorIs(t, err, ErrNoDatabase)
 	_, err = database.SearchScores(ctx, "", "", nil, 0, 0)
 	assert.ErrorIs(t, err, ErrNoDatabase)
+	_, err = database.CountScores(ctx, "")
+	assert.ErrorIs(t, err, ErrNoDatabase)
+	_, err = database.CountSubsets(ctx, "")
+	assert.ErrorIs(t, err, ErrNoDatabase)
+	_, _, err = database.AggregateScores(ctx, "", "")
+	assert.ErrorIs(t, err, ErrNoDatabase)
 	err = database.UpdateScores(ctx, nil, nil, "", ScorePatch{})
 	assert.ErrorIs(t, err, ErrNoDatabase)
 	err = database.DeleteScores(ctx, nil, ScoreCondition{})
@@ -73,6 +87,10 @@ func TestNoDatabase(t *testing.T) {
 
 	err = database.AddTimeSeriesPoints(ctx, nil)
 	assert.ErrorIs(t, err, ErrNoDatabase)
+	err = database.IncrTimeSeriesPoints(ctx, nil)
+	assert.ErrorIs(t, err, ErrNoDatabase)
 	_, err = database.GetTimeSeriesPoints(ctx, "", time.Time{}, time.Time{})
 	assert.ErrorIs(t, err, ErrNoDatabase)
+	err = database.DeleteTimeSeriesPoints(ctx, "", time.Time{})
+	assert.ErrorIs(t, err, ErrNoDatabase)
 }
@@ -24,6 +24,7 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"io"
 	"net"
+	"sort"
 	"time"
 )
 
@@ -65,6 +66,7 @@ func (p *ProxyServer) Set(ctx context.Context, request *protocol.SetRequest) (*p
 		values[i] = Value{
 			name:  value.GetName(),
 			value: value.GetValue(),
+			ttl:   time.Duration(value.GetTtl()),
 		}
 	}
 	return &protocol.SetResponse{}, p.database.Set(ctx, values...)
@@ -74,6 +76,15 @@ func (p *ProxyServer) Delete(ctx context.Context, request *protocol.DeleteReques
 	return &protocol.DeleteResponse{}, p.database.Delete(ctx, request.GetName())
 }
 
+func (p *ProxyServer) TryLock(ctx context.Context, request *protocol.TryLockRequest) (*protocol.TryLockResponse, error) {
+	locked, err := p.database.TryLock(ctx, request.GetName(), request.GetToken(), time.Duration(request.GetTtl()))
+	return &protocol.TryLockResponse{Locked: locked}, err
+}
+
+func (p *ProxyServer) Unlock(ctx context.Context, request *protocol.UnlockRequest) (*protocol.UnlockResponse, error) {
+	return &protocol.UnlockResponse{}, p.database.Unlock(ctx, request.GetName(), request.GetToken())
+}
+
 func (p *ProxyServer) GetSet(ctx context.Context, request *protocol.GetSetRequest) (*protocol.GetSetResponse, error) {
 	members, err := p.database.GetSet(ctx, request.GetKey())
 	if err != nil {
@@ -131,6 +142,20 @@ func (p *ProxyServer) AddScores(ctx context.Context, request *protocol.AddScores
 	return &protocol.AddScoresResponse{}, p.database.AddScores(ctx, request.GetCollection(), request.GetSubset(), scores)
 }
 
+func (p *ProxyServer) IncrScores(ctx context.Context, request *protocol.AddScoresRequest) (*protocol.AddScoresResponse, error) {
+	scores := make([]Score, len(request.Documents))
+	for i, doc := range request.Documents {
+		scores[i] = Score{
+			Id:         doc.GetId(),
+			Score:      doc.GetScore(),
+			IsHidden:   doc.GetIsHidden(),
+			Categories: doc.GetCategories(),
+			Timestamp:  doc.GetTimestamp().AsTime(),
+		}
+	}
+	return &protocol.AddScoresResponse{}, p.database.IncrScores(ctx, request.GetCollection(), request.GetSubset(), scores)
+}
+
 func (p *ProxyServer) SearchScores(ctx context.Context, request *protocol.SearchScoresRequest) (*protocol.SearchScoresResponse, error) {
 	resp, err := p.database.SearchScores(ctx, request.GetCollection(), request.GetSubset(), request.GetQuery(), int(request.GetBegin()), int(request.GetEnd()))
 	if err != nil {
@@ -181,6 +206,18 @@ func (p *ProxyServer) AddTimeSeriesPoints(ctx context.Context, request *protocol
 	return &protocol.AddTimeSeriesPointsResponse{}, p.database.AddTimeSeriesPoints(ctx, points)
 }
 
+func (p *ProxyServer) IncrTimeSeriesPoints(ctx context.Context, request *protocol.AddTimeSeriesPointsRequest) (*protocol.AddTimeSeriesPointsResponse, error) {
+	points := make([]TimeSeriesPoint, len(request.Points))
+	for i, point := range request.Points {
+		points[i] = TimeSeriesPoint{
+			Name:      point.Name,
+			Timestamp: point.Timestamp.AsTime(),
+			Value:     point.Value,
+		}
+	}
+	return &protocol.AddTimeSeriesPointsResponse{}, p.database.IncrTimeSeriesPoints(ctx, points)
+}
+
 func (p *ProxyServer) GetTimeSeriesPoints(ctx context.Context, request *protocol.GetTimeSeriesPointsRequest) (*protocol.GetTimeSeriesPointsResponse, error) {
 	resp, err := p.database.GetTimeSeriesPoints(ctx, request.GetName(), request.GetBegin().AsTime(), request.GetEnd().AsTime())
 	if err != nil {
@@ -197,6 +234,10 @@ func (p *ProxyServer) GetTimeSeriesPoints(ctx context.Context, request *protocol
 	return &protocol.GetTimeSeriesPointsResponse{Points: points}, nil
 }
 
+func (p *ProxyServer) DeleteTimeSeriesPoints(ctx context.Context, request *protocol.DeleteTimeSeriesPointsRequest) (*protocol.DeleteTimeSeriesPointsResponse, error) {
+	return &protocol.DeleteTimeSeriesPointsResponse{}, p.database.DeleteTimeSeriesPoints(ctx, request.GetName(), request.GetBefore().AsTime())
+}
+
 type ProxyClient struct {
 	protocol.CacheStoreClient
 }
@@ -222,12 +263,25 @@ func (p ProxyClient) Purge() error {
 	return errors.MethodNotAllowedf("purge is not allowed in proxy client")
 }
 
+func (p ProxyClient) CountScores(_ context.Context, _ string) (int, error) {
+	return 0, errors.MethodNotAllowedf("count scores is not allowed in proxy client")
+}
+
+func (p ProxyClient) CountSubsets(_ context.Context, _ string) (int, error) {
+	return 0, errors.MethodNotAllowedf("count subsets is not allowed in proxy client")
+}
+
+func (p ProxyClient) AggregateScores(_ context.Context, _, _ string) (int, float64, error) {
+	return 0, 0, errors.MethodNotAllowedf("aggregate scores is not allowed in proxy client")
+}
+
 func (p ProxyClient) Set(ctx context.Context, values ...Value) error {
 	pbValues := make([]*protocol.Value, len(values))
 	for i, value := range values {
 		pbValues[i] = &protocol.Value{
 			Name:  value.name,
 			Value: value.value,
+			Ttl:   int64(value.ttl),
 		}
 	}
 	_, err := p.CacheStoreClient.Set(ctx, &protocol.SetRequest{
@@ -256,6 +310,26 @@ func (p ProxyClient) Delete(ctx context.Context, name string) error {
 	return err
 }
 
+func (p ProxyClient) TryLock(ctx context.Context, name, token string, ttl time.Duration) (bool, error) {
+	resp, err := p.CacheStoreClient.TryLock(ctx, &protocol.TryLockRequest{
+		Name:  name,
+		Token: token,
+		Ttl:   int64(ttl),
+	})
+	if err != nil {
+		return false, err
+	}
+	return resp.Locked, nil
+}
+
+func (p ProxyClient) Unlock(ctx context.Context, name, token string) error {
+	_, err := p.CacheStoreClient.Unlock(ctx, &protocol.UnlockRequest{
+		Name:  name,
+		Token: token,
+	})
+	return err
+}
+
 func (p ProxyClient) GetSet(ctx context.Context, key string) ([]string, error) {
 	resp, err := p.CacheStoreClient.GetSet(ctx, &protocol.GetSetRequest{
 		Key: key,
@@ -266,6 +340,25 @@ func (p ProxyClient) GetSet(ctx context.Context, key string) ([]string, error) {
 	return resp.Members, nil
 }
 
+// GetSetPage of the proxy client fetches the whole set and returns a page of members sorted by member.
+func (p ProxyClient) GetSetPage(ctx context.Context, key, cursor string, n int) (string, []string, error) {
+	if n <= 0 {
+		return "", nil, errors.NotValidf("page size %d", n)
+	}
+	members, err := p.GetSet(ctx, key)
+	if err != nil {
+		return "", nil, err
+	}
+	sort.Strings(members)
+	members = lo.Filter(members, func(member string, _ int) bool {
+		return member > cursor
+	})
+	if len(members) <= n {
+		return "", members, nil
+	}
+	return members[n-1], members[:n], nil
+}
+
 func (p ProxyClient) SetSet(ctx context.Context, key string, members ...string) error {
 	_, err := p.CacheStoreClient.SetSet(ctx, &protocol.SetSetRequest{
 		Key:     key,
@@ -340,6 +433,25 @@ func (p ProxyClient) AddScores(ctx context.Context, collection, subset string, d
 	return err
 }
 
+func (p ProxyClient) IncrScores(ctx context.Context, collection, subset string, documents []Score) error {
+	scores := make([]*protocol.Score, len(documents))
+	for i, doc := range documents {
+		scores[i] = &protocol.Score{
+			Id:         doc.Id,
+			Score:      doc.Score,
+			IsHidden:   doc.IsHidden,
+			Categories: doc.Categories,
+			Timestamp:  timestamppb.New(doc.Timestamp),
+		}
+	}
+	_, err := p.CacheStoreClient.IncrScores(ctx, &protocol.AddScoresRequest{
+		Collection: collection,
+		Subset:     subset,
+		Documents:  scores,
+	})
+	return err
+}
+
 func (p ProxyClient) SearchScores(ctx context.Context, collection, subset string, query []string, begin, end int) ([]Score, error) {
 	resp, err := p.CacheStoreClient.SearchScores(ctx, &protocol.SearchScoresRequest{
 		Collection: collection,
@@ -412,6 +524,21 @@ func (p ProxyClient) AddTimeSeriesPoints(ctx context.Context, points []TimeSerie
 	return err
 }
 
+func (p ProxyClient) IncrTimeSeriesPoints(ctx context.Context, points []TimeSeriesPoint) error {
+	pbPoints := make([]*protocol.TimeSeriesPoint, len(points))
+	for i, point := range points {
+		pbPoints[i] = &protocol.TimeSeriesPoint{
+			Name:      point.Name,
+			Timestamp: timestamppb.New(point.Timestamp),
+			Value:     point.Value,
+		}
+	}
+	_, err := p.CacheStoreClient.IncrTimeSeriesPoints(ctx, &protocol.AddTimeSeriesPointsRequest{
+		Points: pbPoints,
+	})
+	return err
+}
+
 func (p ProxyClient) GetTimeSeriesPoints(ctx context.Context, name string, begin, end time.Time) ([]TimeSeriesPoint, error) {
 	resp, err := p.CacheStoreClient.GetTimeSeriesPoints(ctx, &protocol.GetTimeSeriesPointsRequest{
 		Name:  name,
@@ -432,6 +559,14 @@ func (p ProxyClient) GetTimeSeriesPoints(ctx context.Context, name string, begin
 	return points, nil
 }
 
+func (p ProxyClient) DeleteTimeSeriesPoints(ctx context.Context, name string, before time.Time) error {
+	_, err := p.CacheStoreClient.DeleteTimeSeriesPoints(ctx, &protocol.DeleteTimeSeriesPointsRequest{
+		Name:   name,
+		Before: timestamppb.New(before),
+	})
+	return err
+}
+
 func NewProxyClient(conn *grpc.ClientConn) *ProxyClient {
 	return &ProxyClient{
 		CacheStoreClient: protocol.NewCacheStoreClient(conn),
@@ -21,6 +21,7 @@ import (
 	"io"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/juju/errors"
@@ -141,6 +142,7 @@ func (r *Redis) purge(ctx context.Context, client redis.UniversalClient, isClust
 		if err != nil {
 			return errors.Trace(err)
 		}
+		result = lo.Without(result, r.Key(PurgeLock))
 		if len(result) > 0 {
 			if isCluster {
 				p := client.Pipeline()
@@ -167,7 +169,7 @@ func (r *Redis) purge(ctx context.Context, client redis.UniversalClient, isClust
 func (r *Redis) Set(ctx context.Context, values ...Value) error {
 	p := r.client.Pipeline()
 	for _, v := range values {
-		if err := p.Set(ctx, r.Key(v.name), v.value, 0).Err(); err != nil {
+		if err := p.Set(ctx, r.Key(v.name), v.value, v.ttl).Err(); err != nil {
 			return errors.Trace(err)
 		}
 	}
@@ -192,11 +194,78 @@ func (r *Redis) Delete(ctx context.Context, key string) error {
 	return r.client.Del(ctx, r.Key(key)).Err()
 }
 
+// TryLock acquires a lock in Redis by SETNX. The token is stored as the value.
+func (r *Redis) TryLock(ctx context.Context, name, token string, ttl time.Duration) (bool, error) {
+	locked, err := r.client.SetNX(ctx, r.Key(name), token, ttl).Result()
+	return locked, errors.Trace(err)
+}
+
+// unlockScript deletes a key if its value equals the token.
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Unlock releases a lock in Redis by a script comparing the token before deleting the key.
+func (r *Redis) Unlock(ctx context.Context, name, token string) error {
+	return errors.Trace(unlockScript.Run(ctx, r.client, []string{r.Key(name)}, token).Err())
+}
+
 // GetSet returns members of a set from Redis.
 func (r *Redis) GetSet(ctx context.Context, key string) ([]string, error) {
 	return r.client.SMembers(ctx, r.Key(key)).Result()
 }
 
+// GetSetPage returns a page of at most n members of a set from Redis by SSCAN. Since SSCAN returns batches of members
+// in hinted sizes, batches are scanned until n members are collected. The cursor consists of the SSCAN cursor of a
+// batch and the number of members in the batch already returned. The returned cursor is empty if there are no more
+// members.
+func (r *Redis) GetSetPage(ctx context.Context, key, cursor string, n int) (string, []string, error) {
+	if n <= 0 {
+		return "", nil, errors.NotValidf("page size %d", n)
+	}
+	var (
+		offset uint64
+		skip   int
+	)
+	if cursor != "" {
+		scanCursor, skipped, found := strings.Cut(cursor, ":")
+		if !found {
+			return "", nil, errors.NotValidf("cursor %s", cursor)
+		}
+		var err error
+		if offset, err = strconv.ParseUint(scanCursor, 10, 64); err != nil {
+			return "", nil, errors.Trace(err)
+		}
+		if skip, err = strconv.Atoi(skipped); err != nil {
+			return "", nil, errors.Trace(err)
+		}
+	}
+	members := make([]string, 0, n)
+	for {
+		batch, next, err := r.client.SScan(ctx, r.Key(key), offset, "", int64(n)).Result()
+		if err != nil {
+			return "", nil, errors.Trace(err)
+		}
+		// the batch might shrink if members are removed
+		batch = batch[min(skip, len(batch)):]
+		if len(members)+len(batch) > n {
+			consumed := n - len(members)
+			return fmt.Sprintf("%d:%d", offset, skip+consumed), append(members, batch[:consumed]...), nil
+		}
+		members = append(members, batch...)
+		if next == 0 {
+			return "", members, nil
+		}
+		offset, skip = next, 0
+		if len(members) == n {
+			return fmt.Sprintf("%d:0", offset), members, nil
+		}
+	}
+}
+
 // SetSet overrides a set with members in Redis.
 func (r *Redis) SetSet(ctx context.Context, key string, members ...string) error {
 	if len(members) == 0 {
@@ -277,6 +346,24 @@ func (r *Redis) AddScores(ctx context.Context, collection, subset string, docume
 	return errors.Trace(err)
 }
 
+// IncrScores adds scores of documents by HINCRBYFLOAT in a pipeline.
+func (r *Redis) IncrScores(ctx context.Context, collection, subset string, documents []Score) error {
+	p := r.client.Pipeline()
+	for _, document := range documents {
+		key := r.documentKey(collection, subset, document.Id)
+		p.HSet(ctx, key,
+			"collection", collection,
+			"subset", subset,
+			"id", document.Id,
+			"is_hidden", document.IsHidden,
+			"categories", encodeCategories(document.Categories),
+			"timestamp", document.Timestamp.UnixMicro())
+		p.HIncrByFloat(ctx, key, "score", document.Score)
+	}
+	_, err := p.Exec(ctx)
+	return errors.Trace(err)
+}
+
 func (r *Redis) SearchScores(ctx context.Context, collection, subset string, query []string, begin, end int) ([]Score, error) {
 	var builder strings.Builder
 	builder.WriteString(fmt.Sprintf("@collection:{ %s } @is_hidden:[0 0]", escape(collection)))
@@ -383,6 +470,121 @@ func (r *Redis) UpdateScores(ctx context.Context, collections []string, subset *
 	return nil
 }
 
+// CountScores counts documents in a collection, including hidden documents.
+func (r *Redis) CountScores(ctx context.Context, collection string) (int, error) {
+	result, err := r.client.FTSearchWithArgs(ctx, r.DocumentTable(), fmt.Sprintf("@collection:{ %s }", escape(collection)),
+		&redis.FTSearchOptions{NoContent: true, LimitOffset: 0, Limit: 1}).Result()
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return result.Total, nil
+}
+
+// CountSubsets counts subsets of a collection having visible documents by COUNT_DISTINCT.
+func (r *Redis) CountSubsets(ctx context.Context, collection string) (int, error) {
+	result, err := r.client.FTAggregateWithArgs(ctx, r.DocumentTable(),
+		fmt.Sprintf("@collection:{ %s } @is_hidden:[0 0]", escape(collection)),
+		&redis.FTAggregateOptions{
+			Load: []redis.FTAggregateLoad{{Field: "subset"}},
+			GroupBy: []redis.FTAggregateGroupBy{{
+				Reduce: []redis.FTAggregateReducer{{Reducer: redis.SearchCountDistinct, Args: []interface{}{"@subset"}, As: "count"}},
+			}},
+		}).Result()
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	if len(result.Rows) == 0 {
+		return 0, nil
+	}
+	count, err := strconv.Atoi(fmt.Sprint(result.Rows[0].Fields["count"]))
+	return count, errors.Trace(err)
+}
+
+// AggregateScores counts documents in a subset and sums their scores by FT.AGGREGATE.
+func (r *Redis) AggregateScores(ctx context.Context, collection, subset string) (int, float64, error) {
+	result, err := r.client.FTAggregateWithArgs(ctx, r.DocumentTable(),
+		fmt.Sprintf("@collection:{ %s } @subset:{ %s }", escape(collection), escape(subset)),
+		&redis.FTAggregateOptions{
+			Load: []redis.FTAggregateLoad{{Field: "score"}},
+			GroupBy: []redis.FTAggregateGroupBy{{
+				Reduce: []redis.FTAggregateReducer{
+					{Reducer: redis.SearchCount, As: "count"},
+					{Reducer: redis.SearchSum, Args: []interface{}{"@score"}, As: "sum"},
+				},
+			}},
+		}).Result()
+	if err != nil {
+		return 0, 0, errors.Trace(err)
+	}
+	if len(result.Rows) == 0 {
+		return 0, 0, nil
+	}
+	count, err := strconv.Atoi(fmt.Sprint(result.Rows[0].Fields["count"]))
+	if err != nil {
+		return 0, 0, errors.Trace(err)
+	}
+	sum, err := strconv.ParseFloat(fmt.Sprint(result.Rows[0].Fields["sum"]), 64)
+	return count, sum, errors.Trace(err)
+}
+
+// memoryUsageSamples is the max number of keys measured by MEMORY USAGE for each pattern on each node. Memory used
+// by other keys is estimated from the average of samples.
+const memoryUsageSamples = 1000
+
+// MemoryUsage estimates memory used by documents and values of a collection. Keys are counted by SCAN, but only
+// sampled keys are measured in a pipeline.
+func (r *Redis) MemoryUsage(ctx context.Context, collection string) (int64, error) {
+	patterns := []string{r.DocumentTable() + ":" + collection + ":*", r.Key(collection + "/*")}
+	var total int64
+	usage := func(ctx context.Context, client redis.UniversalClient) error {
+		for _, pattern := range patterns {
+			var (
+				cursor  uint64
+				numKeys int64
+				samples []*redis.IntCmd
+			)
+			pipeline := client.Pipeline()
+			for {
+				keys, next, err := client.Scan(ctx, cursor, pattern, 0).Result()
+				if err != nil {
+					return errors.Trace(err)
+				}
+				numKeys += int64(len(keys))
+				for _, key := range keys {
+					if len(samples) < memoryUsageSamples {
+						samples = append(samples, pipeline.MemoryUsage(ctx, key))
+					}
+				}
+				if cursor = next; cursor == 0 {
+					break
+				}
+			}
+			if len(samples) == 0 {
+				continue
+			}
+			// keys deleted after scanning are measured as zero
+			if _, err := pipeline.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+				return errors.Trace(err)
+			}
+			var sampled int64
+			for _, sample := range samples {
+				sampled += sample.Val()
+			}
+			atomic.AddInt64(&total, sampled*numKeys/int64(len(samples)))
+		}
+		return nil
+	}
+	var err error
+	if clusterClient, isCluster := r.client.(*redis.ClusterClient); isCluster {
+		err = clusterClient.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			return usage(ctx, client)
+		})
+	} else {
+		err = usage(ctx, r.client)
+	}
+	return total, err
+}
+
 func (r *Redis) DeleteScores(ctx context.Context, collections []string, condition ScoreCondition) error {
 	if err := condition.Check(); err != nil {
 		return errors.Trace(err)
@@ -441,6 +643,19 @@ func (r *Redis) AddTimeSeriesPoints(ctx context.Context, points []TimeSeriesPoin
 	return errors.Trace(err)
 }
 
+func (r *Redis) IncrTimeSeriesPoints(ctx context.Context, points []TimeSeriesPoint) error {
+	p := r.client.Pipeline()
+	for _, point := range points {
+		key := r.pointKey(point.Name, point.Timestamp)
+		p.HSet(ctx, key,
+			"name", point.Name,
+			"timestamp", point.Timestamp.UnixMicro())
+		p.HIncrByFloat(ctx, key, "value", point.Value)
+	}
+	_, err := p.Exec(ctx)
+	return errors.Trace(err)
+}
+
 func (r *Redis) GetTimeSeriesPoints(ctx context.Context, name string, begin, end time.Time) ([]TimeSeriesPoint, error) {
 	result, err := r.client.FTSearchWithArgs(ctx, r.PointsTable(),
 		fmt.Sprintf("@name:{ %s } @timestamp:[%d (%d]", escape(name), begin.UnixMicro(), end.UnixMicro()),
@@ -466,6 +681,30 @@ func (r *Redis) GetTimeSeriesPoints(ctx context.Context, name string, begin, end
 	return points, nil
 }
 
+func (r *Redis) DeleteTimeSeriesPoints(ctx context.Context, name string, before time.Time) error {
+	query := fmt.Sprintf("@name:{ %s } @timestamp:[-inf (%d]", escape(name), before.UnixMicro())
+	for {
+		result, err := r.client.FTSearchWithArgs(ctx, r.PointsTable(), query,
+			&redis.FTSearchOptions{NoContent: true, LimitOffset: 0, Limit: 10000}).Result()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if len(result.Docs) == 0 {
+			return nil
+		}
+		p := r.client.Pipeline()
+		for _, doc := range result.Docs {
+			p.Del(ctx, doc.ID)
+		}
+		if _, err = p.Exec(ctx); err != nil {
+			return errors.Trace(err)
+		}
+		if result.Total == len(result.Docs) {
+			return nil
+		}
+	}
+}
+
 func encdodeCategory(category string) string {
 	return base64.RawStdEncoding.EncodeToString([]byte("_" + category))
 }
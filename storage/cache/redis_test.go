@@ -106,6 +106,27 @@ func (suite *RedisTestSuite) TestEscapeCharacters() {
 	}
 }
 
+func (suite *RedisTestSuite) TestMemoryUsage() {
+	ctx := context.Background()
+	redisClient := suite.Database.(*Redis)
+	// empty collection
+	usage, err := redisClient.MemoryUsage(ctx, "memory")
+	suite.NoError(err)
+	suite.Zero(usage)
+	// more keys than samples
+	scores := make([]Score, memoryUsageSamples+1)
+	for i := range scores {
+		scores[i] = Score{Id: fmt.Sprintf("%d", i), Score: float64(i), Categories: []string{""}}
+	}
+	err = suite.AddScores(ctx, "memory", "a", scores)
+	suite.NoError(err)
+	err = suite.Set(ctx, String(Key("memory", "b"), "value"))
+	suite.NoError(err)
+	usage, err = redisClient.MemoryUsage(ctx, "memory")
+	suite.NoError(err)
+	suite.Positive(usage)
+}
+
 func TestRedis(t *testing.T) {
 	suite.Run(t, new(RedisTestSuite))
 }
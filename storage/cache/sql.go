@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"strconv"
 	"strings"
 	"time"
 
@@ -84,8 +85,9 @@ const (
 )
 
 type SQLValue struct {
-	Name  string `gorm:"type:varchar(256);primaryKey"`
-	Value string `gorm:"type:varchar(256);not null"`
+	Name     string     `gorm:"type:varchar(256);primaryKey"`
+	Value    string     `gorm:"type:varchar(256);not null"`
+	ExpireAt *time.Time `gorm:"index"`
 }
 
 type SQLSet struct {
@@ -240,7 +242,10 @@ func (db *SQLDatabase) Scan(work func(string) error) error {
 }
 
 func (db *SQLDatabase) Purge() error {
-	tables := []any{SQLValue{}, SQLSet{}, SQLSortedSet{}, Message{}, SQLDocument{}}
+	if err := db.gormDB.Where("name <> ?", PurgeLock).Delete(&SQLValue{}).Error; err != nil {
+		return errors.Trace(err)
+	}
+	tables := []any{SQLSet{}, SQLSortedSet{}, Message{}, SQLDocument{}}
 	for _, table := range tables {
 		err := db.gormDB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&table).Error
 		if err != nil {
@@ -254,26 +259,40 @@ func (db *SQLDatabase) Set(ctx context.Context, values ...Value) error {
 	if len(values) == 0 {
 		return nil
 	}
+	now := time.Now()
 	valueSet := mapset.NewSet[string]()
 	rows := make([]SQLValue, 0, len(values))
+	expirable := false
 	for _, value := range values {
 		if !valueSet.Contains(value.name) {
-			rows = append(rows, SQLValue{
+			row := SQLValue{
 				Name:  value.name,
 				Value: value.value,
-			})
+			}
+			if value.ttl > 0 {
+				row.ExpireAt = lo.ToPtr(now.Add(value.ttl))
+				expirable = true
+			}
+			rows = append(rows, row)
 			valueSet.Add(value.name)
 		}
 	}
+	// remove expired values
+	if expirable {
+		if err := db.gormDB.WithContext(ctx).Where("expire_at < ?", now).Delete(&SQLValue{}).Error; err != nil {
+			return errors.Trace(err)
+		}
+	}
 	err := db.gormDB.WithContext(ctx).Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "name"}},
-		DoUpdates: clause.AssignmentColumns([]string{"value"}),
+		DoUpdates: clause.AssignmentColumns([]string{"value", "expire_at"}),
 	}).Create(rows).Error
 	return errors.Trace(err)
 }
 
 func (db *SQLDatabase) Get(ctx context.Context, name string) *ReturnValue {
-	rs, err := db.gormDB.WithContext(ctx).Table(db.ValuesTable()).Where("name = ?", name).Select("value").Rows()
+	rs, err := db.gormDB.WithContext(ctx).Table(db.ValuesTable()).
+		Where("name = ? AND (expire_at IS NULL OR expire_at > ?)", name, time.Now()).Select("value").Rows()
 	if err != nil {
 		return &ReturnValue{err: errors.Trace(err)}
 	}
@@ -294,6 +313,31 @@ func (db *SQLDatabase) Delete(ctx context.Context, name string) error {
 	return errors.Trace(err)
 }
 
+// TryLock acquires a lock in SQL database. The token is stored as the value.
+func (db *SQLDatabase) TryLock(ctx context.Context, name, token string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	// remove expired lock
+	err := db.gormDB.WithContext(ctx).Where("name = ? AND expire_at < ?", name, now).Delete(&SQLValue{}).Error
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	result := db.gormDB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&SQLValue{
+		Name:     name,
+		Value:    token,
+		ExpireAt: lo.ToPtr(now.Add(ttl)),
+	})
+	if result.Error != nil {
+		return false, errors.Trace(result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Unlock releases a lock in SQL database if the value equals the token.
+func (db *SQLDatabase) Unlock(ctx context.Context, name, token string) error {
+	err := db.gormDB.WithContext(ctx).Where("name = ? AND value = ?", name, token).Delete(&SQLValue{}).Error
+	return errors.Trace(err)
+}
+
 func (db *SQLDatabase) GetSet(ctx context.Context, key string) ([]string, error) {
 	rs, err := db.gormDB.WithContext(ctx).Table(db.SetsTable()).Select("member").Where("name = ?", key).Rows()
 	if err != nil {
@@ -311,6 +355,32 @@ func (db *SQLDatabase) GetSet(ctx context.Context, key string) ([]string, error)
 	return members, nil
 }
 
+// GetSetPage returns a page of members of a set sorted by member. The cursor is the last member of the previous page
+// and the returned cursor is empty if there are no more members.
+func (db *SQLDatabase) GetSetPage(ctx context.Context, key, cursor string, n int) (string, []string, error) {
+	if n <= 0 {
+		return "", nil, errors.NotValidf("page size %d", n)
+	}
+	rs, err := db.gormDB.WithContext(ctx).Table(db.SetsTable()).Select("member").
+		Where("name = ? AND member > ?", key, cursor).Order("member").Limit(n).Rows()
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	defer rs.Close()
+	var members []string
+	for rs.Next() {
+		var member string
+		if err = rs.Scan(&member); err != nil {
+			return "", nil, errors.Trace(err)
+		}
+		members = append(members, member)
+	}
+	if len(members) == 0 || len(members) < n {
+		return "", members, nil
+	}
+	return members[len(members)-1], members, nil
+}
+
 func (db *SQLDatabase) SetSet(ctx context.Context, key string, members ...string) error {
 	tx := db.gormDB.WithContext(ctx)
 	err := tx.Delete(&SQLSet{}, "name = ?", key).Error
@@ -392,10 +462,35 @@ func (db *SQLDatabase) Remain(ctx context.Context, name string) (count int64, er
 }
 
 func (db *SQLDatabase) AddScores(ctx context.Context, collection, subset string, documents []Score) error {
-	var rows any
+	db.gormDB.WithContext(ctx).Table(db.DocumentTable()).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "collection"}, {Name: "subset"}, {Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"score", "categories", "timestamp"}),
+	}).Create(db.documentRows(collection, subset, documents))
+	return nil
+}
+
+// IncrScores adds scores of documents in a single upsert.
+func (db *SQLDatabase) IncrScores(ctx context.Context, collection, subset string, documents []Score) error {
+	if len(documents) == 0 {
+		return nil
+	}
+	score := gorm.Expr(db.DocumentTable() + ".score + excluded.score")
+	if db.driver == MySQL {
+		score = gorm.Expr(db.DocumentTable() + ".score + VALUES(score)")
+	}
+	err := db.gormDB.WithContext(ctx).Table(db.DocumentTable()).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "collection"}, {Name: "subset"}, {Name: "id"}},
+		DoUpdates: append(clause.AssignmentColumns([]string{"categories", "timestamp"}),
+			clause.Assignment{Column: clause.Column{Name: "score"}, Value: score}),
+	}).Create(db.documentRows(collection, subset, documents)).Error
+	return errors.Trace(err)
+}
+
+// documentRows converts documents to rows of the document table.
+func (db *SQLDatabase) documentRows(collection, subset string, documents []Score) any {
 	switch db.driver {
 	case Postgres:
-		rows = lo.Map(documents, func(document Score, _ int) PostgresDocument {
+		return lo.Map(documents, func(document Score, _ int) PostgresDocument {
 			return PostgresDocument{
 				Collection: collection,
 				Subset:     subset,
@@ -406,8 +501,8 @@ func (db *SQLDatabase) AddScores(ctx context.Context, collection, subset string,
 				Timestamp:  document.Timestamp,
 			}
 		})
-	case SQLite, MySQL:
-		rows = lo.Map(documents, func(document Score, _ int) SQLDocument {
+	default:
+		return lo.Map(documents, func(document Score, _ int) SQLDocument {
 			return SQLDocument{
 				Collection: collection,
 				Subset:     subset,
@@ -419,11 +514,6 @@ func (db *SQLDatabase) AddScores(ctx context.Context, collection, subset string,
 			}
 		})
 	}
-	db.gormDB.WithContext(ctx).Table(db.DocumentTable()).Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "collection"}, {Name: "subset"}, {Name: "id"}},
-		DoUpdates: clause.AssignmentColumns([]string{"score", "categories", "timestamp"}),
-	}).Create(rows)
-	return nil
 }
 
 func (db *SQLDatabase) SearchScores(ctx context.Context, collection, subset string, query []string, begin, end int) ([]Score, error) {
@@ -479,6 +569,47 @@ func (db *SQLDatabase) SearchScores(ctx context.Context, collection, subset stri
 	return documents, nil
 }
 
+// CountScores counts documents in a collection, including hidden documents.
+func (db *SQLDatabase) CountScores(ctx context.Context, collection string) (int, error) {
+	var count int64
+	if err := db.gormDB.WithContext(ctx).
+		Model(&PostgresDocument{}).
+		Where("collection = ?", collection).
+		Count(&count).Error; err != nil {
+		return 0, errors.Trace(err)
+	}
+	return int(count), nil
+}
+
+// CountSubsets counts subsets of a collection having visible documents.
+func (db *SQLDatabase) CountSubsets(ctx context.Context, collection string) (int, error) {
+	var count int64
+	if err := db.gormDB.WithContext(ctx).
+		Model(&PostgresDocument{}).
+		Where("collection = ? AND is_hidden = ?", collection, false).
+		Distinct("subset").
+		Count(&count).Error; err != nil {
+		return 0, errors.Trace(err)
+	}
+	return int(count), nil
+}
+
+// AggregateScores counts documents in a subset and sums their scores.
+func (db *SQLDatabase) AggregateScores(ctx context.Context, collection, subset string) (int, float64, error) {
+	var result struct {
+		Count int64
+		Sum   float64
+	}
+	if err := db.gormDB.WithContext(ctx).
+		Model(&PostgresDocument{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(score), 0) AS sum").
+		Where("collection = ? AND subset = ?", collection, subset).
+		Scan(&result).Error; err != nil {
+		return 0, 0, errors.Trace(err)
+	}
+	return int(result.Count), result.Sum, nil
+}
+
 func (db *SQLDatabase) UpdateScores(ctx context.Context, collections []string, subset *string, id string, patch ScorePatch) error {
 	if len(collections) == 0 {
 		return nil
@@ -546,6 +677,20 @@ func (db *SQLDatabase) AddTimeSeriesPoints(ctx context.Context, points []TimeSer
 	}).Create(points).Error
 }
 
+func (db *SQLDatabase) IncrTimeSeriesPoints(ctx context.Context, points []TimeSeriesPoint) error {
+	for _, point := range points {
+		if err := db.gormDB.WithContext(ctx).Table(db.PointsTable()).Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "name"}, {Name: "timestamp"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"value": gorm.Expr(db.PointsTable()+".value + ?", point.Value),
+			}),
+		}).Create(&point).Error; err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
 func (db *SQLDatabase) GetTimeSeriesPoints(ctx context.Context, name string, begin, end time.Time) ([]TimeSeriesPoint, error) {
 	var points []TimeSeriesPoint
 	if err := db.gormDB.WithContext(ctx).Table(db.PointsTable()).
@@ -555,3 +700,10 @@ func (db *SQLDatabase) GetTimeSeriesPoints(ctx context.Context, name string, beg
 	}
 	return points, nil
 }
+
+func (db *SQLDatabase) DeleteTimeSeriesPoints(ctx context.Context, name string, before time.Time) error {
+	err := db.gormDB.WithContext(ctx).Table(db.PointsTable()).
+		Where("name = ? and timestamp < ?", name, before).
+		Delete(&TimeSeriesPoint{}).Error
+	return errors.Trace(err)
+}
@@ -100,6 +100,77 @@ func ValidateLabels(o any) error {
 	}
 }
 
+// DuplicateUserIds returns ids of users appearing more than once in a batch.
+func DuplicateUserIds(users []User) []string {
+	counts := make(map[string]int, len(users))
+	var duplicates []string
+	for _, user := range users {
+		counts[user.UserId]++
+		if counts[user.UserId] == 2 {
+			duplicates = append(duplicates, user.UserId)
+		}
+	}
+	return duplicates
+}
+
+// distinctUsers returns users with distinct ids. If an id appears more than once, the last user wins but keeps the
+// position of the first one.
+func distinctUsers(users []User) []User {
+	positions := make(map[string]int, len(users))
+	distinct := make([]User, 0, len(users))
+	for _, user := range users {
+		if i, exist := positions[user.UserId]; exist {
+			distinct[i] = user
+		} else {
+			positions[user.UserId] = len(distinct)
+			distinct = append(distinct, user)
+		}
+	}
+	return distinct
+}
+
+// LimitUserLabels checks the number of labels of each user against maxLabels, where keys of an object or elements of
+// a list are counted as labels. Exceeded labels are dropped if truncate is true, otherwise a NotValid error is
+// returned. There is no limit if maxLabels is 0.
+func LimitUserLabels(users []User, maxLabels int, truncate bool) error {
+	for i, user := range users {
+		labels, err := limitLabels(user.UserId, user.Labels, maxLabels, truncate)
+		if err != nil {
+			return err
+		}
+		users[i].Labels = labels
+	}
+	return nil
+}
+
+// limitLabels limits the number of labels of a user like LimitUserLabels.
+func limitLabels(userId string, labels any, maxLabels int, truncate bool) (any, error) {
+	if maxLabels <= 0 {
+		return labels, nil
+	}
+	switch typed := labels.(type) {
+	case map[string]any:
+		if len(typed) <= maxLabels {
+			return labels, nil
+		}
+		if !truncate {
+			return nil, errors.NewNotValid(nil, fmt.Sprintf("user `%s` has %d labels, exceeding the limit %d", userId, len(typed), maxLabels))
+		}
+		keys := lo.Keys(typed)
+		sort.Strings(keys)
+		return lo.PickByKeys(typed, keys[:maxLabels]), nil
+	case []any:
+		if len(typed) <= maxLabels {
+			return labels, nil
+		}
+		if !truncate {
+			return nil, errors.NewNotValid(nil, fmt.Sprintf("user `%s` has %d labels, exceeding the limit %d", userId, len(typed), maxLabels))
+		}
+		return typed[:maxLabels], nil
+	}
+	return labels, nil
+}
+
 // Item stores meta data about item.
 type Item struct {
 	ItemId     string    `gorm:"primaryKey" mapstructure:"item_id"`
@@ -108,6 +179,21 @@ type Item struct {
 	Timestamp  time.Time `gorm:"column:time_stamp" mapstructure:"timestamp"`
 	Labels     any       `gorm:"serializer:json" mapstructure:"labels"`
 	Comment    string    `mapsstructure:"comment"`
+	// AvailableFrom and AvailableUntil are the window in which the item could be recommended. Items without window
+	// are always available.
+	AvailableFrom  *time.Time `gorm:"column:available_from" mapstructure:"available_from"`
+	AvailableUntil *time.Time `gorm:"column:available_until" mapstructure:"available_until"`
+}
+
+// IsAvailable checks whether the item could be recommended at a time.
+func (item *Item) IsAvailable(t time.Time) bool {
+	if item.AvailableFrom != nil && t.Before(*item.AvailableFrom) {
+		return false
+	}
+	if item.AvailableUntil != nil && t.After(*item.AvailableUntil) {
+		return false
+	}
+	return true
 }
 
 // ItemPatch is the modification on an item.
@@ -150,6 +236,12 @@ type Feedback struct {
 
 type UserFeedback Feedback
 
+// isWithinWindow checks whether the distance between two timestamps is no more than the window.
+func isWithinWindow(a, b time.Time, window time.Duration) bool {
+	d := a.Sub(b)
+	return d <= window && d >= -window
+}
+
 type ItemFeedback Feedback
 
 // SortFeedbacks sorts feedback from latest to oldest.
@@ -266,13 +358,17 @@ type Database interface {
 	BatchInsertUsers(ctx context.Context, users []User) error
 	DeleteUser(ctx context.Context, userId string) error
 	GetUser(ctx context.Context, userId string) (User, error)
+	BatchGetUsers(ctx context.Context, userIds []string) ([]User, error)
 	ModifyUser(ctx context.Context, userId string, patch UserPatch) error
 	GetUsers(ctx context.Context, cursor string, n int) (string, []User, error)
 	GetUserFeedback(ctx context.Context, userId string, endTime *time.Time, feedbackTypes ...string) ([]Feedback, error)
+	CountUserFeedback(ctx context.Context, userId string, feedbackTypes ...string) (int, error)
 	GetUserItemFeedback(ctx context.Context, userId, itemId string, feedbackTypes ...string) ([]Feedback, error)
 	DeleteUserItemFeedback(ctx context.Context, userId, itemId string, feedbackTypes ...string) (int, error)
-	BatchInsertFeedback(ctx context.Context, feedback []Feedback, insertUser, insertItem, overwrite bool) error
+	RenameFeedbackType(ctx context.Context, from, to string) (int, error)
+	BatchInsertFeedback(ctx context.Context, feedback []Feedback, insertUser, insertItem, overwrite bool) ([]Feedback, error)
 	GetFeedback(ctx context.Context, cursor string, n int, beginTime, endTime *time.Time, feedbackTypes ...string) (string, []Feedback, error)
+	GetRecentFeedback(ctx context.Context, n int, feedbackTypes ...string) ([]Feedback, error)
 	GetUserStream(ctx context.Context, batchSize int) (chan []User, chan error)
 	GetItemStream(ctx context.Context, batchSize int, timeLimit *time.Time) (chan []Item, chan error)
 	GetFeedbackStream(ctx context.Context, batchSize int, options ...ScanOption) (chan []Feedback, chan error)
@@ -281,12 +377,121 @@ type Database interface {
 	CountFeedback(ctx context.Context) (int, error)
 }
 
+// Transactor is implemented by databases which write records in transactions.
+type Transactor interface {
+	Transaction(ctx context.Context, f func(tx Database) error) error
+}
+
+// Transaction runs f in a transaction if supported by the database, so that records written by f are rolled back if
+// f fails or the context is canceled. Otherwise, f writes to the database directly and records written before the
+// failure are kept.
+func Transaction(ctx context.Context, database Database, f func(tx Database) error) error {
+	if transactor, ok := database.(Transactor); ok {
+		return transactor.Transaction(ctx, f)
+	}
+	return f(database)
+}
+
+// ItemLabelSearcher is implemented by databases which search items by labels natively.
+type ItemLabelSearcher interface {
+	SearchItemsByLabel(ctx context.Context, name, value, cursor string, n int) (string, []Item, error)
+}
+
+// SearchItemsByLabel returns items whose label equals the value. If the label has multiple values, items match
+// if any value equals the value. The name of a nested label is a dotted path, e.g., spec.color, and literal dots in
+// label names are escaped by backslashes, e.g., version\.major. Items are searched by the database if supported,
+// otherwise by scanning items.
+func SearchItemsByLabel(ctx context.Context, database Database, name, value, cursor string, n int) (string, []Item, error) {
+	if searcher, ok := database.(ItemLabelSearcher); ok {
+		return searcher.SearchItemsByLabel(ctx, name, value, cursor, n)
+	}
+	return scanItemsByLabel(ctx, database, name, value, cursor, n)
+}
+
+// scanItemsByLabel scans items page by page until matched items are found. Less than n items might be
+// returned even if there are more matched items.
+func scanItemsByLabel(ctx context.Context, database Database, name, value, cursor string, n int) (string, []Item, error) {
+	var matched []Item
+	for {
+		var (
+			items []Item
+			err   error
+		)
+		cursor, items, err = database.GetItems(ctx, cursor, n, nil)
+		if err != nil {
+			return "", nil, errors.Trace(err)
+		}
+		for _, item := range items {
+			if MatchLabel(item.Labels, name, value) {
+				matched = append(matched, item)
+			}
+		}
+		if len(matched) > 0 || cursor == "" || len(items) == 0 {
+			return cursor, matched, nil
+		}
+	}
+}
+
+// MatchLabel checks if a label equals the value or any value of a multi-value label equals the value. The name of
+// a nested label is a dotted path.
+func MatchLabel(labels any, name, value string) bool {
+	switch label := LookupLabel(labels, name).(type) {
+	case nil:
+		return false
+	case []any:
+		for _, v := range label {
+			if fmt.Sprint(v) == value {
+				return true
+			}
+		}
+		return false
+	case []string:
+		return lo.Contains(label, value)
+	default:
+		return fmt.Sprint(label) == value
+	}
+}
+
+// LookupLabel returns the label at a dotted path by traversing nested maps, or nil if the label doesn't exist.
+func LookupLabel(labels any, path string) any {
+	for _, key := range SplitLabelPath(path) {
+		labelMap, ok := labels.(map[string]any)
+		if !ok {
+			return nil
+		}
+		labels = labelMap[key]
+	}
+	return labels
+}
+
+// SplitLabelPath splits a dotted label path into label names. A literal dot in a label name is escaped by a
+// backslash, and so is a literal backslash, e.g., the path spec.version\.major consists of spec and version.major.
+func SplitLabelPath(path string) []string {
+	var (
+		keys []string
+		key  strings.Builder
+	)
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path) && (path[i+1] == '.' || path[i+1] == '\\'):
+			i++
+			key.WriteByte(path[i])
+		case path[i] == '.':
+			keys = append(keys, key.String())
+			key.Reset()
+		default:
+			key.WriteByte(path[i])
+		}
+	}
+	return append(keys, key.String())
+}
+
 // Open a connection to a database.
 func Open(path, tablePrefix string, opts ...storage.Option) (Database, error) {
 	var err error
+	option := storage.NewOptions(opts...)
 	if strings.HasPrefix(path, storage.MySQLPrefix) {
 		name := path[len(storage.MySQLPrefix):]
-		option := storage.NewOptions(opts...)
 		// probe isolation variable name
 		isolationVarName, err := storage.ProbeMySQLIsolationVariableName(name)
 		if err != nil {
@@ -304,6 +509,8 @@ func Open(path, tablePrefix string, opts ...storage.Option) (Database, error) {
 		database := new(SQLDatabase)
 		database.driver = MySQL
 		database.TablePrefix = storage.TablePrefix(tablePrefix)
+		database.feedbackDedupWindow = option.FeedbackDedupWindow
+		database.maxUserLabels, database.truncateUserLabels = option.MaxLabelsPerUser, option.TruncateLabels
 		if database.client, err = otelsql.Open("mysql", name,
 			otelsql.WithAttributes(semconv.DBSystemMySQL),
 			otelsql.WithSpanOptions(otelsql.SpanOptions{DisableErrSkip: true}),
@@ -319,6 +526,8 @@ func Open(path, tablePrefix string, opts ...storage.Option) (Database, error) {
 		database := new(SQLDatabase)
 		database.driver = Postgres
 		database.TablePrefix = storage.TablePrefix(tablePrefix)
+		database.feedbackDedupWindow = option.FeedbackDedupWindow
+		database.maxUserLabels, database.truncateUserLabels = option.MaxLabelsPerUser, option.TruncateLabels
 		if database.client, err = otelsql.Open("postgres", path,
 			otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
 			otelsql.WithSpanOptions(otelsql.SpanOptions{DisableErrSkip: true}),
@@ -348,6 +557,8 @@ func Open(path, tablePrefix string, opts ...storage.Option) (Database, error) {
 		database := new(SQLDatabase)
 		database.driver = ClickHouse
 		database.TablePrefix = storage.TablePrefix(tablePrefix)
+		database.feedbackDedupWindow = option.FeedbackDedupWindow
+		database.maxUserLabels, database.truncateUserLabels = option.MaxLabelsPerUser, option.TruncateLabels
 		if database.client, err = otelsql.Open("chhttp", uri,
 			otelsql.WithAttributes(semconv.DBSystemKey.String("clickhouse")),
 			otelsql.WithSpanOptions(otelsql.SpanOptions{DisableErrSkip: true}),
@@ -375,6 +586,8 @@ func Open(path, tablePrefix string, opts ...storage.Option) (Database, error) {
 			database.dbName = cs.Database
 			database.TablePrefix = storage.TablePrefix(tablePrefix)
 		}
+		database.feedbackDedupWindow = option.FeedbackDedupWindow
+		database.maxUserLabels, database.truncateUserLabels = option.MaxLabelsPerUser, option.TruncateLabels
 		return database, nil
 	} else if strings.HasPrefix(path, storage.SQLitePrefix) {
 		dataSourceName := path[len(storage.SQLitePrefix):]
@@ -389,6 +602,8 @@ func Open(path, tablePrefix string, opts ...storage.Option) (Database, error) {
 		database := new(SQLDatabase)
 		database.driver = SQLite
 		database.TablePrefix = storage.TablePrefix(tablePrefix)
+		database.feedbackDedupWindow = option.FeedbackDedupWindow
+		database.maxUserLabels, database.truncateUserLabels = option.MaxLabelsPerUser, option.TruncateLabels
 		if database.client, err = otelsql.Open("sqlite", dataSourceName,
 			otelsql.WithAttributes(semconv.DBSystemSqlite),
 			otelsql.WithSpanOptions(otelsql.SpanOptions{DisableErrSkip: true}),
@@ -136,6 +136,15 @@ func (suite *baseTestSuite) isClickHouse() bool {
 	}
 }
 
+func (suite *baseTestSuite) setFeedbackDedupWindow(window time.Duration) {
+	switch database := suite.Database.(type) {
+	case *SQLDatabase:
+		database.feedbackDedupWindow = window
+	case *MongoDB:
+		database.feedbackDedupWindow = window
+	}
+}
+
 func (suite *baseTestSuite) analyzeTables() {
 	sqlDatabase, ok := suite.Database.(*SQLDatabase)
 	if ok && sqlDatabase.driver == Postgres {
@@ -240,6 +249,68 @@ func (suite *baseTestSuite) TestUsers() {
 	suite.NoError(err)
 }
 
+func (suite *baseTestSuite) TestBatchInsertDuplicateUsers() {
+	ctx := context.Background()
+	// the last user wins
+	err := suite.Database.BatchInsertUsers(ctx, []User{
+		{UserId: "duplicate", Comment: "first"},
+		{UserId: "unique"},
+		{UserId: "duplicate", Comment: "last"},
+	})
+	suite.NoError(err)
+	user, err := suite.Database.GetUser(ctx, "duplicate")
+	suite.NoError(err)
+	suite.Equal("last", user.Comment)
+}
+
+func (suite *baseTestSuite) TestBatchGetUsers() {
+	ctx := context.Background()
+	err := suite.Database.BatchInsertUsers(ctx, []User{
+		{UserId: "batch_0", Comment: "comment"},
+		{UserId: "batch_1"},
+		{UserId: "batch_2"},
+	})
+	suite.NoError(err)
+	// missing users are skipped
+	users, err := suite.Database.BatchGetUsers(ctx, []string{"batch_0", "batch_2", "batch_3"})
+	suite.NoError(err)
+	suite.ElementsMatch([]string{"batch_0", "batch_2"}, lo.Map(users, func(user User, _ int) string {
+		return user.UserId
+	}))
+	for _, user := range users {
+		if user.UserId == "batch_0" {
+			suite.Equal("comment", user.Comment)
+		}
+	}
+	// empty ids
+	users, err = suite.Database.BatchGetUsers(ctx, nil)
+	suite.NoError(err)
+	suite.Empty(users)
+}
+
+func (suite *baseTestSuite) TestCountUserFeedback() {
+	ctx := context.Background()
+	_, err := suite.Database.BatchInsertFeedback(ctx, []Feedback{
+		{FeedbackKey: FeedbackKey{FeedbackType: positiveFeedbackType, UserId: "count_0", ItemId: "0"}},
+		{FeedbackKey: FeedbackKey{FeedbackType: positiveFeedbackType, UserId: "count_0", ItemId: "1"}},
+		{FeedbackKey: FeedbackKey{FeedbackType: negativeFeedbackType, UserId: "count_0", ItemId: "2"}},
+		{FeedbackKey: FeedbackKey{FeedbackType: positiveFeedbackType, UserId: "count_1", ItemId: "0"}},
+	}, true, true, true)
+	suite.NoError(err)
+	// count typed feedback
+	count, err := suite.Database.CountUserFeedback(ctx, "count_0", positiveFeedbackType)
+	suite.NoError(err)
+	suite.Equal(2, count)
+	// count all feedback
+	count, err = suite.Database.CountUserFeedback(ctx, "count_0")
+	suite.NoError(err)
+	suite.Equal(3, count)
+	// count feedback of a user without feedback
+	count, err = suite.Database.CountUserFeedback(ctx, "count_2")
+	suite.NoError(err)
+	suite.Equal(0, count)
+}
+
 func (suite *baseTestSuite) TestFeedback() {
 	ctx := context.Background()
 	// users that already exists
@@ -257,12 +328,12 @@ func (suite *baseTestSuite) TestFeedback() {
 		{FeedbackKey{positiveFeedbackType, "3", "2"}, timestamp, "comment"},
 		{FeedbackKey{positiveFeedbackType, "4", "0"}, timestamp, "comment"},
 	}
-	err = suite.Database.BatchInsertFeedback(ctx, feedback, true, true, true)
+	_, err = suite.Database.BatchInsertFeedback(ctx, feedback, true, true, true)
 	suite.NoError(err)
 	// other type
-	err = suite.Database.BatchInsertFeedback(ctx, []Feedback{{FeedbackKey: FeedbackKey{negativeFeedbackType, "0", "2"}}}, true, true, true)
+	_, err = suite.Database.BatchInsertFeedback(ctx, []Feedback{{FeedbackKey: FeedbackKey{negativeFeedbackType, "0", "2"}}}, true, true, true)
 	suite.NoError(err)
-	err = suite.Database.BatchInsertFeedback(ctx, []Feedback{{FeedbackKey: FeedbackKey{negativeFeedbackType, "2", "4"}}}, true, true, true)
+	_, err = suite.Database.BatchInsertFeedback(ctx, []Feedback{{FeedbackKey: FeedbackKey{negativeFeedbackType, "2", "4"}}}, true, true, true)
 	suite.NoError(err)
 	// future feedback
 	futureFeedback := []Feedback{
@@ -272,7 +343,7 @@ func (suite *baseTestSuite) TestFeedback() {
 		{FeedbackKey{duplicateFeedbackType, "3", "6"}, time.Now().Add(time.Hour), "comment"},
 		{FeedbackKey{duplicateFeedbackType, "4", "8"}, time.Now().Add(time.Hour), "comment"},
 	}
-	err = suite.Database.BatchInsertFeedback(ctx, futureFeedback, true, true, true)
+	_, err = suite.Database.BatchInsertFeedback(ctx, futureFeedback, true, true, true)
 	suite.NoError(err)
 	// Count feedback
 	suite.analyzeTables()
@@ -356,7 +427,7 @@ func (suite *baseTestSuite) TestFeedback() {
 	suite.NoError(err)
 	suite.Equal(2, len(ret))
 	// test override
-	err = suite.Database.BatchInsertFeedback(ctx, []Feedback{{
+	_, err = suite.Database.BatchInsertFeedback(ctx, []Feedback{{
 		FeedbackKey: FeedbackKey{positiveFeedbackType, "0", "8"},
 		Comment:     "override",
 	}}, true, true, true)
@@ -368,11 +439,12 @@ func (suite *baseTestSuite) TestFeedback() {
 	suite.Equal(1, len(ret))
 	suite.Equal("override", ret[0].Comment)
 	// test not overwrite
-	err = suite.Database.BatchInsertFeedback(ctx, []Feedback{{
+	inserted, err := suite.Database.BatchInsertFeedback(ctx, []Feedback{{
 		FeedbackKey: FeedbackKey{positiveFeedbackType, "0", "8"},
 		Comment:     "not_override",
 	}}, true, true, false)
 	suite.NoError(err)
+	suite.Empty(inserted)
 	err = suite.Database.Optimize()
 	suite.NoError(err)
 	ret, err = suite.Database.GetUserFeedback(ctx, "0", lo.ToPtr(time.Now()), positiveFeedbackType)
@@ -381,16 +453,17 @@ func (suite *baseTestSuite) TestFeedback() {
 	suite.Equal("override", ret[0].Comment)
 
 	// insert no feedback
-	err = suite.Database.BatchInsertFeedback(ctx, nil, true, true, true)
+	_, err = suite.Database.BatchInsertFeedback(ctx, nil, true, true, true)
 	suite.NoError(err)
 
 	// not insert users or items
-	err = suite.Database.BatchInsertFeedback(ctx, []Feedback{
+	inserted, err = suite.Database.BatchInsertFeedback(ctx, []Feedback{
 		{FeedbackKey: FeedbackKey{"a", "100", "200"}},
 		{FeedbackKey: FeedbackKey{"a", "0", "200"}},
 		{FeedbackKey: FeedbackKey{"a", "100", "8"}},
 	}, false, false, false)
 	suite.NoError(err)
+	suite.Empty(inserted)
 	result, err := suite.Database.GetUserItemFeedback(ctx, "100", "200")
 	suite.NoError(err)
 	suite.Empty(result)
@@ -402,18 +475,100 @@ func (suite *baseTestSuite) TestFeedback() {
 	suite.Empty(result)
 
 	// insert valid feedback and invalid feedback at the same time
-	err = suite.Database.BatchInsertFeedback(ctx, []Feedback{
+	inserted, err = suite.Database.BatchInsertFeedback(ctx, []Feedback{
 		{FeedbackKey: FeedbackKey{"a", "0", "8"}},
 		{FeedbackKey: FeedbackKey{"a", "100", "200"}},
 	}, false, false, false)
 	suite.NoError(err)
+	suite.Equal([]FeedbackKey{{"a", "0", "8"}}, lo.Map(inserted, func(f Feedback, _ int) FeedbackKey { return f.FeedbackKey }))
 
 	// insert duplicate feedback
-	err = suite.Database.BatchInsertFeedback(ctx, []Feedback{
+	inserted, err = suite.Database.BatchInsertFeedback(ctx, []Feedback{
 		{FeedbackKey: FeedbackKey{"a", "0", "0"}},
 		{FeedbackKey: FeedbackKey{"a", "0", "0"}},
 	}, true, true, true)
 	suite.NoError(err)
+	suite.Equal([]FeedbackKey{{"a", "0", "0"}}, lo.Map(inserted, func(f Feedback, _ int) FeedbackKey { return f.FeedbackKey }))
+}
+
+func (suite *baseTestSuite) TestFeedbackDedupWindow() {
+	ctx := context.Background()
+	suite.setFeedbackDedupWindow(time.Minute)
+	defer suite.setFeedbackDedupWindow(0)
+	timestamp := time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC)
+	_, err := suite.Database.BatchInsertFeedback(ctx, []Feedback{
+		{FeedbackKey{positiveFeedbackType, "0", "0"}, timestamp, "first"},
+		{FeedbackKey{positiveFeedbackType, "1", "1"}, timestamp, "first"},
+	}, true, true, true)
+	suite.NoError(err)
+	// duplicate feedback inside the window is ignored
+	_, err = suite.Database.BatchInsertFeedback(ctx, []Feedback{
+		{FeedbackKey{positiveFeedbackType, "0", "0"}, timestamp.Add(30 * time.Second), "inside"},
+		{FeedbackKey{positiveFeedbackType, "1", "1"}, timestamp.Add(2 * time.Minute), "outside"},
+	}, true, true, true)
+	suite.NoError(err)
+	err = suite.Database.Optimize()
+	suite.NoError(err)
+	ret, err := suite.Database.GetUserItemFeedback(ctx, "0", "0", positiveFeedbackType)
+	suite.NoError(err)
+	if suite.Equal(1, len(ret)) {
+		suite.Equal("first", ret[0].Comment)
+		suite.Equal(timestamp, ret[0].Timestamp.In(time.UTC))
+	}
+	// duplicate feedback outside the window is inserted
+	ret, err = suite.Database.GetUserItemFeedback(ctx, "1", "1", positiveFeedbackType)
+	suite.NoError(err)
+	if suite.Equal(1, len(ret)) {
+		suite.Equal("outside", ret[0].Comment)
+		suite.Equal(timestamp.Add(2*time.Minute), ret[0].Timestamp.In(time.UTC))
+	}
+}
+
+func (suite *baseTestSuite) TestSearchItemsByLabel() {
+	ctx := context.Background()
+	err := suite.Database.BatchInsertItems(ctx, []Item{
+		{ItemId: "search_0", Labels: map[string]any{
+			"spec":          map[string]any{"color": "red"},
+			"tags":          []any{"a", "b"},
+			"version.major": "1",
+		}},
+		{ItemId: "search_1", Labels: map[string]any{
+			"spec":    map[string]any{"color": "blue"},
+			"tags":    []any{"b"},
+			"version": map[string]any{"major": "1"},
+		}},
+		{ItemId: "search_2", Labels: map[string]any{
+			"spec":          map[string]any{"color": "red"},
+			"version.major": "2",
+		}},
+	})
+	suite.NoError(err)
+	search := func(name, value string) []string {
+		var (
+			itemIds []string
+			cursor  string
+		)
+		for {
+			var items []Item
+			cursor, items, err = SearchItemsByLabel(ctx, suite.Database, name, value, cursor, 1)
+			suite.NoError(err)
+			for _, item := range items {
+				itemIds = append(itemIds, item.ItemId)
+			}
+			if cursor == "" {
+				return itemIds
+			}
+		}
+	}
+	// search nested labels
+	suite.Equal([]string{"search_0", "search_2"}, search("spec.color", "red"))
+	// search multi-value labels
+	suite.Equal([]string{"search_0", "search_1"}, search("tags", "b"))
+	// search labels with literal dots
+	suite.Equal([]string{"search_0"}, search("version\\.major", "1"))
+	suite.Equal([]string{"search_1"}, search("version.major", "1"))
+	// search missing labels
+	suite.Empty(search("spec.size", "1"))
 }
 
 func (suite *baseTestSuite) TestItems() {
@@ -536,6 +691,37 @@ func (suite *baseTestSuite) TestItems() {
 	suite.NoError(err)
 }
 
+func (suite *baseTestSuite) TestItemAvailability() {
+	ctx := context.Background()
+	from := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2000, 2, 1, 0, 0, 0, 0, time.UTC)
+	err := suite.Database.BatchInsertItems(ctx, []Item{
+		{ItemId: "0", Timestamp: from, Labels: []any{}},
+		{ItemId: "1", Timestamp: from, Labels: []any{}, AvailableFrom: &from},
+		{ItemId: "2", Timestamp: from, Labels: []any{}, AvailableFrom: &from, AvailableUntil: &until},
+	})
+	suite.NoError(err)
+	items, err := suite.Database.BatchGetItems(ctx, []string{"0", "1", "2"})
+	suite.NoError(err)
+	suite.Len(items, 3)
+	windows := make(map[string][2]*time.Time)
+	for _, item := range items {
+		windows[item.ItemId] = [2]*time.Time{item.AvailableFrom, item.AvailableUntil}
+	}
+	suite.Nil(windows["0"][0])
+	suite.Nil(windows["0"][1])
+	suite.Equal(from.Unix(), windows["1"][0].Unix())
+	suite.Nil(windows["1"][1])
+	suite.Equal(from.Unix(), windows["2"][0].Unix())
+	suite.Equal(until.Unix(), windows["2"][1].Unix())
+	// check availability
+	item, err := suite.Database.GetItem(ctx, "2")
+	suite.NoError(err)
+	suite.False(item.IsAvailable(from.Add(-time.Hour)))
+	suite.True(item.IsAvailable(from.Add(time.Hour)))
+	suite.False(item.IsAvailable(until.Add(time.Hour)))
+}
+
 func (suite *baseTestSuite) TestDeleteUser() {
 	ctx := context.Background()
 	// Insert ret
@@ -546,7 +732,7 @@ func (suite *baseTestSuite) TestDeleteUser() {
 		{FeedbackKey{positiveFeedbackType, "a", "6"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
 		{FeedbackKey{positiveFeedbackType, "a", "8"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
 	}
-	err := suite.Database.BatchInsertFeedback(ctx, feedback, true, true, true)
+	_, err := suite.Database.BatchInsertFeedback(ctx, feedback, true, true, true)
 	suite.NoError(err)
 	// Delete user
 	err = suite.Database.DeleteUser(ctx, "a")
@@ -571,7 +757,7 @@ func (suite *baseTestSuite) TestDeleteItem() {
 		{FeedbackKey{positiveFeedbackType, "3", "b"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
 		{FeedbackKey{positiveFeedbackType, "4", "b"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
 	}
-	err := suite.Database.BatchInsertFeedback(ctx, feedbacks, true, true, true)
+	_, err := suite.Database.BatchInsertFeedback(ctx, feedbacks, true, true, true)
 	suite.NoError(err)
 	// Delete item
 	err = suite.Database.DeleteItem(ctx, "b")
@@ -595,7 +781,7 @@ func (suite *baseTestSuite) TestDeleteFeedback() {
 		{FeedbackKey{"type1", "2", "4"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
 		{FeedbackKey{"type1", "1", "3"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
 	}
-	err := suite.Database.BatchInsertFeedback(ctx, feedbacks, true, true, true)
+	_, err := suite.Database.BatchInsertFeedback(ctx, feedbacks, true, true, true)
 	suite.NoError(err)
 	// get user-item feedback
 	ret, err := suite.Database.GetUserItemFeedback(ctx, "2", "3")
@@ -629,6 +815,92 @@ func (suite *baseTestSuite) TestDeleteFeedback() {
 	suite.Empty(ret)
 }
 
+func (suite *baseTestSuite) TestTransaction() {
+	if _, ok := suite.Database.(Transactor); !ok {
+		suite.T().Skip()
+	}
+	ctx := context.Background()
+	// records are committed if succeeded
+	err := Transaction(ctx, suite.Database, func(tx Database) error {
+		if err := tx.BatchInsertUsers(ctx, []User{{UserId: "transaction_1"}}); err != nil {
+			return err
+		}
+		_, err := tx.BatchInsertFeedback(ctx, []Feedback{{FeedbackKey: FeedbackKey{"click", "transaction_1", "transaction_1"}}}, false, true, true)
+		return err
+	})
+	suite.NoError(err)
+	_, err = suite.Database.GetUser(ctx, "transaction_1")
+	suite.NoError(err)
+	_, err = suite.Database.GetItem(ctx, "transaction_1")
+	suite.NoError(err)
+	// records are rolled back if failed
+	err = Transaction(ctx, suite.Database, func(tx Database) error {
+		if err := tx.BatchInsertUsers(ctx, []User{{UserId: "transaction_2"}}); err != nil {
+			return err
+		}
+		if err := tx.BatchInsertItems(ctx, []Item{{ItemId: "transaction_2"}}); err != nil {
+			return err
+		}
+		return errors.New("failed")
+	})
+	suite.Error(err)
+	_, err = suite.Database.GetUser(ctx, "transaction_2")
+	suite.True(errors.Is(err, errors.NotFound), err)
+	_, err = suite.Database.GetItem(ctx, "transaction_2")
+	suite.True(errors.Is(err, errors.NotFound), err)
+}
+
+func (suite *baseTestSuite) TestRenameFeedbackType() {
+	ctx := context.Background()
+	feedbacks := []Feedback{
+		{FeedbackKey{"clic", "1", "1"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
+		{FeedbackKey{"clic", "1", "2"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
+		{FeedbackKey{"clic", "2", "1"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
+		{FeedbackKey{"click", "2", "1"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
+		{FeedbackKey{"like", "1", "1"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
+	}
+	_, err := suite.Database.BatchInsertFeedback(ctx, feedbacks, true, true, true)
+	suite.NoError(err)
+	// rename feedback type
+	affected, err := suite.Database.RenameFeedbackType(ctx, "clic", "click")
+	suite.NoError(err)
+	suite.Equal(3, affected)
+	_, ret, err := suite.Database.GetFeedback(ctx, "", 100, nil, lo.ToPtr(time.Now()), "clic")
+	suite.NoError(err)
+	suite.Empty(ret)
+	_, ret, err = suite.Database.GetFeedback(ctx, "", 100, nil, lo.ToPtr(time.Now()), "click")
+	suite.NoError(err)
+	suite.ElementsMatch([]FeedbackKey{{"click", "1", "1"}, {"click", "1", "2"}, {"click", "2", "1"}},
+		lo.Map(ret, func(f Feedback, _ int) FeedbackKey { return f.FeedbackKey }))
+	// rename again
+	affected, err = suite.Database.RenameFeedbackType(ctx, "clic", "click")
+	suite.NoError(err)
+	suite.Zero(affected)
+}
+
+func (suite *baseTestSuite) TestGetRecentFeedback() {
+	ctx := context.Background()
+	feedbacks := []Feedback{
+		{FeedbackKey{"click", "1", "1"}, time.Date(1996, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
+		{FeedbackKey{"like", "1", "2"}, time.Date(1997, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
+		{FeedbackKey{"click", "2", "1"}, time.Date(1998, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
+		{FeedbackKey{"like", "2", "2"}, time.Date(1999, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
+		{FeedbackKey{"click", "3", "1"}, time.Date(2000, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
+	}
+	_, err := suite.Database.BatchInsertFeedback(ctx, feedbacks, true, true, true)
+	suite.NoError(err)
+	// get recent feedback
+	ret, err := suite.Database.GetRecentFeedback(ctx, 3)
+	suite.NoError(err)
+	suite.Equal([]FeedbackKey{{"click", "3", "1"}, {"like", "2", "2"}, {"click", "2", "1"}},
+		lo.Map(ret, func(f Feedback, _ int) FeedbackKey { return f.FeedbackKey }))
+	// get recent feedback of a type
+	ret, err = suite.Database.GetRecentFeedback(ctx, 3, "like")
+	suite.NoError(err)
+	suite.Equal([]FeedbackKey{{"like", "2", "2"}, {"like", "1", "2"}},
+		lo.Map(ret, func(f Feedback, _ int) FeedbackKey { return f.FeedbackKey }))
+}
+
 func (suite *baseTestSuite) TestTimeLimit() {
 	ctx := context.Background()
 	// insert items
@@ -679,7 +951,7 @@ func (suite *baseTestSuite) TestTimeLimit() {
 		{FeedbackKey{"type1", "2", "4"}, time.Date(1999, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
 		{FeedbackKey{"type1", "1", "3"}, time.Date(2000, 3, 15, 0, 0, 0, 0, time.UTC), "comment"},
 	}
-	err = suite.Database.BatchInsertFeedback(ctx, feedbacks, true, true, true)
+	_, err = suite.Database.BatchInsertFeedback(ctx, feedbacks, true, true, true)
 	suite.NoError(err)
 	_, retFeedback, err := suite.Database.GetFeedback(ctx, "", 100, &timeLimit, lo.ToPtr(time.Now()))
 	suite.NoError(err)
@@ -695,7 +967,7 @@ func (suite *baseTestSuite) TestTimezone() {
 	loc, err := time.LoadLocation("Asia/Tokyo")
 	suite.NoError(err)
 	// insert feedbacks
-	err = suite.Database.BatchInsertFeedback(ctx, []Feedback{
+	_, err = suite.Database.BatchInsertFeedback(ctx, []Feedback{
 		{FeedbackKey: FeedbackKey{"read", "1", "1"}, Timestamp: time.Now().Add(-time.Second).In(loc)},
 		{FeedbackKey: FeedbackKey{"read", "1", "2"}, Timestamp: time.Now().Add(-time.Second).In(loc)},
 		{FeedbackKey: FeedbackKey{"read", "2", "2"}, Timestamp: time.Now().Add(-time.Second).In(loc)},
@@ -774,7 +1046,7 @@ func (suite *baseTestSuite) TestTimezone() {
 func (suite *baseTestSuite) TestPurge() {
 	ctx := context.Background()
 	// insert data
-	err := suite.Database.BatchInsertFeedback(ctx, lo.Map(lo.Range(100), func(t int, i int) Feedback {
+	_, err := suite.Database.BatchInsertFeedback(ctx, lo.Map(lo.Range(100), func(t int, i int) Feedback {
 		return Feedback{FeedbackKey: FeedbackKey{
 			FeedbackType: "click",
 			UserId:       strconv.Itoa(t),
@@ -838,6 +1110,51 @@ func TestValidateLabels(t *testing.T) {
 	assert.Error(t, ValidateLabels(map[string]any{"city": "wenzhou", "tags": []any{"1", "2", json.Number("3")}}))
 }
 
+func TestSplitLabelPath(t *testing.T) {
+	assert.Equal(t, []string{"color"}, SplitLabelPath("color"))
+	assert.Equal(t, []string{"spec", "color"}, SplitLabelPath("spec.color"))
+	assert.Equal(t, []string{"spec", "version.major"}, SplitLabelPath(`spec.version\.major`))
+	assert.Equal(t, []string{`a\`, "b"}, SplitLabelPath(`a\\.b`))
+	assert.Equal(t, []string{`a\b`}, SplitLabelPath(`a\b`))
+}
+
+func TestDistinctUsers(t *testing.T) {
+	assert.Equal(t, []User{{UserId: "1", Comment: "c"}, {UserId: "2", Comment: "b"}}, distinctUsers([]User{
+		{UserId: "1", Comment: "a"}, {UserId: "2", Comment: "b"}, {UserId: "1", Comment: "c"},
+	}))
+}
+
+func TestDuplicateUserIds(t *testing.T) {
+	assert.Empty(t, DuplicateUserIds([]User{{UserId: "1"}, {UserId: "2"}}))
+	assert.Equal(t, []string{"2", "1"}, DuplicateUserIds([]User{
+		{UserId: "1"}, {UserId: "2"}, {UserId: "2"}, {UserId: "1"}, {UserId: "2"}, {UserId: "3"},
+	}))
+}
+
+func TestLimitUserLabels(t *testing.T) {
+	newUsers := func() []User {
+		return []User{
+			{UserId: "1", Labels: map[string]any{"c": "3", "a": "1", "b": "2"}},
+			{UserId: "2", Labels: []any{"1", "2", "3"}},
+			{UserId: "3", Labels: "1"},
+		}
+	}
+	// no limit
+	users := newUsers()
+	assert.NoError(t, LimitUserLabels(users, 0, false))
+	assert.Equal(t, newUsers(), users)
+	assert.NoError(t, LimitUserLabels(users, 3, false))
+	assert.Equal(t, newUsers(), users)
+	// reject users
+	assert.EqualError(t, LimitUserLabels(newUsers(), 2, false), "user `1` has 3 labels, exceeding the limit 2")
+	// truncate labels
+	users = newUsers()
+	assert.NoError(t, LimitUserLabels(users, 2, true))
+	assert.Equal(t, map[string]any{"a": "1", "b": "2"}, users[0].Labels)
+	assert.Equal(t, []any{"1", "2"}, users[1].Labels)
+	assert.Equal(t, "1", users[2].Labels)
+}
+
 func benchmarkCountItems(b *testing.B, db Database) {
 	ctx := context.Background()
 	// Insert 10,000 items
@@ -18,10 +18,12 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"strings"
 	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/juju/errors"
+	"github.com/samber/lo"
 	"github.com/zhenghaoz/gorse/storage"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -61,8 +63,11 @@ func unpack(o any) any {
 // MongoDB is the data storage based on MongoDB.
 type MongoDB struct {
 	storage.TablePrefix
-	client *mongo.Client
-	dbName string
+	client              *mongo.Client
+	dbName              string
+	feedbackDedupWindow time.Duration
+	maxUserLabels       int
+	truncateUserLabels  bool
 }
 
 // Optimize is used by ClickHouse only.
@@ -302,6 +307,45 @@ func (db *MongoDB) GetItems(ctx context.Context, cursor string, n int, timeLimit
 	return base64.StdEncoding.EncodeToString([]byte(cursor)), items, nil
 }
 
+// SearchItemsByLabel returns items whose label equals the value or contains the value. Since field paths of MongoDB
+// can't contain literal dots, items are scanned if a label name contains dots.
+func (db *MongoDB) SearchItemsByLabel(ctx context.Context, name, value, cursor string, n int) (string, []Item, error) {
+	keys := SplitLabelPath(name)
+	if lo.SomeBy(keys, func(key string) bool { return strings.Contains(key, ".") }) {
+		return scanItemsByLabel(ctx, db, name, value, cursor, n)
+	}
+	buf, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	c := db.client.Database(db.dbName).Collection(db.ItemsTable())
+	opt := options.Find()
+	opt.SetLimit(int64(n + 1))
+	opt.SetSort(bson.D{{"itemid", 1}})
+	filter := bson.M{"labels." + strings.Join(keys, "."): value}
+	if cursorItem := string(buf); cursorItem != "" {
+		filter["itemid"] = bson.M{"$gte": cursorItem}
+	}
+	r, err := c.Find(ctx, filter, opt)
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	items := make([]Item, 0)
+	defer r.Close(ctx)
+	for r.Next(ctx) {
+		var item Item
+		if err = r.Decode(&item); err != nil {
+			return "", nil, errors.Trace(err)
+		}
+		item.Labels = unpack(item.Labels)
+		items = append(items, item)
+	}
+	if len(items) == n+1 {
+		return base64.StdEncoding.EncodeToString([]byte(items[len(items)-1].ItemId)), items[:len(items)-1], nil
+	}
+	return "", items, nil
+}
+
 // GetItemStream read items from MongoDB by stream.
 func (db *MongoDB) GetItemStream(ctx context.Context, batchSize int, timeLimit *time.Time) (chan []Item, chan error) {
 	itemChan := make(chan []Item, bufSize)
@@ -379,6 +423,10 @@ func (db *MongoDB) BatchInsertUsers(ctx context.Context, users []User) error {
 	if len(users) == 0 {
 		return nil
 	}
+	users = append([]User(nil), users...)
+	if err := LimitUserLabels(users, db.maxUserLabels, db.truncateUserLabels); err != nil {
+		return errors.Trace(err)
+	}
 	c := db.client.Database(db.dbName).Collection(db.UsersTable())
 	var models []mongo.WriteModel
 	for _, user := range users {
@@ -396,7 +444,11 @@ func (db *MongoDB) ModifyUser(ctx context.Context, userId string, patch UserPatc
 	// create patch
 	update := bson.M{}
 	if patch.Labels != nil {
-		update["labels"] = patch.Labels
+		labels, err := limitLabels(userId, patch.Labels, db.maxUserLabels, db.truncateUserLabels)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		update["labels"] = labels
 	}
 	if patch.Comment != nil {
 		update["comment"] = patch.Comment
@@ -437,6 +489,29 @@ func (db *MongoDB) GetUser(ctx context.Context, userId string) (user User, err e
 	return
 }
 
+// BatchGetUsers returns users from MongoDB. Missing users are skipped.
+func (db *MongoDB) BatchGetUsers(ctx context.Context, userIds []string) ([]User, error) {
+	if len(userIds) == 0 {
+		return nil, nil
+	}
+	c := db.client.Database(db.dbName).Collection(db.UsersTable())
+	r, err := c.Find(ctx, bson.M{"userid": bson.M{"$in": userIds}})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	users := make([]User, 0)
+	defer r.Close(ctx)
+	for r.Next(ctx) {
+		var user User
+		if err = r.Decode(&user); err != nil {
+			return nil, errors.Trace(err)
+		}
+		user.Labels = unpack(user.Labels)
+		users = append(users, user)
+	}
+	return users, nil
+}
+
 // GetUsers returns users from MongoDB.
 func (db *MongoDB) GetUsers(ctx context.Context, cursor string, n int) (string, []User, error) {
 	buf, err := base64.StdEncoding.DecodeString(cursor)
@@ -539,11 +614,63 @@ func (db *MongoDB) GetUserFeedback(ctx context.Context, userId string, endTime *
 	return feedbacks, nil
 }
 
-// BatchInsertFeedback returns multiple feedback into MongoDB.
-func (db *MongoDB) BatchInsertFeedback(ctx context.Context, feedback []Feedback, insertUser, insertItem, overwrite bool) error {
+// CountUserFeedback returns the number of feedback of a user without loading feedback.
+func (db *MongoDB) CountUserFeedback(ctx context.Context, userId string, feedbackTypes ...string) (int, error) {
+	c := db.client.Database(db.dbName).Collection(db.FeedbackTable())
+	filter := bson.M{
+		"feedbackkey.userid": bson.M{"$eq": userId},
+	}
+	if len(feedbackTypes) > 0 {
+		filter["feedbackkey.feedbacktype"] = bson.M{"$in": feedbackTypes}
+	}
+	n, err := c.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return int(n), nil
+}
+
+// GetRecentFeedback returns the most recent feedback from MongoDB.
+func (db *MongoDB) GetRecentFeedback(ctx context.Context, n int, feedbackTypes ...string) ([]Feedback, error) {
+	c := db.client.Database(db.dbName).Collection(db.FeedbackTable())
+	filter := bson.M{}
+	if len(feedbackTypes) > 0 {
+		filter["feedbackkey.feedbacktype"] = bson.M{"$in": feedbackTypes}
+	}
+	opt := options.Find()
+	opt.SetSort(bson.D{{"timestamp", -1}})
+	opt.SetLimit(int64(n))
+	r, err := c.Find(ctx, filter, opt)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	feedbacks := make([]Feedback, 0)
+	defer r.Close(ctx)
+	for r.Next(ctx) {
+		var feedback Feedback
+		if err = r.Decode(&feedback); err != nil {
+			return nil, errors.Trace(err)
+		}
+		feedbacks = append(feedbacks, feedback)
+	}
+	return feedbacks, nil
+}
+
+// BatchInsertFeedback returns multiple feedback into MongoDB. Feedback which did not exist before is returned.
+func (db *MongoDB) BatchInsertFeedback(ctx context.Context, feedback []Feedback, insertUser, insertItem, overwrite bool) ([]Feedback, error) {
 	// skip empty list
 	if len(feedback) == 0 {
-		return nil
+		return nil, nil
+	}
+	// skip duplicate feedback
+	if db.feedbackDedupWindow > 0 {
+		var err error
+		if feedback, err = db.removeDuplicateFeedback(ctx, feedback); err != nil {
+			return nil, errors.Trace(err)
+		}
+		if len(feedback) == 0 {
+			return nil, nil
+		}
 	}
 	// collect users and items
 	users := mapset.NewSet[string]()
@@ -565,7 +692,7 @@ func (db *MongoDB) BatchInsertFeedback(ctx context.Context, feedback []Feedback,
 		c := db.client.Database(db.dbName).Collection(db.UsersTable())
 		_, err := c.BulkWrite(ctx, models)
 		if err != nil {
-			return errors.Trace(err)
+			return nil, errors.Trace(err)
 		}
 	} else {
 		for _, userId := range userList {
@@ -575,7 +702,7 @@ func (db *MongoDB) BatchInsertFeedback(ctx context.Context, feedback []Feedback,
 					users.Remove(userId)
 					continue
 				}
-				return errors.Trace(err)
+				return nil, errors.Trace(err)
 			}
 		}
 	}
@@ -592,7 +719,7 @@ func (db *MongoDB) BatchInsertFeedback(ctx context.Context, feedback []Feedback,
 		c := db.client.Database(db.dbName).Collection(db.ItemsTable())
 		_, err := c.BulkWrite(ctx, models)
 		if err != nil {
-			return errors.Trace(err)
+			return nil, errors.Trace(err)
 		}
 	} else {
 		for _, itemId := range itemList {
@@ -602,13 +729,16 @@ func (db *MongoDB) BatchInsertFeedback(ctx context.Context, feedback []Feedback,
 					items.Remove(itemId)
 					continue
 				}
-				return errors.Trace(err)
+				return nil, errors.Trace(err)
 			}
 		}
 	}
 	// insert feedback
 	c := db.client.Database(db.dbName).Collection(db.FeedbackTable())
-	var models []mongo.WriteModel
+	var (
+		models []mongo.WriteModel
+		rows   []Feedback
+	)
 	for _, f := range feedback {
 		if users.Contains(f.UserId) && items.Contains(f.ItemId) {
 			model := mongo.NewUpdateOneModel().
@@ -622,13 +752,50 @@ func (db *MongoDB) BatchInsertFeedback(ctx context.Context, feedback []Feedback,
 				model.SetUpdate(bson.M{"$setOnInsert": f})
 			}
 			models = append(models, model)
+			rows = append(rows, f)
 		}
 	}
 	if len(models) == 0 {
-		return nil
+		return nil, nil
 	}
-	_, err := c.BulkWrite(ctx, models)
-	return errors.Trace(err)
+	result, err := c.BulkWrite(ctx, models)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// feedback is inserted if it is upserted
+	var inserted []Feedback
+	for i, f := range rows {
+		if _, exist := result.UpsertedIDs[int64(i)]; exist {
+			inserted = append(inserted, f)
+		}
+	}
+	return inserted, nil
+}
+
+// removeDuplicateFeedback removes feedback if identical feedback exists within the deduplication window.
+// Existing feedback of the whole batch is fetched by a single query.
+func (db *MongoDB) removeDuplicateFeedback(ctx context.Context, feedback []Feedback) ([]Feedback, error) {
+	keys := lo.Map(feedback, func(f Feedback, _ int) FeedbackKey {
+		return f.FeedbackKey
+	})
+	c := db.client.Database(db.dbName).Collection(db.FeedbackTable())
+	r, err := c.Find(ctx, bson.M{"feedbackkey": bson.M{"$in": keys}})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer r.Close(ctx)
+	existed := make(map[FeedbackKey]time.Time)
+	for r.Next(ctx) {
+		var f Feedback
+		if err = r.Decode(&f); err != nil {
+			return nil, errors.Trace(err)
+		}
+		existed[f.FeedbackKey] = f.Timestamp
+	}
+	return lo.Filter(feedback, func(f Feedback, _ int) bool {
+		t, exist := existed[f.FeedbackKey]
+		return !exist || !isWithinWindow(f.Timestamp, t, db.feedbackDedupWindow)
+	}), nil
 }
 
 // GetFeedback returns multiple feedback from MongoDB.
@@ -810,6 +977,50 @@ func (db *MongoDB) DeleteUserItemFeedback(ctx context.Context, userId, itemId st
 	return int(r.DeletedCount), nil
 }
 
+// RenameFeedbackType renames a feedback type in MongoDB. Feedback conflicting with existed feedback of the new type
+// is removed. MongoDB has no transactions on standalone servers, so each feedback is renamed in place by a single
+// update and the rename is not atomic as a whole: readers might see feedback of both types until it completes, but
+// never miss or duplicate non-conflicting feedback. Renaming is idempotent and safe to retry on failures.
+func (db *MongoDB) RenameFeedbackType(ctx context.Context, from, to string) (int, error) {
+	c := db.client.Database(db.dbName).Collection(db.FeedbackTable())
+	r, err := c.Find(ctx, bson.M{"feedbackkey.feedbacktype": bson.M{"$eq": from}})
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	defer r.Close(ctx)
+	var models []mongo.WriteModel
+	for r.Next(ctx) {
+		var feedback Feedback
+		if err = r.Decode(&feedback); err != nil {
+			return 0, errors.Trace(err)
+		}
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"feedbackkey": feedback.FeedbackKey}).
+			SetUpdate(bson.M{"$set": bson.M{"feedbackkey.feedbacktype": to}}))
+	}
+	var rowAffected int
+	if len(models) > 0 {
+		// feedback conflicting with feedback of the new type fails with duplicate key errors
+		result, err := c.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		var bulkErr mongo.BulkWriteException
+		if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil &&
+			lo.EveryBy(bulkErr.WriteErrors, func(e mongo.BulkWriteError) bool { return e.Code == 11000 }) {
+			err = nil
+		}
+		if err != nil {
+			return 0, errors.Trace(err)
+		} else if result != nil {
+			rowAffected += int(result.ModifiedCount)
+		}
+	}
+	// remove feedback conflicting with feedback of the new type
+	result, err := c.DeleteMany(ctx, bson.M{"feedbackkey.feedbacktype": bson.M{"$eq": from}})
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return rowAffected + int(result.DeletedCount), nil
+}
+
 func (db *MongoDB) CountUsers(ctx context.Context) (int, error) {
 	n, err := db.client.Database(db.dbName).Collection(db.UsersTable()).EstimatedDocumentCount(ctx)
 	return int(n), err
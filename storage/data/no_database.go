@@ -102,6 +102,11 @@ func (NoDatabase) GetUser(_ context.Context, _ string) (User, error) {
 	return User{}, ErrNoDatabase
 }
 
+// BatchGetUsers method of NoDatabase returns ErrNoDatabase.
+func (NoDatabase) BatchGetUsers(_ context.Context, _ []string) ([]User, error) {
+	return nil, ErrNoDatabase
+}
+
 // GetUsers method of NoDatabase returns ErrNoDatabase.
 func (NoDatabase) GetUsers(_ context.Context, _ string, _ int) (string, []User, error) {
 	return "", nil, ErrNoDatabase
@@ -124,6 +129,11 @@ func (NoDatabase) GetUserFeedback(_ context.Context, _ string, _ *time.Time, _ .
 	return nil, ErrNoDatabase
 }
 
+// CountUserFeedback method of NoDatabase returns ErrNoDatabase.
+func (NoDatabase) CountUserFeedback(_ context.Context, _ string, _ ...string) (int, error) {
+	return 0, ErrNoDatabase
+}
+
 // GetUserItemFeedback method of NoDatabase returns ErrNoDatabase.
 func (NoDatabase) GetUserItemFeedback(_ context.Context, _, _ string, _ ...string) ([]Feedback, error) {
 	return nil, ErrNoDatabase
@@ -134,9 +144,19 @@ func (NoDatabase) DeleteUserItemFeedback(_ context.Context, _, _ string, _ ...st
 	return 0, ErrNoDatabase
 }
 
+// RenameFeedbackType method of NoDatabase returns ErrNoDatabase.
+func (NoDatabase) RenameFeedbackType(_ context.Context, _, _ string) (int, error) {
+	return 0, ErrNoDatabase
+}
+
+// GetRecentFeedback method of NoDatabase returns ErrNoDatabase.
+func (NoDatabase) GetRecentFeedback(_ context.Context, _ int, _ ...string) ([]Feedback, error) {
+	return nil, ErrNoDatabase
+}
+
 // BatchInsertFeedback method of NoDatabase returns ErrNoDatabase.
-func (NoDatabase) BatchInsertFeedback(_ context.Context, _ []Feedback, _, _, _ bool) error {
-	return ErrNoDatabase
+func (NoDatabase) BatchInsertFeedback(_ context.Context, _ []Feedback, _, _, _ bool) ([]Feedback, error) {
+	return nil, ErrNoDatabase
 }
 
 // GetFeedback method of NoDatabase returns ErrNoDatabase.
@@ -57,6 +57,8 @@ func TestNoDatabase(t *testing.T) {
 	assert.ErrorIs(t, err, ErrNoDatabase)
 	_, err = database.GetUser(ctx, "")
 	assert.ErrorIs(t, err, ErrNoDatabase)
+	_, err = database.BatchGetUsers(ctx, nil)
+	assert.ErrorIs(t, err, ErrNoDatabase)
 	err = database.ModifyUser(ctx, "", UserPatch{})
 	assert.ErrorIs(t, err, ErrNoDatabase)
 	_, _, err = database.GetUsers(ctx, "", 0)
@@ -66,12 +68,14 @@ func TestNoDatabase(t *testing.T) {
 	_, c = database.GetUserStream(ctx, 0)
 	assert.ErrorIs(t, <-c, ErrNoDatabase)
 
-	err = database.BatchInsertFeedback(ctx, nil, false, false, false)
+	_, err = database.BatchInsertFeedback(ctx, nil, false, false, false)
 	assert.ErrorIs(t, err, ErrNoDatabase)
-	err = database.BatchInsertFeedback(ctx, nil, false, false, false)
+	_, err = database.BatchInsertFeedback(ctx, nil, false, false, false)
 	assert.ErrorIs(t, err, ErrNoDatabase)
 	_, err = database.GetUserFeedback(ctx, "", lo.ToPtr(time.Now()))
 	assert.ErrorIs(t, err, ErrNoDatabase)
+	_, err = database.CountUserFeedback(ctx, "")
+	assert.ErrorIs(t, err, ErrNoDatabase)
 	_, err = database.GetItemFeedback(ctx, "")
 	assert.ErrorIs(t, err, ErrNoDatabase)
 	_, _, err = database.GetFeedback(ctx, "", 0, nil, lo.ToPtr(time.Now()))
@@ -80,6 +84,10 @@ func TestNoDatabase(t *testing.T) {
 	assert.ErrorIs(t, err, ErrNoDatabase)
 	_, err = database.DeleteUserItemFeedback(ctx, "", "")
 	assert.ErrorIs(t, err, ErrNoDatabase)
+	_, err = database.RenameFeedbackType(ctx, "", "")
+	assert.ErrorIs(t, err, ErrNoDatabase)
+	_, err = database.GetRecentFeedback(ctx, 0)
+	assert.ErrorIs(t, err, ErrNoDatabase)
 	_, c = database.GetFeedbackStream(ctx, 0)
 	assert.ErrorIs(t, <-c, ErrNoDatabase)
 
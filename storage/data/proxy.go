@@ -52,6 +52,22 @@ func (p *ProxyServer) Ping(_ context.Context, _ *protocol.PingRequest) (*protoco
 	return &protocol.PingResponse{}, p.database.Ping()
 }
 
+// timestampOrNil converts an optional time to a timestamp. Nil is converted to nil.
+func timestampOrNil(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// timeOrNil converts a timestamp to an optional time. Nil is converted to nil.
+func timeOrNil(t *timestamppb.Timestamp) *time.Time {
+	if t == nil {
+		return nil
+	}
+	return lo.ToPtr(t.AsTime())
+}
+
 func (p *ProxyServer) BatchInsertItems(ctx context.Context, in *protocol.BatchInsertItemsRequest) (*protocol.BatchInsertItemsResponse, error) {
 	items := make([]Item, len(in.Items))
 	for i, item := range in.Items {
@@ -61,12 +77,14 @@ func (p *ProxyServer) BatchInsertItems(ctx context.Context, in *protocol.BatchIn
 			return nil, err
 		}
 		items[i] = Item{
-			ItemId:     item.ItemId,
-			IsHidden:   item.IsHidden,
-			Categories: item.Categories,
-			Timestamp:  item.Timestamp.AsTime(),
-			Labels:     labels,
-			Comment:    item.Comment,
+			ItemId:         item.ItemId,
+			IsHidden:       item.IsHidden,
+			Categories:     item.Categories,
+			Timestamp:      item.Timestamp.AsTime(),
+			Labels:         labels,
+			Comment:        item.Comment,
+			AvailableFrom:  timeOrNil(item.AvailableFrom),
+			AvailableUntil: timeOrNil(item.AvailableUntil),
 		}
 	}
 	err := p.database.BatchInsertItems(ctx, items)
@@ -85,12 +103,14 @@ func (p *ProxyServer) BatchGetItems(ctx context.Context, in *protocol.BatchGetIt
 			return nil, err
 		}
 		pbItems[i] = &protocol.Item{
-			ItemId:     item.ItemId,
-			IsHidden:   item.IsHidden,
-			Categories: item.Categories,
-			Timestamp:  timestamppb.New(item.Timestamp),
-			Labels:     labels,
-			Comment:    item.Comment,
+			ItemId:         item.ItemId,
+			IsHidden:       item.IsHidden,
+			Categories:     item.Categories,
+			Timestamp:      timestamppb.New(item.Timestamp),
+			Labels:         labels,
+			Comment:        item.Comment,
+			AvailableFrom:  timestampOrNil(item.AvailableFrom),
+			AvailableUntil: timestampOrNil(item.AvailableUntil),
 		}
 	}
 	return &protocol.BatchGetItemsResponse{Items: pbItems}, nil
@@ -115,12 +135,14 @@ func (p *ProxyServer) GetItem(ctx context.Context, in *protocol.GetItemRequest)
 	}
 	return &protocol.GetItemResponse{
 		Item: &protocol.Item{
-			ItemId:     item.ItemId,
-			IsHidden:   item.IsHidden,
-			Categories: item.Categories,
-			Timestamp:  timestamppb.New(item.Timestamp),
-			Labels:     labels,
-			Comment:    item.Comment,
+			ItemId:         item.ItemId,
+			IsHidden:       item.IsHidden,
+			Categories:     item.Categories,
+			Timestamp:      timestamppb.New(item.Timestamp),
+			Labels:         labels,
+			Comment:        item.Comment,
+			AvailableFrom:  timestampOrNil(item.AvailableFrom),
+			AvailableUntil: timestampOrNil(item.AvailableUntil),
 		},
 	}, nil
 }
@@ -163,12 +185,14 @@ func (p *ProxyServer) GetItems(ctx context.Context, in *protocol.GetItemsRequest
 			return nil, err
 		}
 		pbItems[i] = &protocol.Item{
-			ItemId:     item.ItemId,
-			IsHidden:   item.IsHidden,
-			Categories: item.Categories,
-			Timestamp:  timestamppb.New(item.Timestamp),
-			Labels:     labels,
-			Comment:    item.Comment,
+			ItemId:         item.ItemId,
+			IsHidden:       item.IsHidden,
+			Categories:     item.Categories,
+			Timestamp:      timestamppb.New(item.Timestamp),
+			Labels:         labels,
+			Comment:        item.Comment,
+			AvailableFrom:  timestampOrNil(item.AvailableFrom),
+			AvailableUntil: timestampOrNil(item.AvailableUntil),
 		}
 	}
 	return &protocol.GetItemsResponse{Cursor: cursor, Items: pbItems}, nil
@@ -333,8 +357,21 @@ func (p *ProxyServer) BatchInsertFeedback(ctx context.Context, in *protocol.Batc
 			Comment:   f.Comment,
 		}
 	}
-	err := p.database.BatchInsertFeedback(ctx, feedback, in.InsertUser, in.InsertItem, in.Overwrite)
-	return &protocol.BatchInsertFeedbackResponse{}, err
+	inserted, err := p.database.BatchInsertFeedback(ctx, feedback, in.InsertUser, in.InsertItem, in.Overwrite)
+	if err != nil {
+		return nil, err
+	}
+	pbFeedback := make([]*protocol.Feedback, len(inserted))
+	for i, f := range inserted {
+		pbFeedback[i] = &protocol.Feedback{
+			FeedbackType: f.FeedbackType,
+			UserId:       f.UserId,
+			ItemId:       f.ItemId,
+			Timestamp:    timestamppb.New(f.Timestamp),
+			Comment:      f.Comment,
+		}
+	}
+	return &protocol.BatchInsertFeedbackResponse{Feedback: pbFeedback}, nil
 }
 
 func (p *ProxyServer) GetFeedback(ctx context.Context, in *protocol.GetFeedbackRequest) (*protocol.GetFeedbackResponse, error) {
@@ -400,12 +437,14 @@ func (p *ProxyServer) GetItemStream(in *protocol.GetItemStreamRequest, stream gr
 				return err
 			}
 			pbItems[i] = &protocol.Item{
-				ItemId:     item.ItemId,
-				IsHidden:   item.IsHidden,
-				Categories: item.Categories,
-				Timestamp:  timestamppb.New(item.Timestamp),
-				Labels:     labels,
-				Comment:    item.Comment,
+				ItemId:         item.ItemId,
+				IsHidden:       item.IsHidden,
+				Categories:     item.Categories,
+				Timestamp:      timestamppb.New(item.Timestamp),
+				Labels:         labels,
+				Comment:        item.Comment,
+				AvailableFrom:  timestampOrNil(item.AvailableFrom),
+				AvailableUntil: timestampOrNil(item.AvailableUntil),
 			}
 		}
 		err := stream.Send(&protocol.GetItemStreamResponse{Items: pbItems})
@@ -516,12 +555,14 @@ func (p ProxyClient) BatchInsertItems(ctx context.Context, items []Item) error {
 			return err
 		}
 		pbItems[i] = &protocol.Item{
-			ItemId:     item.ItemId,
-			IsHidden:   item.IsHidden,
-			Categories: item.Categories,
-			Timestamp:  timestamppb.New(item.Timestamp),
-			Labels:     labels,
-			Comment:    item.Comment,
+			ItemId:         item.ItemId,
+			IsHidden:       item.IsHidden,
+			Categories:     item.Categories,
+			Timestamp:      timestamppb.New(item.Timestamp),
+			Labels:         labels,
+			Comment:        item.Comment,
+			AvailableFrom:  timestampOrNil(item.AvailableFrom),
+			AvailableUntil: timestampOrNil(item.AvailableUntil),
 		}
 	}
 	_, err := p.DataStoreClient.BatchInsertItems(ctx, &protocol.BatchInsertItemsRequest{Items: pbItems})
@@ -541,12 +582,14 @@ func (p ProxyClient) BatchGetItems(ctx context.Context, itemIds []string) ([]Ite
 			return nil, err
 		}
 		items[i] = Item{
-			ItemId:     item.ItemId,
-			IsHidden:   item.IsHidden,
-			Categories: item.Categories,
-			Timestamp:  item.Timestamp.AsTime(),
-			Labels:     labels,
-			Comment:    item.Comment,
+			ItemId:         item.ItemId,
+			IsHidden:       item.IsHidden,
+			Categories:     item.Categories,
+			Timestamp:      item.Timestamp.AsTime(),
+			Labels:         labels,
+			Comment:        item.Comment,
+			AvailableFrom:  timeOrNil(item.AvailableFrom),
+			AvailableUntil: timeOrNil(item.AvailableUntil),
 		}
 	}
 	return items, nil
@@ -570,12 +613,14 @@ func (p ProxyClient) GetItem(ctx context.Context, itemId string) (Item, error) {
 		return Item{}, err
 	}
 	return Item{
-		ItemId:     resp.Item.ItemId,
-		IsHidden:   resp.Item.IsHidden,
-		Categories: resp.Item.Categories,
-		Timestamp:  resp.Item.Timestamp.AsTime(),
-		Labels:     labels,
-		Comment:    resp.Item.Comment,
+		ItemId:         resp.Item.ItemId,
+		IsHidden:       resp.Item.IsHidden,
+		Categories:     resp.Item.Categories,
+		Timestamp:      resp.Item.Timestamp.AsTime(),
+		Labels:         labels,
+		Comment:        resp.Item.Comment,
+		AvailableFrom:  timeOrNil(resp.Item.AvailableFrom),
+		AvailableUntil: timeOrNil(resp.Item.AvailableUntil),
 	}, nil
 }
 
@@ -622,12 +667,14 @@ func (p ProxyClient) GetItems(ctx context.Context, cursor string, n int, beginTi
 			return "", nil, err
 		}
 		items[i] = Item{
-			ItemId:     item.ItemId,
-			IsHidden:   item.IsHidden,
-			Categories: item.Categories,
-			Timestamp:  item.Timestamp.AsTime(),
-			Labels:     labels,
-			Comment:    item.Comment,
+			ItemId:         item.ItemId,
+			IsHidden:       item.IsHidden,
+			Categories:     item.Categories,
+			Timestamp:      item.Timestamp.AsTime(),
+			Labels:         labels,
+			Comment:        item.Comment,
+			AvailableFrom:  timeOrNil(item.AvailableFrom),
+			AvailableUntil: timeOrNil(item.AvailableUntil),
 		}
 	}
 	return resp.Cursor, items, nil
@@ -804,7 +851,27 @@ func (p ProxyClient) DeleteUserItemFeedback(ctx context.Context, userId, itemId
 	return int(resp.Count), nil
 }
 
-func (p ProxyClient) BatchInsertFeedback(ctx context.Context, feedback []Feedback, insertUser, insertItem, overwrite bool) error {
+// RenameFeedbackType is not supported by the proxy yet.
+func (p ProxyClient) RenameFeedbackType(_ context.Context, _, _ string) (int, error) {
+	return 0, errors.NotImplementedf("rename feedback type through proxy")
+}
+
+// GetRecentFeedback is not supported by the proxy yet.
+func (p ProxyClient) GetRecentFeedback(_ context.Context, _ int, _ ...string) ([]Feedback, error) {
+	return nil, errors.NotImplementedf("get recent feedback through proxy")
+}
+
+// CountUserFeedback is not supported by the proxy yet.
+func (p ProxyClient) CountUserFeedback(_ context.Context, _ string, _ ...string) (int, error) {
+	return 0, errors.NotImplementedf("count user feedback through proxy")
+}
+
+// BatchGetUsers is not supported by the proxy yet.
+func (p ProxyClient) BatchGetUsers(_ context.Context, _ []string) ([]User, error) {
+	return nil, errors.NotImplementedf("batch get users through proxy")
+}
+
+func (p ProxyClient) BatchInsertFeedback(ctx context.Context, feedback []Feedback, insertUser, insertItem, overwrite bool) ([]Feedback, error) {
 	reqFeedback := make([]*protocol.Feedback, len(feedback))
 	for i, f := range feedback {
 		reqFeedback[i] = &protocol.Feedback{
@@ -815,13 +882,28 @@ func (p ProxyClient) BatchInsertFeedback(ctx context.Context, feedback []Feedbac
 			Comment:      f.Comment,
 		}
 	}
-	_, err := p.DataStoreClient.BatchInsertFeedback(ctx, &protocol.BatchInsertFeedbackRequest{
+	resp, err := p.DataStoreClient.BatchInsertFeedback(ctx, &protocol.BatchInsertFeedbackRequest{
 		Feedback:   reqFeedback,
 		InsertUser: insertUser,
 		InsertItem: insertItem,
 		Overwrite:  overwrite,
 	})
-	return err
+	if err != nil {
+		return nil, err
+	}
+	inserted := make([]Feedback, len(resp.Feedback))
+	for i, f := range resp.Feedback {
+		inserted[i] = Feedback{
+			FeedbackKey: FeedbackKey{
+				FeedbackType: f.FeedbackType,
+				UserId:       f.UserId,
+				ItemId:       f.ItemId,
+			},
+			Timestamp: f.Timestamp.AsTime(),
+			Comment:   f.Comment,
+		}
+	}
+	return inserted, nil
 }
 
 func (p ProxyClient) GetFeedback(ctx context.Context, cursor string, n int, beginTime, endTime *time.Time, feedbackTypes ...string) (string, []Feedback, error) {
@@ -925,12 +1007,14 @@ func (p ProxyClient) GetItemStream(ctx context.Context, batchSize int, timeLimit
 					return
 				}
 				items[i] = Item{
-					ItemId:     item.ItemId,
-					IsHidden:   item.IsHidden,
-					Categories: item.Categories,
-					Timestamp:  item.Timestamp.AsTime(),
-					Labels:     labels,
-					Comment:    item.Comment,
+					ItemId:         item.ItemId,
+					IsHidden:       item.IsHidden,
+					Categories:     item.Categories,
+					Timestamp:      item.Timestamp.AsTime(),
+					Labels:         labels,
+					Comment:        item.Comment,
+					AvailableFrom:  timeOrNil(item.AvailableFrom),
+					AvailableUntil: timeOrNil(item.AvailableUntil),
 				}
 			}
 			itemsChan <- items
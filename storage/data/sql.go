@@ -19,6 +19,7 @@ import (
 	"database/sql"
 	"encoding/base64"
 	"fmt"
+	"strings"
 	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
@@ -53,6 +54,9 @@ type SQLItem struct {
 	Timestamp  time.Time `gorm:"column:time_stamp"`
 	Labels     string    `gorm:"column:labels"`
 	Comment    string    `gorm:"column:comment"`
+
+	AvailableFrom  *time.Time `gorm:"column:available_from"`
+	AvailableUntil *time.Time `gorm:"column:available_until"`
 }
 
 func NewSQLItem(item Item) (sqlItem SQLItem) {
@@ -65,6 +69,8 @@ func NewSQLItem(item Item) (sqlItem SQLItem) {
 	buf, _ = jsonutil.Marshal(item.Labels)
 	sqlItem.Labels = string(buf)
 	sqlItem.Comment = item.Comment
+	sqlItem.AvailableFrom = item.AvailableFrom
+	sqlItem.AvailableUntil = item.AvailableUntil
 	return
 }
 
@@ -94,10 +100,20 @@ type ClickHouseItem struct {
 func NewClickHouseItem(item Item) (clickHouseItem ClickHouseItem) {
 	clickHouseItem.SQLItem = NewSQLItem(item)
 	clickHouseItem.Timestamp = item.Timestamp.In(time.UTC)
+	clickHouseItem.AvailableFrom = inUTC(item.AvailableFrom)
+	clickHouseItem.AvailableUntil = inUTC(item.AvailableUntil)
 	clickHouseItem.Version = time.Now().In(time.UTC)
 	return
 }
 
+// inUTC converts an optional time to UTC.
+func inUTC(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	return lo.ToPtr(t.In(time.UTC))
+}
+
 type ClickhouseUser struct {
 	SQLUser `gorm:"embedded"`
 	Version time.Time `gorm:"column:version"`
@@ -117,9 +133,12 @@ type ClickHouseFeedback struct {
 // SQLDatabase use MySQL as data storage.
 type SQLDatabase struct {
 	storage.TablePrefix
-	gormDB *gorm.DB
-	client *sql.DB
-	driver SQLDriver
+	gormDB              *gorm.DB
+	client              *sql.DB
+	driver              SQLDriver
+	feedbackDedupWindow time.Duration
+	maxUserLabels       int
+	truncateUserLabels  bool
 }
 
 // Optimize is used by ClickHouse only.
@@ -147,6 +166,9 @@ func (d *SQLDatabase) Init() error {
 			Timestamp  time.Time `gorm:"column:time_stamp;type:datetime;not null"`
 			Labels     []string  `gorm:"column:labels;type:json;not null"`
 			Comment    string    `gorm:"column:comment;type:text;not null"`
+
+			AvailableFrom  *time.Time `gorm:"column:available_from;type:datetime"`
+			AvailableUntil *time.Time `gorm:"column:available_until;type:datetime"`
 		}
 		type Users struct {
 			UserId    string   `gorm:"column:user_id;type:varchar(256);not null;primaryKey"`
@@ -174,6 +196,9 @@ func (d *SQLDatabase) Init() error {
 			Timestamp  time.Time `gorm:"column:time_stamp;type:timestamptz;not null"`
 			Labels     string    `gorm:"column:labels;type:json;not null;default:'[]'"`
 			Comment    string    `gorm:"column:comment;type:text;not null;default:''"`
+
+			AvailableFrom  *time.Time `gorm:"column:available_from;type:timestamptz"`
+			AvailableUntil *time.Time `gorm:"column:available_until;type:timestamptz"`
 		}
 		type Users struct {
 			UserId    string `gorm:"column:user_id;type:varchar(256) not null;primaryKey"`
@@ -201,6 +226,9 @@ func (d *SQLDatabase) Init() error {
 			Timestamp  string `gorm:"column:time_stamp;type:datetime;not null;default:'0001-01-01'"`
 			Labels     string `gorm:"column:labels;type:json;not null;default:'[]'"`
 			Comment    string `gorm:"column:comment;type:text;not null;default:''"`
+
+			AvailableFrom  *string `gorm:"column:available_from;type:datetime"`
+			AvailableUntil *string `gorm:"column:available_until;type:datetime"`
 		}
 		type Users struct {
 			UserId    string `gorm:"column:user_id;type:varchar(256) not null;primaryKey"`
@@ -229,6 +257,9 @@ func (d *SQLDatabase) Init() error {
 			Labels     string    `gorm:"column:labels;type:String;default:'[]'"`
 			Comment    string    `gorm:"column:comment;type:String"`
 			Version    struct{}  `gorm:"column:version;type:DateTime"`
+
+			AvailableFrom  *time.Time `gorm:"column:available_from;type:Nullable(Datetime64(9,'UTC'))"`
+			AvailableUntil *time.Time `gorm:"column:available_until;type:Nullable(Datetime64(9,'UTC'))"`
 		}
 		err := d.gormDB.Set("gorm:table_options", "ENGINE = ReplacingMergeTree(version) ORDER BY item_id").AutoMigrate(Items{})
 		if err != nil {
@@ -337,13 +368,15 @@ func (d *SQLDatabase) BatchInsertItems(ctx context.Context, items []Item) error
 				row := NewSQLItem(item)
 				if d.driver == SQLite {
 					row.Timestamp = row.Timestamp.In(time.UTC)
+					row.AvailableFrom = inUTC(row.AvailableFrom)
+					row.AvailableUntil = inUTC(row.AvailableUntil)
 				}
 				rows = append(rows, row)
 			}
 		}
 		err := d.gormDB.WithContext(ctx).Clauses(clause.OnConflict{
 			Columns:   []clause.Column{{Name: "item_id"}},
-			DoUpdates: clause.AssignmentColumns([]string{"is_hidden", "categories", "time_stamp", "labels", "comment"}),
+			DoUpdates: clause.AssignmentColumns([]string{"is_hidden", "categories", "time_stamp", "labels", "comment", "available_from", "available_until"}),
 		}).Create(rows).Error
 		return errors.Trace(err)
 	}
@@ -355,7 +388,7 @@ func (d *SQLDatabase) BatchGetItems(ctx context.Context, itemIds []string) ([]It
 	}
 	result, err := d.gormDB.WithContext(ctx).
 		Table(d.ItemsTable()).
-		Select("item_id, is_hidden, categories, time_stamp, labels, comment").
+		Select("item_id, is_hidden, categories, time_stamp, labels, comment, available_from, available_until").
 		Where("item_id IN ?", itemIds).Rows()
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -397,7 +430,7 @@ func (d *SQLDatabase) GetItem(ctx context.Context, itemId string) (Item, error)
 	var err error
 	result, err = d.gormDB.WithContext(ctx).
 		Table(d.ItemsTable()).
-		Select("item_id, is_hidden, categories, time_stamp, labels, comment").
+		Select("item_id, is_hidden, categories, time_stamp, labels, comment, available_from, available_until").
 		Where("item_id = ?", itemId).Rows()
 	if err != nil {
 		return Item{}, errors.Trace(err)
@@ -460,7 +493,7 @@ func (d *SQLDatabase) GetItems(ctx context.Context, cursor string, n int, timeLi
 	cursorItem := string(buf)
 	tx := d.gormDB.WithContext(ctx).
 		Table(d.ItemsTable()).
-		Select("item_id, is_hidden, categories, time_stamp, labels, comment")
+		Select("item_id, is_hidden, categories, time_stamp, labels, comment, available_from, available_until")
 	if cursorItem != "" {
 		tx.Where("item_id >= ?", cursorItem)
 	}
@@ -486,6 +519,59 @@ func (d *SQLDatabase) GetItems(ctx context.Context, cursor string, n int, timeLi
 	return "", items, nil
 }
 
+// SearchItemsByLabel returns items whose label equals the value or contains the value.
+func (d *SQLDatabase) SearchItemsByLabel(ctx context.Context, name, value, cursor string, n int) (string, []Item, error) {
+	if d.driver == ClickHouse {
+		return scanItemsByLabel(ctx, d, name, value, cursor, n)
+	}
+	buf, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	cursorItem := string(buf)
+	keys := SplitLabelPath(name)
+	path := "$"
+	for _, key := range keys {
+		path += fmt.Sprintf(".%q", key)
+	}
+	candidate, err := jsonutil.Marshal(value)
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	tx := d.gormDB.WithContext(ctx).
+		Table(d.ItemsTable()).
+		Select("item_id, is_hidden, categories, time_stamp, labels, comment, available_from, available_until")
+	if cursorItem != "" {
+		tx.Where("item_id >= ?", cursorItem)
+	}
+	switch d.driver {
+	case MySQL:
+		tx.Where("JSON_CONTAINS(labels, ?, ?)", string(candidate), path)
+	case Postgres:
+		args := lo.Map(keys, func(key string, _ int) any { return key })
+		tx.Where("(labels::jsonb"+strings.Repeat(" -> ?", len(keys))+") @> ?::jsonb", append(args, string(candidate))...)
+	case SQLite:
+		tx.Where("EXISTS (SELECT 1 FROM json_each(labels, ?) WHERE json_each.value = ?)", path, value)
+	}
+	result, err := tx.Order("item_id").Limit(n + 1).Rows()
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	items := make([]Item, 0)
+	defer result.Close()
+	for result.Next() {
+		var item Item
+		if err = d.gormDB.ScanRows(result, &item); err != nil {
+			return "", nil, errors.Trace(err)
+		}
+		items = append(items, item)
+	}
+	if len(items) == n+1 {
+		return base64.StdEncoding.EncodeToString([]byte(items[len(items)-1].ItemId)), items[:len(items)-1], nil
+	}
+	return "", items, nil
+}
+
 // GetItemStream reads items by stream.
 func (d *SQLDatabase) GetItemStream(ctx context.Context, batchSize int, timeLimit *time.Time) (chan []Item, chan error) {
 	itemChan := make(chan []Item, bufSize)
@@ -496,7 +582,7 @@ func (d *SQLDatabase) GetItemStream(ctx context.Context, batchSize int, timeLimi
 		// send query
 		tx := d.gormDB.WithContext(ctx).
 			Table(d.ItemsTable()).
-			Select("item_id, is_hidden, categories, time_stamp, labels, comment")
+			Select("item_id, is_hidden, categories, time_stamp, labels, comment, available_from, available_until")
 		if timeLimit != nil {
 			tx.Where("time_stamp >= ?", *timeLimit)
 		}
@@ -570,25 +656,22 @@ func (d *SQLDatabase) BatchInsertUsers(ctx context.Context, users []User) error
 	if len(users) == 0 {
 		return nil
 	}
+	// the last user wins if an id appears more than once
+	users = distinctUsers(users)
+	if err := LimitUserLabels(users, d.maxUserLabels, d.truncateUserLabels); err != nil {
+		return errors.Trace(err)
+	}
 	if d.driver == ClickHouse {
 		rows := make([]ClickhouseUser, 0, len(users))
-		memo := mapset.NewSet[string]()
 		for _, user := range users {
-			if !memo.Contains(user.UserId) {
-				memo.Add(user.UserId)
-				rows = append(rows, NewClickhouseUser(user))
-			}
+			rows = append(rows, NewClickhouseUser(user))
 		}
 		err := d.gormDB.Create(rows).Error
 		return errors.Trace(err)
 	} else {
 		rows := make([]SQLUser, 0, len(users))
-		memo := mapset.NewSet[string]()
 		for _, user := range users {
-			if !memo.Contains(user.UserId) {
-				memo.Add(user.UserId)
-				rows = append(rows, NewSQLUser(user))
-			}
+			rows = append(rows, NewSQLUser(user))
 		}
 		err := d.gormDB.WithContext(ctx).Clauses(clause.OnConflict{
 			Columns:   []clause.Column{{Name: "user_id"}},
@@ -638,6 +721,29 @@ func (d *SQLDatabase) GetUser(ctx context.Context, userId string) (User, error)
 	return User{}, errors.Annotate(ErrUserNotExist, userId)
 }
 
+// BatchGetUsers returns users from MySQL. Missing users are skipped.
+func (d *SQLDatabase) BatchGetUsers(ctx context.Context, userIds []string) ([]User, error) {
+	if len(userIds) == 0 {
+		return nil, nil
+	}
+	result, err := d.gormDB.WithContext(ctx).Table(d.UsersTable()).
+		Select("user_id, labels, subscribe, comment").
+		Where("user_id IN ?", userIds).Rows()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer result.Close()
+	var users []User
+	for result.Next() {
+		var user User
+		if err = d.gormDB.ScanRows(result, &user); err != nil {
+			return nil, errors.Trace(err)
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
 // ModifyUser modify a user in MySQL.
 func (d *SQLDatabase) ModifyUser(ctx context.Context, userId string, patch UserPatch) error {
 	// ignore empty patch
@@ -650,7 +756,11 @@ func (d *SQLDatabase) ModifyUser(ctx context.Context, userId string, patch UserP
 		attributes["comment"] = *patch.Comment
 	}
 	if patch.Labels != nil {
-		text, _ := jsonutil.Marshal(patch.Labels)
+		labels, err := limitLabels(userId, patch.Labels, d.maxUserLabels, d.truncateUserLabels)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		text, _ := jsonutil.Marshal(labels)
 		attributes["labels"] = string(text)
 	}
 	if patch.Subscribe != nil {
@@ -761,14 +871,44 @@ func (d *SQLDatabase) GetUserFeedback(ctx context.Context, userId string, endTim
 	return feedbacks, nil
 }
 
+// CountUserFeedback returns the number of feedback of a user without loading feedback.
+func (d *SQLDatabase) CountUserFeedback(ctx context.Context, userId string, feedbackTypes ...string) (int, error) {
+	tx := d.gormDB.WithContext(ctx)
+	if d.driver == ClickHouse {
+		tx = tx.Table(d.UserFeedbackTable())
+	} else {
+		tx = tx.Table(d.FeedbackTable())
+	}
+	tx.Where("user_id = ?", userId)
+	if len(feedbackTypes) > 0 {
+		tx.Where("feedback_type IN ?", feedbackTypes)
+	}
+	var count int64
+	if err := tx.Count(&count).Error; err != nil {
+		return 0, errors.Trace(err)
+	}
+	return int(count), nil
+}
+
 // BatchInsertFeedback insert a batch feedback into MySQL.
 // If insertUser set, new users will be inserted to user table.
 // If insertItem set, new items will be inserted to item table.
-func (d *SQLDatabase) BatchInsertFeedback(ctx context.Context, feedback []Feedback, insertUser, insertItem, overwrite bool) error {
+// Feedback which did not exist before is returned.
+func (d *SQLDatabase) BatchInsertFeedback(ctx context.Context, feedback []Feedback, insertUser, insertItem, overwrite bool) ([]Feedback, error) {
 	tx := d.gormDB.WithContext(ctx)
 	// skip empty list
 	if len(feedback) == 0 {
-		return nil
+		return nil, nil
+	}
+	// skip duplicate feedback
+	if d.feedbackDedupWindow > 0 {
+		var err error
+		if feedback, err = d.removeDuplicateFeedback(ctx, feedback); err != nil {
+			return nil, errors.Trace(err)
+		}
+		if len(feedback) == 0 {
+			return nil, nil
+		}
 	}
 	// collect users and items
 	users := mapset.NewSet[string]()
@@ -791,7 +931,7 @@ func (d *SQLDatabase) BatchInsertFeedback(ctx context.Context, feedback []Feedba
 				}
 			})).Error
 			if err != nil {
-				return errors.Trace(err)
+				return nil, errors.Trace(err)
 			}
 		} else {
 			err := tx.Clauses(clause.OnConflict{
@@ -805,19 +945,19 @@ func (d *SQLDatabase) BatchInsertFeedback(ctx context.Context, feedback []Feedba
 				}
 			})).Error
 			if err != nil {
-				return errors.Trace(err)
+				return nil, errors.Trace(err)
 			}
 		}
 	} else {
 		for _, user := range users.ToSlice() {
 			rs, err := tx.Table(d.UsersTable()).Select("user_id").Where("user_id = ?", user).Rows()
 			if err != nil {
-				return errors.Trace(err)
+				return nil, errors.Trace(err)
 			} else if !rs.Next() {
 				users.Remove(user)
 			}
 			if err = rs.Close(); err != nil {
-				return errors.Trace(err)
+				return nil, errors.Trace(err)
 			}
 		}
 	}
@@ -835,7 +975,7 @@ func (d *SQLDatabase) BatchInsertFeedback(ctx context.Context, feedback []Feedba
 				}
 			})).Error
 			if err != nil {
-				return errors.Trace(err)
+				return nil, errors.Trace(err)
 			}
 		} else {
 			err := tx.Clauses(clause.OnConflict{
@@ -849,67 +989,108 @@ func (d *SQLDatabase) BatchInsertFeedback(ctx context.Context, feedback []Feedba
 				}
 			})).Error
 			if err != nil {
-				return errors.Trace(err)
+				return nil, errors.Trace(err)
 			}
 		}
 	} else {
 		for _, item := range items.ToSlice() {
 			rs, err := tx.Table(d.ItemsTable()).Select("item_id").Where("item_id = ?", item).Rows()
 			if err != nil {
-				return errors.Trace(err)
+				return nil, errors.Trace(err)
 			} else if !rs.Next() {
 				items.Remove(item)
 			}
 			if err = rs.Close(); err != nil {
-				return errors.Trace(err)
+				return nil, errors.Trace(err)
 			}
 		}
 	}
+	// collect feedback of existing users and items
+	rows := make([]Feedback, 0, len(feedback))
+	memo := mapset.NewSet[FeedbackKey]()
+	for _, f := range feedback {
+		if users.Contains(f.UserId) && items.Contains(f.ItemId) && !memo.Contains(f.FeedbackKey) {
+			memo.Add(f.FeedbackKey)
+			rows = append(rows, f)
+		}
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	// find feedback which did not exist before
+	existed, err := d.getFeedbackTimestamps(ctx, rows)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	inserted := lo.Filter(rows, func(f Feedback, _ int) bool {
+		_, exist := existed[f.FeedbackKey]
+		return !exist
+	})
 	// insert feedback
 	if d.driver == ClickHouse {
-		rows := make([]ClickHouseFeedback, 0, len(feedback))
-		memo := make(map[lo.Tuple3[string, string, string]]struct{})
-		for _, f := range feedback {
-			if users.Contains(f.UserId) && items.Contains(f.ItemId) {
-				if _, exist := memo[lo.Tuple3[string, string, string]{f.FeedbackType, f.UserId, f.ItemId}]; !exist {
-					memo[lo.Tuple3[string, string, string]{f.FeedbackType, f.UserId, f.ItemId}] = struct{}{}
-					f.Timestamp = f.Timestamp.In(time.UTC)
-					rows = append(rows, ClickHouseFeedback{
-						Feedback: f,
-						Version:  lo.If(overwrite, time.Now().In(time.UTC)).Else(time.Time{}),
-					})
-				}
+		err = tx.Create(lo.Map(rows, func(f Feedback, _ int) ClickHouseFeedback {
+			f.Timestamp = f.Timestamp.In(time.UTC)
+			return ClickHouseFeedback{
+				Feedback: f,
+				Version:  lo.If(overwrite, time.Now().In(time.UTC)).Else(time.Time{}),
 			}
-		}
-		if len(rows) == 0 {
-			return nil
-		}
-		err := tx.Create(rows).Error
-		return errors.Trace(err)
+		})).Error
 	} else {
-		rows := make([]Feedback, 0, len(feedback))
-		memo := make(map[lo.Tuple3[string, string, string]]struct{})
-		for _, f := range feedback {
-			if users.Contains(f.UserId) && items.Contains(f.ItemId) {
-				if _, exist := memo[lo.Tuple3[string, string, string]{f.FeedbackType, f.UserId, f.ItemId}]; !exist {
-					memo[lo.Tuple3[string, string, string]{f.FeedbackType, f.UserId, f.ItemId}] = struct{}{}
-					if d.driver == SQLite {
-						f.Timestamp = f.Timestamp.In(time.UTC)
-					}
-					rows = append(rows, f)
-				}
-			}
+		if d.driver == SQLite {
+			rows = lo.Map(rows, func(f Feedback, _ int) Feedback {
+				f.Timestamp = f.Timestamp.In(time.UTC)
+				return f
+			})
 		}
-		if len(rows) == 0 {
-			return nil
-		}
-		err := tx.Clauses(clause.OnConflict{
+		err = tx.Clauses(clause.OnConflict{
 			Columns:   []clause.Column{{Name: "feedback_type"}, {Name: "user_id"}, {Name: "item_id"}},
 			DoNothing: !overwrite,
 			DoUpdates: lo.If(overwrite, clause.AssignmentColumns([]string{"time_stamp", "comment"})).Else(nil),
 		}).Create(rows).Error
-		return errors.Trace(err)
 	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return inserted, nil
+}
+
+// removeDuplicateFeedback removes feedback if identical feedback exists within the deduplication window.
+// Existing feedback of the whole batch is fetched by a single query.
+func (d *SQLDatabase) removeDuplicateFeedback(ctx context.Context, feedback []Feedback) ([]Feedback, error) {
+	existed, err := d.getFeedbackTimestamps(ctx, feedback)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return lo.Filter(feedback, func(f Feedback, _ int) bool {
+		return !lo.ContainsBy(existed[f.FeedbackKey], func(t time.Time) bool {
+			return isWithinWindow(f.Timestamp, t, d.feedbackDedupWindow)
+		})
+	}), nil
+}
+
+// getFeedbackTimestamps returns timestamps of existing feedback with the same keys as the given feedback.
+func (d *SQLDatabase) getFeedbackTimestamps(ctx context.Context, feedback []Feedback) (map[FeedbackKey][]time.Time, error) {
+	keys := make([][]any, 0, len(feedback))
+	for _, f := range feedback {
+		keys = append(keys, []any{f.FeedbackType, f.UserId, f.ItemId})
+	}
+	result, err := d.gormDB.WithContext(ctx).Table(d.FeedbackTable()).
+		Select("feedback_type, user_id, item_id, time_stamp").
+		Where("(feedback_type, user_id, item_id) IN ?", keys).
+		Rows()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer result.Close()
+	existed := make(map[FeedbackKey][]time.Time)
+	for result.Next() {
+		var f Feedback
+		if err = d.gormDB.ScanRows(result, &f); err != nil {
+			return nil, errors.Trace(err)
+		}
+		existed[f.FeedbackKey] = append(existed[f.FeedbackKey], f.Timestamp)
+	}
+	return existed, nil
 }
 
 // GetFeedback returns feedback from MySQL.
@@ -960,6 +1141,29 @@ func (d *SQLDatabase) GetFeedback(ctx context.Context, cursor string, n int, beg
 	return "", feedbacks, nil
 }
 
+// GetRecentFeedback returns the most recent feedback from MySQL.
+func (d *SQLDatabase) GetRecentFeedback(ctx context.Context, n int, feedbackTypes ...string) ([]Feedback, error) {
+	tx := d.gormDB.WithContext(ctx).Table(d.FeedbackTable()).Select("feedback_type, user_id, item_id, time_stamp, comment")
+	if len(feedbackTypes) > 0 {
+		tx.Where("feedback_type IN ?", feedbackTypes)
+	}
+	tx.Order("time_stamp DESC").Limit(n)
+	result, err := tx.Rows()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	feedbacks := make([]Feedback, 0)
+	defer result.Close()
+	for result.Next() {
+		var feedback Feedback
+		if err = d.gormDB.ScanRows(result, &feedback); err != nil {
+			return nil, errors.Trace(err)
+		}
+		feedbacks = append(feedbacks, feedback)
+	}
+	return feedbacks, nil
+}
+
 // GetFeedbackStream reads feedback by stream.
 func (d *SQLDatabase) GetFeedbackStream(ctx context.Context, batchSize int, scanOptions ...ScanOption) (chan []Feedback, chan error) {
 	scan := NewScanOptions(scanOptions...)
@@ -1085,6 +1289,55 @@ func (d *SQLDatabase) DeleteUserItemFeedback(ctx context.Context, userId, itemId
 	return rowAffected, nil
 }
 
+// Transaction runs f in a transaction of MySQL, Postgres or SQLite. ClickHouse doesn't support transactions, so f
+// writes to ClickHouse directly.
+func (d *SQLDatabase) Transaction(ctx context.Context, f func(tx Database) error) error {
+	if d.driver == ClickHouse {
+		return f(d)
+	}
+	return d.gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return f(&SQLDatabase{
+			TablePrefix:         d.TablePrefix,
+			gormDB:              tx,
+			client:              d.client,
+			driver:              d.driver,
+			feedbackDedupWindow: d.feedbackDedupWindow,
+			maxUserLabels:       d.maxUserLabels,
+			truncateUserLabels:  d.truncateUserLabels,
+		})
+	})
+}
+
+// RenameFeedbackType renames a feedback type in MySQL. Feedback conflicting with existed feedback of the new type
+// is removed.
+func (d *SQLDatabase) RenameFeedbackType(ctx context.Context, from, to string) (int, error) {
+	if d.driver == ClickHouse {
+		return 0, errors.NotSupportedf("rename feedback type in ClickHouse")
+	}
+	var rowAffected int
+	err := d.gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// remove feedback conflicting with feedback of the new type
+		result := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE feedback_type = ? AND (user_id, item_id) IN "+
+			"(SELECT user_id, item_id FROM (SELECT user_id, item_id FROM %s WHERE feedback_type = ?) AS t)",
+			d.FeedbackTable(), d.FeedbackTable()), from, to)
+		if result.Error != nil {
+			return errors.Trace(result.Error)
+		}
+		rowAffected += int(result.RowsAffected)
+		// rename remained feedback
+		result = tx.Table(d.FeedbackTable()).Where("feedback_type = ?", from).Update("feedback_type", to)
+		if result.Error != nil {
+			return errors.Trace(result.Error)
+		}
+		rowAffected += int(result.RowsAffected)
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return rowAffected, nil
+}
+
 func (d *SQLDatabase) convertTimeZone(timestamp *time.Time) time.Time {
 	switch d.driver {
 	case ClickHouse, SQLite:
@@ -133,6 +133,14 @@ func (suite *ClickHouseTestSuite) SetupSuite() {
 	suite.NoError(err)
 }
 
+func (suite *ClickHouseTestSuite) TestRenameFeedbackType() {
+	suite.T().Skip()
+}
+
+func (suite *ClickHouseTestSuite) TestTransaction() {
+	suite.T().Skip()
+}
+
 func TestClickHouse(t *testing.T) {
 	suite.Run(t, new(ClickHouseTestSuite))
 }
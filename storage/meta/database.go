@@ -24,6 +24,10 @@ import (
 	"time"
 )
 
+const (
+	FeedbackTypes = "feedback_types"
+)
+
 type Node struct {
 	UUID       string
 	Hostname   string
@@ -32,11 +36,27 @@ type Node struct {
 	UpdateTime time.Time
 }
 
+// AuditLog is a record of a destructive action performed on the dashboard.
+type AuditLog struct {
+	Id        int64
+	Actor     string
+	Action    string
+	Target    string
+	Timestamp time.Time
+}
+
 type Database interface {
 	Close() error
 	Init() error
 	UpdateNode(node *Node) error
 	ListNodes() ([]*Node, error)
+	ListAllNodes() ([]*Node, error)
+	ListNodesPage(cursor string, n int) (string, []*Node, error)
+	DeleteNode(uuid string) error
+	Get(key string) (*string, error)
+	Put(key, value string) error
+	AddAuditLog(log *AuditLog) error
+	ListAuditLogs(cursor string, n int) (string, []*AuditLog, error)
 }
 
 // Open a connection to a database.
@@ -15,6 +15,8 @@
 package meta
 
 import (
+	"fmt"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/suite"
 	"time"
 )
@@ -62,3 +64,90 @@ func (suite *baseTestSuite) TestNodes() {
 		suite.Equal("v0.1.1", nodes[0].Version)
 	}
 }
+
+func (suite *baseTestSuite) TestAllNodes() {
+	// Add nodes
+	err := suite.Database.UpdateNode(&Node{UUID: "node-1", Type: "master", UpdateTime: time.Now()})
+	suite.NoError(err)
+	err = suite.Database.UpdateNode(&Node{UUID: "node-2", Type: "worker", UpdateTime: time.Now().Add(-time.Hour)})
+	suite.NoError(err)
+	// List all nodes
+	nodes, err := suite.Database.ListAllNodes()
+	suite.NoError(err)
+	suite.ElementsMatch([]string{"node-1", "node-2"}, lo.Map(nodes, func(node *Node, _ int) string { return node.UUID }))
+	// Delete node
+	err = suite.Database.DeleteNode("node-2")
+	suite.NoError(err)
+	nodes, err = suite.Database.ListAllNodes()
+	suite.NoError(err)
+	suite.Equal([]string{"node-1"}, lo.Map(nodes, func(node *Node, _ int) string { return node.UUID }))
+}
+
+func (suite *baseTestSuite) TestKeyValues() {
+	// Get non-existent key
+	value, err := suite.Database.Get("key")
+	suite.NoError(err)
+	suite.Nil(value)
+	// Put key
+	err = suite.Database.Put("key", "value")
+	suite.NoError(err)
+	value, err = suite.Database.Get("key")
+	suite.NoError(err)
+	suite.Equal(lo.ToPtr("value"), value)
+	// Overwrite key
+	err = suite.Database.Put("key", "new_value")
+	suite.NoError(err)
+	value, err = suite.Database.Get("key")
+	suite.NoError(err)
+	suite.Equal(lo.ToPtr("new_value"), value)
+}
+
+func (suite *baseTestSuite) TestNodesPage() {
+	// Add nodes
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		err := suite.Database.UpdateNode(&Node{UUID: fmt.Sprintf("node-%d", i), UpdateTime: now.Add(-time.Duration(i) * time.Hour)})
+		suite.NoError(err)
+	}
+	// List nodes by pages
+	cursor, nodes, err := suite.Database.ListNodesPage("", 3)
+	suite.NoError(err)
+	suite.Equal("node-2", cursor)
+	suite.Equal([]string{"node-0", "node-1", "node-2"}, lo.Map(nodes, func(node *Node, _ int) string { return node.UUID }))
+	// Heartbeats don't shift pages
+	err = suite.Database.UpdateNode(&Node{UUID: "node-4", UpdateTime: now.Add(time.Hour)})
+	suite.NoError(err)
+	cursor, nodes, err = suite.Database.ListNodesPage(cursor, 3)
+	suite.NoError(err)
+	suite.Empty(cursor)
+	suite.Equal([]string{"node-3", "node-4"}, lo.Map(nodes, func(node *Node, _ int) string { return node.UUID }))
+}
+
+func (suite *baseTestSuite) TestAuditLogs() {
+	// Add audit logs
+	now := time.Now().Truncate(time.Second)
+	for i := 0; i < 5; i++ {
+		log := &AuditLog{Actor: "admin", Action: fmt.Sprintf("action-%d", i), Target: "target", Timestamp: now}
+		err := suite.Database.AddAuditLog(log)
+		suite.NoError(err)
+		suite.Equal(int64(i+1), log.Id)
+	}
+	// List audit logs by pages
+	cursor, logs, err := suite.Database.ListAuditLogs("", 3)
+	suite.NoError(err)
+	suite.Equal("3", cursor)
+	suite.Equal([]string{"action-4", "action-3", "action-2"}, lo.Map(logs, func(log *AuditLog, _ int) string { return log.Action }))
+	suite.Equal("admin", logs[0].Actor)
+	suite.Equal("target", logs[0].Target)
+	suite.True(now.Equal(logs[0].Timestamp))
+	// logs appended while paging don't shift pages
+	err = suite.Database.AddAuditLog(&AuditLog{Actor: "admin", Action: "action-5", Target: "target", Timestamp: now})
+	suite.NoError(err)
+	cursor, logs, err = suite.Database.ListAuditLogs(cursor, 3)
+	suite.NoError(err)
+	suite.Empty(cursor)
+	suite.Equal([]string{"action-1", "action-0"}, lo.Map(logs, func(log *AuditLog, _ int) string { return log.Action }))
+	// n must be positive
+	_, _, err = suite.Database.ListAuditLogs("", 0)
+	suite.Error(err)
+}
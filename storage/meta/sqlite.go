@@ -16,8 +16,11 @@ package meta
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"math"
 	_ "modernc.org/sqlite"
+	"strconv"
 	"time"
 )
 
@@ -51,6 +54,23 @@ CREATE TABLE IF NOT EXISTS cron_jobs (
 	start_time TIMESTAMP,
 	end_time TIMESTAMP,
 	update_time TIMESTAMP
+);`); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`
+CREATE TABLE IF NOT EXISTS key_values (
+	name TEXT PRIMARY KEY,
+	value TEXT
+);`); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`
+CREATE TABLE IF NOT EXISTS audit_logs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	actor TEXT,
+	action TEXT,
+	target TEXT,
+	timestamp TIMESTAMP
 );`); err != nil {
 		return err
 	}
@@ -88,11 +108,125 @@ WHERE update_time > datetime('now', ?)
 		}
 		nodes = append(nodes, &node)
 	}
-	// Delete outdated nodes
-	if _, err = s.db.Exec(`
-DELETE FROM nodes WHERE update_time < datetime('now', ?)
-`, fmt.Sprintf("-%.0f seconds", s.ttl.Seconds())); err != nil {
+	return nodes, nil
+}
+
+func (s *SQLite) ListAllNodes() ([]*Node, error) {
+	// List nodes including outdated nodes
+	rs, err := s.db.Query(`SELECT uuid, hostname, type, version, update_time FROM nodes`)
+	if err != nil {
 		return nil, err
 	}
+	defer rs.Close()
+	var nodes []*Node
+	for rs.Next() {
+		var node Node
+		if err = rs.Scan(&node.UUID, &node.Hostname, &node.Type, &node.Version, &node.UpdateTime); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, &node)
+	}
 	return nodes, nil
 }
+
+// ListNodesPage lists nodes including outdated nodes sorted by UUID. The cursor is the UUID of the last node in the
+// previous page, and the returned cursor is empty if there are no more nodes.
+func (s *SQLite) ListNodesPage(cursor string, n int) (string, []*Node, error) {
+	rs, err := s.db.Query(`
+SELECT uuid, hostname, type, version, update_time FROM nodes
+WHERE uuid > ?
+ORDER BY uuid
+LIMIT ?
+`, cursor, n+1)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rs.Close()
+	var nodes []*Node
+	for rs.Next() {
+		var node Node
+		if err = rs.Scan(&node.UUID, &node.Hostname, &node.Type, &node.Version, &node.UpdateTime); err != nil {
+			return "", nil, err
+		}
+		nodes = append(nodes, &node)
+	}
+	if len(nodes) > n {
+		return nodes[n-1].UUID, nodes[:n], nil
+	}
+	return "", nodes, nil
+}
+
+func (s *SQLite) DeleteNode(uuid string) error {
+	_, err := s.db.Exec(`DELETE FROM nodes WHERE uuid = ?`, uuid)
+	return err
+}
+
+// Get returns the value of a key. Nil is returned if the key doesn't exist.
+func (s *SQLite) Get(key string) (*string, error) {
+	var value string
+	err := s.db.QueryRow(`SELECT value FROM key_values WHERE name = ?`, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+func (s *SQLite) Put(key, value string) error {
+	_, err := s.db.Exec(`
+INSERT INTO key_values (name, value)
+VALUES (?, ?)
+ON CONFLICT(name) DO UPDATE SET
+	value = excluded.value
+`, key, value)
+	return err
+}
+
+// AddAuditLog appends an audit log. The id of the log is assigned by the database.
+func (s *SQLite) AddAuditLog(log *AuditLog) error {
+	result, err := s.db.Exec(`INSERT INTO audit_logs (actor, action, target, timestamp) VALUES (?, ?, ?, ?)`,
+		log.Actor, log.Action, log.Target, log.Timestamp.UTC())
+	if err != nil {
+		return err
+	}
+	log.Id, err = result.LastInsertId()
+	return err
+}
+
+// ListAuditLogs lists audit logs from the newest to the oldest. The cursor is the id of the last log in the previous
+// page, so logs appended while paging don't shift pages. The returned cursor is empty if there are no more logs.
+func (s *SQLite) ListAuditLogs(cursor string, n int) (string, []*AuditLog, error) {
+	if n <= 0 {
+		return "", nil, fmt.Errorf("invalid n `%d`", n)
+	}
+	before := int64(math.MaxInt64)
+	if cursor != "" {
+		var err error
+		if before, err = strconv.ParseInt(cursor, 10, 64); err != nil {
+			return "", nil, err
+		}
+	}
+	rs, err := s.db.Query(`
+SELECT id, actor, action, target, timestamp FROM audit_logs
+WHERE id < ?
+ORDER BY id DESC
+LIMIT ?
+`, before, n+1)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rs.Close()
+	var logs []*AuditLog
+	for rs.Next() {
+		var log AuditLog
+		if err = rs.Scan(&log.Id, &log.Actor, &log.Action, &log.Target, &log.Timestamp); err != nil {
+			return "", nil, err
+		}
+		logs = append(logs, &log)
+	}
+	if len(logs) > n {
+		return strconv.FormatInt(logs[n-1].Id, 10), logs[:n], nil
+	}
+	return "", logs, nil
+}
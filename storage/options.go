@@ -1,7 +1,12 @@
 package storage
 
+import "time"
+
 type Options struct {
-	IsolationLevel string
+	IsolationLevel      string
+	FeedbackDedupWindow time.Duration
+	MaxLabelsPerUser    int
+	TruncateLabels      bool
 }
 
 type Option func(*Options)
@@ -12,6 +17,22 @@ func WithIsolationLevel(isolationLevel string) Option {
 	}
 }
 
+// WithFeedbackDedupWindow sets the time window to deduplicate feedback on insert. Zero disables deduplication.
+func WithFeedbackDedupWindow(window time.Duration) Option {
+	return func(o *Options) {
+		o.FeedbackDedupWindow = window
+	}
+}
+
+// WithUserLabelLimit sets the maximum number of labels per user. Exceeded labels are truncated if truncate is true,
+// otherwise users are rejected. Zero means unlimited.
+func WithUserLabelLimit(maxLabels int, truncate bool) Option {
+	return func(o *Options) {
+		o.MaxLabelsPerUser = maxLabels
+		o.TruncateLabels = truncate
+	}
+}
+
 func NewOptions(opts ...Option) Options {
 	opt := Options{
 		IsolationLevel: "READ-UNCOMMITTED",
@@ -506,6 +506,14 @@ func (w *Worker) Recommend(users []data.User) {
 	MemoryInuseBytesVec.WithLabelValues("item_cache").Set(float64(sizeof.DeepSize(itemCache)))
 	defer MemoryInuseBytesVec.WithLabelValues("item_cache").Set(0)
 
+	// pull frozen users from cache
+	frozenUsers, err := w.CacheClient.GetSet(ctx, cache.FrozenUsers)
+	if err != nil {
+		log.Logger().Error("failed to pull frozen users", zap.Error(err))
+		return
+	}
+	frozenUserSet := mapset.NewSet(frozenUsers...)
+
 	// progress tracker
 	completed := make(chan struct{}, 1000)
 	_, span := w.tracer.Start(context.Background(), "Recommend", len(users))
@@ -570,7 +578,7 @@ func (w *Worker) Recommend(users []data.User) {
 		popularRecommendSeconds       atomic.Float64
 	)
 
-	userFeedbackCache := NewFeedbackCache(w, w.Config.Recommend.DataSource.PositiveFeedbackTypes...)
+	userFeedbackCache := NewFeedbackCache(w, w.FeedbackTypes().Positive...)
 	defer MemoryInuseBytesVec.WithLabelValues("user_feedback_cache").Set(0)
 	err = parallel.Parallel(len(users), w.jobs, func(workerId, jobId int) error {
 		defer func() {
@@ -578,6 +586,10 @@ func (w *Worker) Recommend(users []data.User) {
 		}()
 		user := users[jobId]
 		userId := user.UserId
+		// skip frozen users
+		if frozenUserSet.Contains(userId) {
+			return nil
+		}
 		// skip inactive users before max recommend period
 		if !w.checkUserActiveTime(ctx, userId) || !w.checkRecommendCacheTimeout(ctx, userId, itemCategories) {
 			return nil
@@ -1222,10 +1234,10 @@ func (w *Worker) replacement(recommend map[string][]cache.Score, user *data.User
 	positiveItems := mapset.NewSet[string]()
 	distinctItems := mapset.NewSet[string]()
 	for _, feedback := range feedbacks {
-		if funk.ContainsString(w.Config.Recommend.DataSource.PositiveFeedbackTypes, feedback.FeedbackType) {
+		if funk.ContainsString(w.FeedbackTypes().Positive, feedback.FeedbackType) {
 			positiveItems.Add(feedback.ItemId)
 			distinctItems.Add(feedback.ItemId)
-		} else if funk.ContainsString(w.Config.Recommend.DataSource.ReadFeedbackTypes, feedback.FeedbackType) {
+		} else if funk.ContainsString(w.FeedbackTypes().Read, feedback.FeedbackType) {
 			distinctItems.Add(feedback.ItemId)
 		}
 	}
@@ -207,7 +207,7 @@ func (suite *WorkerTestSuite) TestRecommendMatrixFactorizationHNSW() {
 	suite.Config.Recommend.Offline.EnableColRecommend = true
 	// insert feedbacks
 	now := time.Now()
-	err := suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
+	_, err := suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "0", ItemId: "9"}, Timestamp: now.Add(-time.Hour)},
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "0", ItemId: "8"}, Timestamp: now.Add(-time.Hour)},
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "click", UserId: "0", ItemId: "7"}, Timestamp: now.Add(-time.Hour)},
@@ -253,7 +253,7 @@ func (suite *WorkerTestSuite) TestRecommendItemBased() {
 	suite.Config.Recommend.Offline.EnableColRecommend = false
 	suite.Config.Recommend.Offline.EnableItemBasedRecommend = true
 	// insert feedback
-	err := suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
+	_, err := suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "0", ItemId: "21"}},
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "0", ItemId: "22"}},
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "0", ItemId: "23"}},
@@ -336,18 +336,18 @@ func (suite *WorkerTestSuite) TestRecommendUserBased() {
 	})
 	suite.NoError(err)
 	// insert feedback
-	err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
+	_, err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "1", ItemId: "10"}},
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "1", ItemId: "11"}},
 	}, true, true, true)
 	suite.NoError(err)
-	err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
+	_, err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "2", ItemId: "10"}},
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "2", ItemId: "12"}},
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "2", ItemId: "48"}},
 	}, true, true, true)
 	suite.NoError(err)
-	err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
+	_, err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "3", ItemId: "10"}},
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "3", ItemId: "13"}},
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "a", UserId: "3", ItemId: "48"}},
@@ -431,6 +431,37 @@ func (suite *WorkerTestSuite) TestRecommendPopular() {
 	}, recommends)
 }
 
+func (suite *WorkerTestSuite) TestRecommendFrozenUsers() {
+	ctx := context.Background()
+	suite.Config.Recommend.Offline.EnableColRecommend = false
+	suite.Config.Recommend.Offline.EnablePopularRecommend = true
+	// insert popular items
+	err := suite.CacheClient.AddScores(ctx, cache.NonPersonalized, cache.Popular, []cache.Score{
+		{Id: "10", Score: 10, Categories: []string{""}},
+		{Id: "9", Score: 9, Categories: []string{""}},
+	})
+	suite.NoError(err)
+	err = suite.DataClient.BatchInsertItems(ctx, []data.Item{{ItemId: "10"}, {ItemId: "9"}})
+	suite.NoError(err)
+	// freeze recommendation of user 0
+	err = suite.CacheClient.AddScores(ctx, cache.OfflineRecommend, "0", []cache.Score{
+		{Id: "1", Score: 1, Categories: []string{""}, Timestamp: time.Now().Add(-time.Hour)},
+	})
+	suite.NoError(err)
+	err = suite.CacheClient.AddSet(ctx, cache.FrozenUsers, "0")
+	suite.NoError(err)
+	suite.RankingModel = newMockMatrixFactorizationForRecommend(2, 10)
+	suite.Recommend([]data.User{{UserId: "0"}, {UserId: "1"}})
+	// recommendation of the frozen user is unchanged
+	recommends, err := suite.CacheClient.SearchScores(ctx, cache.OfflineRecommend, "0", []string{""}, 0, -1)
+	suite.NoError(err)
+	suite.Equal([]string{"1"}, cache.ConvertDocumentsToValues(recommends))
+	// recommendation of other users is updated
+	recommends, err = suite.CacheClient.SearchScores(ctx, cache.OfflineRecommend, "1", []string{""}, 0, -1)
+	suite.NoError(err)
+	suite.Equal([]string{"10", "9"}, cache.ConvertDocumentsToValues(recommends))
+}
+
 func (suite *WorkerTestSuite) TestRecommendLatest() {
 	// create mock worker
 	ctx := context.Background()
@@ -859,7 +890,7 @@ func (suite *WorkerTestSuite) TestReplacement_ClickThroughRate() {
 	})
 	suite.NoError(err)
 	// insert feedback
-	err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
+	_, err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "p", UserId: "0", ItemId: "10"}},
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "n", UserId: "0", ItemId: "9"}},
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "i", UserId: "0", ItemId: "8"}},
@@ -889,7 +920,7 @@ func (suite *WorkerTestSuite) TestReplacement_ClickThroughRate() {
 	})
 	suite.NoError(err)
 	// insert feedback
-	err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
+	_, err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "p", UserId: "0", ItemId: "10"}},
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "n", UserId: "0", ItemId: "9"}},
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "i", UserId: "0", ItemId: "8"}},
@@ -924,7 +955,7 @@ func (suite *WorkerTestSuite) TestReplacement_CollaborativeFiltering() {
 	})
 	suite.NoError(err)
 	// insert feedback
-	err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
+	_, err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "p", UserId: "0", ItemId: "10"}},
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "n", UserId: "0", ItemId: "9"}},
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "i", UserId: "0", ItemId: "8"}},
@@ -953,7 +984,7 @@ func (suite *WorkerTestSuite) TestReplacement_CollaborativeFiltering() {
 		{Id: "5", Score: 8, Categories: []string{""}}})
 	suite.NoError(err)
 	// insert feedback
-	err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
+	_, err = suite.DataClient.BatchInsertFeedback(ctx, []data.Feedback{
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "p", UserId: "0", ItemId: "10"}},
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "n", UserId: "0", ItemId: "9"}},
 		{FeedbackKey: data.FeedbackKey{FeedbackType: "i", UserId: "0", ItemId: "8"}},